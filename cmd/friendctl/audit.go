@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// runAudit lists the audit log of the graph changes, newest first.
+func runAudit(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("audit")
+	user := fs.String("user", "", "only the changes where the user is the requestor or the target")
+	action := fs.String("action", "", "only the changes of the action, a domain event type")
+	from := timeFlag(fs, "from", "only the changes made at or after the RFC 3339 `time`")
+	to := timeFlag(fs, "to", "only the changes made before the RFC 3339 `time`")
+	limit := fs.Int("limit", 50, "maximum number of entries")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	auditService, err := a.auditService()
+	if err != nil {
+		return err
+	}
+	filter := repository.AuditFilter{User: *user, Action: *action, From: from.Time, To: to.Time}
+	entries, err := auditService.ListAuditEntries(ctx, filter, *limit, 0)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "TIME", "ACTOR", "ACTION", "REQUESTOR", "TARGET", "BEFORE", "AFTER"}}
+	for _, e := range entries {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(e.ID, 10), e.CreatedAt.Format(time.RFC3339), e.Actor, e.Action,
+			e.Requestor, e.Target, string(e.Before), string(e.After),
+		})
+	}
+	return a.print(entries, t)
+}
+
+// timeValue is a flag holding an RFC 3339 time, zero when unset.
+type timeValue struct {
+	time.Time
+}
+
+// timeFlag defines a time flag.
+func timeFlag(fs *flag.FlagSet, name, usage string) *timeValue {
+	v := &timeValue{}
+	fs.Var(v, name, usage)
+	return v
+}
+
+func (v *timeValue) String() string {
+	if v.IsZero() {
+		return ""
+	}
+	return v.Format(time.RFC3339)
+}
+
+func (v *timeValue) Set(s string) error {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return errors.New("must be an RFC 3339 time")
+	}
+	v.Time = t
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// runImport imports the relations of a CSV or JSON Lines file, printing the import report.
+// It fails when any row was not imported.
+func runImport(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("import")
+	format := fs.String("format", "", "file format, csv or jsonl (default guessed from the file extension)")
+	dryRun := fs.Bool("dry-run", false, "only validate the rows")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected a single file, - for standard input")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+
+	var in io.Reader = a.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	rows, err := bulk.Read(in, *format)
+	if err != nil {
+		return err
+	}
+
+	bulkService, err := a.bulkService()
+	if err != nil {
+		return err
+	}
+	report, err := bulkService.Import(ctx, rows, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if err := a.print(report, reportTable(report)); err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows not imported", report.Failed, report.Total)
+	}
+	return nil
+}
+
+// reportTable renders an import report as the table of the rows not imported, followed
+// by the totals.
+func reportTable(report *bulk.Report) table {
+	t := table{
+		header: []string{"LINE", "TYPE", "REQUESTOR", "TARGET", "ERROR"},
+		footer: fmt.Sprintf("total %d, valid %d, created %d, existing %d, failed %d, dry run %t",
+			report.Total, report.Valid, report.Created, report.Existing, report.Failed, report.DryRun),
+	}
+	for _, rowErr := range report.Errors {
+		t.rows = append(t.rows, []string{strconv.Itoa(rowErr.Line), rowErr.Type, rowErr.Requestor, rowErr.Target, rowErr.Error})
+	}
+	return t
+}
+
+// runExport exports the relations of a user, or of the whole graph, to a file.
+func runExport(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("export")
+	format := fs.String("format", "", "file format, csv or jsonl (default guessed from -o, else csv)")
+	email := fs.String("email", "", "export only the relations of this user")
+	output := fs.String("o", "-", "output file, - for standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = formatFromPath(*output)
+	}
+	if !bulk.IsValidFormat(*format) {
+		return errors.New(response.ErrMsgInvalidBulkFormat)
+	}
+
+	var out io.Writer = a.stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	writer, err := bulk.NewWriter(out, *format)
+	if err != nil {
+		return err
+	}
+
+	bulkService, err := a.bulkService()
+	if err != nil {
+		return err
+	}
+	if err := bulkService.Export(ctx, *email, writer.Write); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// formatFromPath guesses the file format from the extension of the path, csv by default.
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return bulk.FormatJSONL
+	}
+	return bulk.FormatCSV
+}
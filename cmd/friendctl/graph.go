@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/pkg/errors"
+)
+
+// runCreateUser creates a user.
+func runCreateUser(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("create-user")
+	name := fs.String("name", "", "name of the user (default the local part of the email)")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	userService, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := userService.CreateUser(ctx, *name, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return a.print(user, table{
+		header: []string{"ID", "NAME", "EMAIL"},
+		rows:   [][]string{{strconv.Itoa(user.ID), user.Name, user.Email}},
+	})
+}
+
+// runDeleteUser marks a user as deleted, they are purged once the retention period is over.
+func runDeleteUser(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("delete-user")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	userService, err := a.userService()
+	if err != nil {
+		return err
+	}
+	if err := userService.DeleteUser(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+	return a.printDone("user deleted")
+}
+
+// runSetStatus changes the account state of a user.
+func runSetStatus(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("set-status")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+
+	userService, err := a.userService()
+	if err != nil {
+		return err
+	}
+	user, err := userService.SetUserStatus(ctx, fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	return a.print(user, table{
+		header: []string{"ID", "EMAIL", "STATUS"},
+		rows:   [][]string{{strconv.Itoa(user.ID), user.Email, user.Status}},
+	})
+}
+
+// runPurge permanently deletes the users deleted for longer than the retention period.
+func runPurge(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("purge")
+	retention := fs.Duration("retention", a.cfg.Accounts.RetentionPeriod, "how long deleted users are kept")
+	if err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+
+	userService, err := a.userService()
+	if err != nil {
+		return err
+	}
+	n, err := userService.PurgeDeletedUsers(ctx, time.Now().Add(-*retention))
+	if err != nil {
+		return err
+	}
+	return a.print(map[string]int{"purged": n}, table{rows: [][]string{{strconv.Itoa(n) + " deleted users purged"}}})
+}
+
+// runConnect creates a friend connection between two users.
+func runConnect(ctx context.Context, a *app, args []string) error {
+	return runPairCommand(ctx, a, "connect", args, "friend connection created", service.FriendService.CreateFriend)
+}
+
+// runDisconnect removes the friend connection between two users.
+func runDisconnect(ctx context.Context, a *app, args []string) error {
+	return runPairCommand(ctx, a, "disconnect", args, "friend connection removed", service.FriendService.RemoveFriend)
+}
+
+// runSubscribe subscribes the requestor to updates from the target. The subscription is
+// pending until approved when the target is private.
+func runSubscribe(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("subscribe")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	status, err := friendService.SubscribeUpdates(ctx, fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if status == repository.SubscriptionStatusPending {
+		return a.printDone("subscription requested")
+	}
+	return a.printDone("subscribed")
+}
+
+// runApprove approves the pending subscription of the requestor to updates from the target.
+func runApprove(ctx context.Context, a *app, args []string) error {
+	return runPairCommand(ctx, a, "approve", args, "subscription approved", service.FriendService.ApproveSubscription)
+}
+
+// runDeny denies the pending subscription of the requestor to updates from the target.
+func runDeny(ctx context.Context, a *app, args []string) error {
+	return runPairCommand(ctx, a, "deny", args, "subscription denied", service.FriendService.DenySubscription)
+}
+
+// runSetPrivacy makes a user private or public.
+func runSetPrivacy(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("set-privacy")
+	policy := fs.String("policy", repository.FollowerPolicyKeep, "what happens to the existing subscribers of a user made private: keep, review or remove")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+	var private bool
+	switch fs.Arg(1) {
+	case "private":
+		private = true
+	case "public":
+	default:
+		return errors.Errorf("unknown privacy %q, use private or public", fs.Arg(1))
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	change, err := friendService.SetPrivacy(ctx, fs.Arg(0), private, *policy)
+	if err != nil {
+		return err
+	}
+	return a.print(change, table{
+		header: []string{"EMAIL", "PRIVATE", "POLICY", "SUBSCRIPTIONS CHANGED"},
+		rows:   [][]string{{change.Email, strconv.FormatBool(change.Private), change.Policy, strconv.Itoa(change.Subscriptions)}},
+	})
+}
+
+// runBlock blocks updates from the target to the requestor, for a while or until unblocked.
+func runBlock(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("block")
+	category := fs.String("category", repository.BlockCategoryOther, "what the block is for: "+strings.Join(repository.BlockCategories, ", "))
+	reason := fs.String("reason", "", "why the target is blocked")
+	duration := fs.Duration("for", 0, "how long the target stays blocked (default until unblocked)")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+	details := repository.BlockDetails{Category: *category, Reason: *reason}
+	if *duration > 0 {
+		t := time.Now().Add(*duration)
+		details.ExpiresAt = &t
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	if err := friendService.BlockUpdates(ctx, fs.Arg(0), fs.Arg(1), details); err != nil {
+		return err
+	}
+	return a.printDone("blocked")
+}
+
+// runUnblock lifts the block of the requestor on updates from the target.
+func runUnblock(ctx context.Context, a *app, args []string) error {
+	return runPairCommand(ctx, a, "unblock", args, "unblocked", service.FriendService.UnblockUpdates)
+}
+
+// runMute mutes the updates of the target for the requestor, for a while or until unmuted.
+func runMute(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("mute")
+	duration := fs.Duration("for", 0, "how long the target stays muted (default until unmuted)")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+	var expiresAt *time.Time
+	if *duration > 0 {
+		t := time.Now().Add(*duration)
+		expiresAt = &t
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	if err := friendService.MuteUpdates(ctx, fs.Arg(0), fs.Arg(1), expiresAt); err != nil {
+		return err
+	}
+	return a.printDone("muted")
+}
+
+// runUnmute lifts the mute of the requestor on updates from the target.
+func runUnmute(ctx context.Context, a *app, args []string) error {
+	return runPairCommand(ctx, a, "unmute", args, "unmuted", service.FriendService.UnmuteUpdates)
+}
+
+// runPairCommand runs a FriendService method taking two emails.
+func runPairCommand(ctx context.Context, a *app, name string, args []string, done string, fn func(service.FriendService, context.Context, string, string) error) error {
+	fs := newFlagSet(name)
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	if err := fn(friendService, ctx, fs.Arg(0), fs.Arg(1)); err != nil {
+		return err
+	}
+	return a.printDone(done)
+}
+
+// runFriends lists the friends of a user.
+func runFriends(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("friends")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	friends, err := friendService.GetFriendsList(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if friends == nil {
+		friends = []string{}
+	}
+	return a.print(map[string]interface{}{"friends": friends, "count": len(friends)}, listTable("FRIEND", friends))
+}
+
+// runGraph lists the friendships, subscriptions and blocks of a user.
+func runGraph(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("graph")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	bulkService, err := a.bulkService()
+	if err != nil {
+		return err
+	}
+	relations := []repository.Relation{}
+	err = bulkService.Export(ctx, fs.Arg(0), func(rel repository.Relation) error {
+		relations = append(relations, rel)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"TYPE", "REQUESTOR", "TARGET"}}
+	for _, rel := range relations {
+		t.rows = append(t.rows, []string{rel.Type, rel.Requestor, rel.Target})
+	}
+	return a.print(map[string]interface{}{"relations": relations, "count": len(relations)}, t)
+}
+
+// runRecipients computes the recipients of an update and the candidates excluded from them.
+func runRecipients(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("recipients")
+	if err := parseArgs(fs, args, 2); err != nil {
+		return err
+	}
+
+	friendService, err := a.friendService()
+	if err != nil {
+		return err
+	}
+	result, err := friendService.GetRecipients(ctx, fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"EMAIL", "STATUS", "SOURCES"}}
+	for _, r := range result.Recipients {
+		t.rows = append(t.rows, []string{r.Email, "recipient", strings.Join(r.Sources, ",")})
+	}
+	for _, e := range result.Excluded {
+		t.rows = append(t.rows, []string{e.Email, "excluded: " + e.Reason, strings.Join(e.Sources, ",")})
+	}
+	return a.print(result, t)
+}
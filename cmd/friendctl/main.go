@@ -0,0 +1,239 @@
+// Command friendctl operates the friend management service directly against its database.
+//
+// Usage:
+//
+//	friendctl [-output table|json] <command> [flags] [args]
+//
+// The database and logging are configured from the same environment variables as the server.
+// Run friendctl help for the list of commands.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"os/user"
+	"sort"
+	"syscall"
+
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/db"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/pkg/errors"
+)
+
+// command is a friendctl subcommand.
+type command struct {
+	usage string
+	// run runs the command with the arguments following its name.
+	run func(ctx context.Context, app *app, args []string) error
+}
+
+// commands are the friendctl subcommands by name. It is set in init as the commands
+// look up their usage line in it.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"create-user":      {usage: "create-user [-name name] <email>", run: runCreateUser},
+		"delete-user":      {usage: "delete-user <email>", run: runDeleteUser},
+		"set-status":       {usage: "set-status <email> <active|deactivated|suspended|deleted>", run: runSetStatus},
+		"purge":            {usage: "purge [-retention duration]", run: runPurge},
+		"export-user-data": {usage: "export-user-data <email>", run: runExportUserData},
+		"erase-user":       {usage: "erase-user [-mode delete|anonymize] [-reason reason] <email>", run: runEraseUser},
+		"connect":          {usage: "connect <email> <email>", run: runConnect},
+		"disconnect":       {usage: "disconnect <email> <email>", run: runDisconnect},
+		"friends":          {usage: "friends <email>", run: runFriends},
+		"subscribe":        {usage: "subscribe <requestor> <target>", run: runSubscribe},
+		"approve":          {usage: "approve <requestor> <target>", run: runApprove},
+		"deny":             {usage: "deny <requestor> <target>", run: runDeny},
+		"set-privacy":      {usage: "set-privacy [-policy keep|review|remove] <email> <private|public>", run: runSetPrivacy},
+		"block":            {usage: "block [-category category] [-reason reason] [-for duration] <requestor> <target>", run: runBlock},
+		"unblock":          {usage: "unblock <requestor> <target>", run: runUnblock},
+		"mute":             {usage: "mute [-for duration] <requestor> <target>", run: runMute},
+		"unmute":           {usage: "unmute <requestor> <target>", run: runUnmute},
+		"graph":            {usage: "graph <email>", run: runGraph},
+		"recipients":       {usage: "recipients <sender> <text>", run: runRecipients},
+		"migrate":          {usage: "migrate [-to version] <up|down|status>", run: runMigrate},
+		"import":           {usage: "import [-format csv|jsonl] [-dry-run] <file|->", run: runImport},
+		"export":           {usage: "export [-format csv|jsonl] [-email email] [-o file]", run: runExport},
+		"audit":            {usage: "audit [-user email] [-action action] [-from time] [-to time] [-limit n]", run: runAudit},
+		"token":            {usage: "token [-admin] [-ttl duration] <email>", run: runToken},
+	}
+}
+
+// app holds what the commands share.
+type app struct {
+	cfg config.Config
+	// output is the format results are printed in, table or json.
+	output string
+	conn   *sql.DB
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// db returns the database connection, opened on first use so that commands can print
+// their usage without a database.
+func (a *app) db() (*sql.DB, error) {
+	if a.conn == nil {
+		conn, err := db.ConnectDB(a.cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		a.conn = conn
+	}
+	return a.conn, nil
+}
+
+// close closes the database connection, if opened.
+func (a *app) close() {
+	if a.conn != nil {
+		a.conn.Close()
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command named by the first argument and returns the exit status.
+func run(args []string) int {
+	global := flag.NewFlagSet("friendctl", flag.ContinueOnError)
+	global.Usage = func() { usage(global.Output()) }
+	output := global.String("output", outputTable, "output format, table or json")
+	if err := global.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if err := validateOutput(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "friendctl: %v\n", err)
+		return 2
+	}
+	args = global.Args()
+
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(os.Stdout)
+		return 0
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "friendctl: unknown command %q\n\n", args[0])
+		usage(os.Stderr)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "friendctl: %v\n", err)
+		return 1
+	}
+	logger.New(cfg.Log)
+
+	// Changes made by friendctl are audited as made by the operating system user
+	ctx = audit.NewContext(ctx, audit.Source{Actor: actor()})
+
+	a := &app{cfg: cfg, output: *output, stdin: os.Stdin, stdout: os.Stdout}
+	defer a.close()
+	if err := cmd.run(ctx, a, args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "friendctl %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: friendctl [-output table|json] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+// parseArgs parses the flags of a command and checks it is given n arguments.
+func parseArgs(fs *flag.FlagSet, args []string, n int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != n {
+		fs.Usage()
+		return errors.Errorf("expected %d arguments, got %d", n, fs.NArg())
+	}
+	return nil
+}
+
+// friendService creates a FriendService using the database.
+func (a *app) friendService() (service.FriendService, error) {
+	conn, err := a.db()
+	if err != nil {
+		return nil, err
+	}
+	return service.NewFriendService(repository.NewFriendRepository(conn)), nil
+}
+
+// userService creates a UserService using the database.
+func (a *app) userService() (service.UserService, error) {
+	conn, err := a.db()
+	if err != nil {
+		return nil, err
+	}
+	return service.NewUserService(repository.NewUserRepository(conn), a.cfg.Accounts), nil
+}
+
+// bulkService creates a BulkService using the database.
+func (a *app) bulkService() (service.BulkService, error) {
+	conn, err := a.db()
+	if err != nil {
+		return nil, err
+	}
+	return service.NewBulkService(repository.NewBulkRepository(conn)), nil
+}
+
+// auditService creates an AuditService using the database.
+func (a *app) auditService() (service.AuditService, error) {
+	conn, err := a.db()
+	if err != nil {
+		return nil, err
+	}
+	return service.NewAuditService(repository.NewAuditRepository(conn)), nil
+}
+
+// actor names the operating system user running friendctl in the audit log.
+func actor() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return "friendctl:" + name
+}
+
+// newFlagSet creates the flag set of a command, printing its usage line on errors.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: friendctl %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
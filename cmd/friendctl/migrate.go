@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/boldnguyen/friend-management/data/migrations"
+	"github.com/boldnguyen/friend-management/internal/pkg/db"
+	"github.com/pkg/errors"
+)
+
+// runMigrate applies or reverts the embedded migrations, or reports the schema version.
+// up migrates to the latest version and down reverts the last migration, unless -to
+// gives the version to migrate to.
+func runMigrate(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("migrate")
+	to := fs.Int("to", -1, "version to migrate to, 0 reverting every migration")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	all, err := migrations.All()
+	if err != nil {
+		return err
+	}
+	conn, err := a.db()
+	if err != nil {
+		return err
+	}
+	current, dirty, err := db.SchemaVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	target := *to
+	switch fs.Arg(0) {
+	case "status":
+		return a.printStatus(all, current, dirty)
+	case "up":
+		if target < 0 && len(all) > 0 {
+			target = all[len(all)-1].Version
+		}
+		if target < current {
+			return errors.Errorf("version %d is below the current version %d, use down", target, current)
+		}
+	case "down":
+		if target < 0 {
+			target = previousVersion(all, current)
+		}
+		if target > current {
+			return errors.Errorf("version %d is above the current version %d, use up", target, current)
+		}
+	default:
+		fs.Usage()
+		return errors.Errorf("unknown migrate action %q", fs.Arg(0))
+	}
+
+	ran, err := db.Migrate(ctx, conn, all, target)
+	if err != nil {
+		return err
+	}
+	if ran == nil {
+		ran = []int{}
+	}
+
+	t := table{header: []string{"VERSION"}, footer: "schema at version " + strconv.Itoa(target)}
+	for _, v := range ran {
+		t.rows = append(t.rows, []string{strconv.Itoa(v)})
+	}
+	return a.print(map[string]interface{}{"action": fs.Arg(0), "ran": ran, "version": target}, t)
+}
+
+// printStatus prints the embedded migrations and whether they are applied.
+func (a *app) printStatus(all []migrations.Migration, current int, dirty bool) error {
+	type status struct {
+		Version int    `json:"version"`
+		Name    string `json:"name"`
+		Applied bool   `json:"applied"`
+	}
+
+	statuses := make([]status, 0, len(all))
+	t := table{header: []string{"VERSION", "NAME", "APPLIED"}, footer: "schema at version " + strconv.Itoa(current)}
+	if dirty {
+		t.footer += " (dirty)"
+	}
+	for _, m := range all {
+		s := status{Version: m.Version, Name: m.Name, Applied: m.Version <= current}
+		statuses = append(statuses, s)
+		t.rows = append(t.rows, []string{strconv.Itoa(s.Version), s.Name, strconv.FormatBool(s.Applied)})
+	}
+	return a.print(map[string]interface{}{"version": current, "dirty": dirty, "migrations": statuses}, t)
+}
+
+// previousVersion returns the version of the migration preceding the current one, 0 when
+// there is none.
+func previousVersion(all []migrations.Migration, current int) int {
+	previous := 0
+	for _, m := range all {
+		if m.Version >= current {
+			break
+		}
+		previous = m.Version
+	}
+	return previous
+}
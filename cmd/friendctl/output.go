@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// table is the tabular rendering of a command result.
+type table struct {
+	header []string
+	rows   [][]string
+	// footer is printed after the rows.
+	footer string
+}
+
+// print prints the result of a command, v as JSON or t as a table depending on the output
+// format.
+func (a *app) print(v interface{}, t table) error {
+	if a.output == outputJSON {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if t.footer != "" {
+		_, err := fmt.Fprintln(a.stdout, t.footer)
+		return err
+	}
+	return nil
+}
+
+// printDone reports the success of a command that has no result.
+func (a *app) printDone(message string) error {
+	return a.print(map[string]interface{}{"success": true, "message": message}, table{rows: [][]string{{message}}})
+}
+
+// validateOutput checks the output format.
+func validateOutput(output string) error {
+	if output != outputTable && output != outputJSON {
+		return errors.Errorf("output must be %s or %s", outputTable, outputJSON)
+	}
+	return nil
+}
+
+// listTable renders a list of values as a single column table.
+func listTable(name string, values []string) table {
+	t := table{header: []string{name}}
+	for _, v := range values {
+		t.rows = append(t.rows, []string{v})
+	}
+	return t
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+)
+
+// runToken prints a bearer token authenticating the API requests as the given user.
+func runToken(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("token")
+	admin := fs.Bool("admin", false, "grant administrator access")
+	ttl := fs.Duration("ttl", a.cfg.Auth.TokenTTL, "how long the token is valid")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(*ttl)
+	token, err := auth.NewToken(a.cfg.Auth.Secret, auth.Principal{Email: fs.Arg(0), Admin: *admin}, expiresAt)
+	if err != nil {
+		return err
+	}
+	return a.print(map[string]interface{}{"token": token, "expires_at": expiresAt}, table{rows: [][]string{{token}}})
+}
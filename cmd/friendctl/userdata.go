@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+)
+
+// runExportUserData prints all the data held about a user. The table output summarizes the
+// archive, the json output holds it in full.
+func runExportUserData(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("export-user-data")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	userService, err := a.userService()
+	if err != nil {
+		return err
+	}
+	archive, err := userService.ExportUserData(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"DATA", "COUNT"}}
+	for _, row := range []struct {
+		name  string
+		count int
+	}{
+		{"friends", len(archive.Friends)},
+		{"subscriptions", len(archive.Subscriptions)},
+		{"subscribers", len(archive.Subscribers)},
+		{"blocking", len(archive.Blocking)},
+		{"blocked_by", len(archive.BlockedBy)},
+		{"updates", len(archive.Updates)},
+	} {
+		t.rows = append(t.rows, []string{row.name, strconv.Itoa(row.count)})
+	}
+	return a.print(archive, t)
+}
+
+// runEraseUser deletes or anonymizes a user and prints the audit record of the erasure.
+func runEraseUser(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("erase-user")
+	mode := fs.String("mode", repository.ErasureModeDelete, "erasure mode, delete or anonymize")
+	reason := fs.String("reason", "", "reason of the erasure, kept in the audit record")
+	if err := parseArgs(fs, args, 1); err != nil {
+		return err
+	}
+
+	userService, err := a.userService()
+	if err != nil {
+		return err
+	}
+	erasure, err := userService.EraseUser(ctx, fs.Arg(0), *mode, *reason)
+	if err != nil {
+		return err
+	}
+	return a.print(erasure, table{
+		header: []string{"ERASURE", "USER ID", "MODE", "FRIENDSHIPS", "SUBSCRIPTIONS", "BLOCKS", "UPDATES"},
+		rows: [][]string{{
+			strconv.FormatInt(erasure.ID, 10), strconv.Itoa(erasure.UserID), erasure.Mode,
+			strconv.Itoa(erasure.Friendships), strconv.Itoa(erasure.Subscriptions),
+			strconv.Itoa(erasure.Blocks), strconv.Itoa(erasure.Updates),
+		}},
+	})
+}
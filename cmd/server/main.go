@@ -1,55 +1,232 @@
 package main
 
 import (
-	"log"
+	"context"
+	"database/sql"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/analytics"
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/blocks"
+	"github.com/boldnguyen/friend-management/internal/fanout"
 	"github.com/boldnguyen/friend-management/internal/handler"
+	"github.com/boldnguyen/friend-management/internal/outbox"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
 	"github.com/boldnguyen/friend-management/internal/pkg/db"
+	"github.com/boldnguyen/friend-management/internal/pkg/health"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/metrics"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/purge"
 	"github.com/boldnguyen/friend-management/internal/repository"
 	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/boldnguyen/friend-management/internal/stats"
+	"github.com/boldnguyen/friend-management/internal/stream"
+	"github.com/boldnguyen/friend-management/internal/webhook"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 )
 
+// shutdownTimeout bounds the graceful shutdown of the HTTP server.
+const shutdownTimeout = 15 * time.Second
+
 func main() {
+	// Cancelled on SIGINT/SIGTERM to stop the server and background workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	// Init logger
+	l := logger.New(cfg.Log)
+
+	// Init tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+	defer shutdownTracing(context.Background())
+
 	// Open DB connection
-	db, err := db.ConnectDB("postgres://friend-management:1234@db:5432/friend-management?sslmode=disable")
+	db, err := db.ConnectDB(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatal("Faild to connect to the database", err)
+		l.Fatal().Err(err).Msg("Faild to connect to the database")
 	}
 	defer db.Close()
+	metrics.RegisterDB(db, "friend-management")
 
 	// Initialize friend repository and service with the database connection
 	friendRepository := repository.NewFriendRepository(db)
 	friendService := service.NewFriendService(friendRepository)
+	webhookRepository := repository.NewWebhookRepository(db)
+	webhookService := service.NewWebhookService(webhookRepository, cfg.Webhooks)
+	updateRepository := repository.NewUpdateRepository(db)
+	fanoutQueue := make(chan int64, cfg.Updates.QueueSize)
+	hub := stream.NewHub(cfg.Stream)
+	updateService := service.NewUpdateService(updateRepository, friendRepository, friendService, hub, fanoutQueue, cfg.Updates.AsyncThreshold)
+	userService := service.NewUserService(repository.NewUserRepository(db), cfg.Accounts)
+	bulkService := service.NewBulkService(repository.NewBulkRepository(db))
+	auditService := service.NewAuditService(repository.NewAuditRepository(db))
+	analyticsService := service.NewAnalyticsService(repository.NewAnalyticsRepository(db), cfg.Analytics.TopUsers)
+	statsService := service.NewStatsService(repository.NewStatsRepository(db))
+	graphService := service.NewGraphService(repository.NewGraphRepository(db), friendRepository, cfg.Graph.MaxPathDepth)
+
+	// Register readiness checks
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	checker.Register("database", health.DBPing(db))
+	checker.Register("schema", health.SchemaVersion(db))
+
+	// Start background workers
+	sinks, err := outbox.NewSinks(cfg.Outbox)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Failed to create event sinks")
+	}
+	// Events are always fanned out to the registered webhooks
+	sinks = append(sinks, webhook.NewSink(webhookRepository))
+	relay := outbox.NewRelay(repository.NewOutboxRepository(db), sinks, cfg.Outbox)
+	deliverer := webhook.NewDeliverer(webhookRepository, cfg.Webhooks)
+	fanoutWorker := fanout.NewWorker(updateService, updateRepository, fanoutQueue, cfg.Updates)
+	purger := purge.NewPurger(userService, cfg.Accounts)
+	snapshotter := analytics.NewSnapshotter(analyticsService, cfg.Analytics)
+	reconciler := stats.NewReconciler(statsService, cfg.Stats)
+	blockSweeper := blocks.NewSweeper(friendService, cfg.Blocks)
+
+	var workers sync.WaitGroup
+	workers.Add(7)
+	go func() {
+		defer workers.Done()
+		relay.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		deliverer.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		fanoutWorker.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		purger.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		snapshotter.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		reconciler.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		blockSweeper.Run(ctx)
+	}()
 
 	// Init Router
-	r := initRouter(friendService)
+	r := initRouter(l, db, checker, friendService, webhookService, updateService, userService, bulkService, auditService, graphService, analyticsService, statsService, hub, cfg.Stream, cfg.Auth)
 
 	// Start server
-	log.Println("Starting app at port: 5000")
-	if err := http.ListenAndServe(":5000", r); err != nil {
-		log.Println("Server error", err)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	// Streams never go idle, end them so the shutdown does not wait for them
+	srv.RegisterOnShutdown(hub.Close)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	l.Info().Msgf("Starting app at port: %s", cfg.Port)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		l.Error().Err(err).Msg("Server error")
 	}
 
+	stop()
+	workers.Wait()
 }
 
 // The initRouter function is used to set up and configure the routes for my web application using the Chi router.
-func initRouter(friendService service.FriendService) *chi.Mux {
+func initRouter(l zerolog.Logger, db *sql.DB, checker *health.Checker, friendService service.FriendService, webhookService service.WebhookService, updateService service.UpdateService, userService service.UserService, bulkService service.BulkService, auditService service.AuditService, graphService service.GraphService, analyticsService service.AnalyticsService, statsService service.StatsService, hub *stream.Hub, streamCfg config.Stream, authCfg config.Auth) *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	// RequestLogger also installs the RequestID and Recoverer middlewares
+	r.Use(logger.RequestLogger(l, []string{"/metrics", "/healthz", "/readyz"}))
+	r.Use(metrics.Middleware)
+	r.Use(tracing.Middleware)
+	r.Use(auth.Middleware(authCfg.Secret))
+	r.Use(audit.Middleware)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	})
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", handler.LivenessHandler())
+	r.Get("/readyz", handler.ReadinessHandler(checker))
+	r.Get("/status", handler.StatusHandler(checker, db))
 	r.Post("/friend/create", handler.NewHandler(friendService))
 	r.Post("/friend/list", handler.FriendListHandler(friendService))
 	r.Post("/friend/common", handler.CommonFriendsHandler(friendService))
 	r.Post("/friend/subscribe", handler.SubscribeHandler(friendService))
+	r.With(auth.RequireUser).Post("/friend/subscribe/approve", handler.ApproveSubscriptionHandler(friendService))
+	r.With(auth.RequireUser).Post("/friend/subscribe/deny", handler.DenySubscriptionHandler(friendService))
 	r.Post("/friend/block", handler.BlockUpdatesHandler(friendService))
+	r.Post("/friend/mute", handler.MuteHandler(friendService))
+	r.Post("/friend/unmute", handler.UnmuteHandler(friendService))
+	r.Post("/friend/path", handler.FriendPathHandler(graphService))
+	r.Post("/friend/relationships", handler.RelationshipsHandler(friendService))
 	r.Post("/recipients", handler.GetRecipientsHandler(friendService)) // New endpoint
 
+	r.Route("/webhooks", func(r chi.Router) {
+		// Webhooks receive the events of every user
+		r.Use(auth.RequireAdmin)
+		r.Post("/", handler.RegisterWebhookHandler(webhookService))
+		r.Get("/", handler.ListWebhooksHandler(webhookService))
+		r.Delete("/{id}", handler.DeleteWebhookHandler(webhookService))
+		r.Get("/{id}/deliveries", handler.WebhookDeliveriesHandler(webhookService))
+		r.Get("/{id}/dead-letters", handler.WebhookDeadLettersHandler(webhookService))
+	})
+
+	r.Post("/updates", handler.PostUpdateHandler(updateService))
+	r.With(auth.RequireOwner(false)).Get("/users/{email}/inbox", handler.InboxHandler(updateService))
+	r.Get("/users/{email}/suggestions", handler.FriendSuggestionsHandler(friendService))
+	r.Get("/users/{email}/stats", handler.UserStatsHandler(statsService))
+	r.With(auth.RequireOwner(false)).Put("/users/{email}/privacy", handler.SetPrivacyHandler(friendService))
+	r.With(auth.RequireOwner(false)).Get("/users/{email}/subscription-requests", handler.SubscriptionRequestsHandler(friendService))
+	r.With(auth.RequireOwner(false)).Get("/users/{email}/mutes", handler.MutesHandler(friendService))
+	r.With(auth.RequireOwner(true)).Get("/users/{email}/data", handler.UserDataHandler(userService))
+	r.With(auth.RequireOwner(true)).Delete("/users/{email}", handler.EraseUserHandler(userService))
+	r.Get("/stream", handler.StreamHandler(hub, streamCfg))
+
+	r.Route("/bulk", func(r chi.Router) {
+		// Bulk operations read and write the relations of every user
+		r.Use(auth.RequireAdmin)
+		r.Post("/import", handler.ImportHandler(bulkService))
+		r.Get("/export", handler.ExportHandler(bulkService))
+	})
+
+	r.Route("/v2", func(r chi.Router) {
+		r.Get("/users/{a}/path/{b}", handler.UserPathHandler(graphService))
+		r.Get("/users/{a}/relationship/{b}", handler.RelationshipHandler(friendService))
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(auth.RequireAdmin)
+		r.Get("/users/duplicate-emails", handler.DuplicateEmailsHandler(userService))
+		r.Put("/users/{email}/status", handler.SetUserStatusHandler(userService))
+		r.Get("/audit", handler.AuditLogHandler(auditService))
+		r.Get("/analytics", handler.AnalyticsHandler(analyticsService))
+	})
+
 	return r
 
 }
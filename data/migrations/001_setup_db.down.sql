@@ -1,10 +1,8 @@
--- Drop friend_connections table
-DROP TABLE IF EXISTS friend_connections;
--- Drop users table
-DROP TABLE IF EXISTS users;
 -- Drop blocks table
 DROP TABLE IF EXISTS blocks;
 -- Drop subscriptions table
-DROP TABLE IF EXISTS subscriptions; 
-
-
+DROP TABLE IF EXISTS subscriptions;
+-- Drop friend_connections table
+DROP TABLE IF EXISTS friend_connections;
+-- Drop users table, once no table references it
+DROP TABLE IF EXISTS users;
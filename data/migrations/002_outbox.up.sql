@@ -0,0 +1,14 @@
+-- Create outbox table holding the domain events written in the same transaction as the change
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW() -- Set from the relay clock, compared with NOW()
+);
+
+-- Speeds up polling for events still to be published
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE published_at IS NULL;
@@ -0,0 +1,6 @@
+-- Drop webhook_dead_letters table
+DROP TABLE IF EXISTS webhook_dead_letters;
+-- Drop webhook_deliveries table
+DROP TABLE IF EXISTS webhook_deliveries;
+-- Drop webhooks table
+DROP TABLE IF EXISTS webhooks;
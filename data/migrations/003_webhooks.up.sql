@@ -0,0 +1,45 @@
+-- Create webhooks table holding the endpoints notified of graph changes
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- Empty means every event type
+    emails TEXT[] NOT NULL DEFAULT '{}', -- Empty means events about any user
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create webhook_deliveries table, one row per event sent to a webhook
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, delivered or dead
+    attempts INT NOT NULL DEFAULT 0,
+    last_status_code INT,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Set from the deliverer clock, compared with NOW()
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (webhook_id, event_id), -- Makes enqueuing the same event twice a no-op
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- Create webhook_dead_letters table holding the deliveries that exhausted their retries
+CREATE TABLE webhook_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL UNIQUE,
+    webhook_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL,
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
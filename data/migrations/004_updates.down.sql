@@ -0,0 +1,4 @@
+-- Drop inbox_items table
+DROP TABLE IF EXISTS inbox_items;
+-- Drop updates table
+DROP TABLE IF EXISTS updates;
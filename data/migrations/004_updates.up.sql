@@ -0,0 +1,28 @@
+-- Create updates table holding the posts sent by users
+CREATE TABLE updates (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id INT NOT NULL,
+    text TEXT NOT NULL,
+    fanout_status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, done or failed
+    recipient_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Compared with the sweep cutoff of the fan-out worker
+    fanned_out_at TIMESTAMP,
+    FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Speeds up looking for updates whose fan-out did not complete
+CREATE INDEX updates_pending_idx ON updates (created_at) WHERE fanout_status = 'pending';
+
+-- Create inbox_items table, one row per update delivered to a user
+CREATE TABLE inbox_items (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    update_id BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, update_id), -- Makes delivering the same update twice a no-op
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (update_id) REFERENCES updates(id) ON DELETE CASCADE
+);
+
+-- Serves the inbox feed, newest first
+CREATE INDEX inbox_items_feed_idx ON inbox_items (user_id, update_id DESC);
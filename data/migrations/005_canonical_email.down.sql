@@ -0,0 +1,10 @@
+-- Drop user_email_duplicates view
+DROP VIEW IF EXISTS user_email_duplicates;
+-- Drop the canonical email index, the canonicalized emails are kept
+DROP INDEX IF EXISTS users_email_lower_idx;
+-- Restore the subscriptions foreign keys
+ALTER TABLE IF EXISTS subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_requestor_fkey,
+    DROP CONSTRAINT IF EXISTS subscriptions_target_fkey,
+    ADD CONSTRAINT subscriptions_requestor_fkey FOREIGN KEY (requestor) REFERENCES users(email),
+    ADD CONSTRAINT subscriptions_target_fkey FOREIGN KEY (target) REFERENCES users(email);
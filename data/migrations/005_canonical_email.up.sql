@@ -0,0 +1,27 @@
+-- Follow the users whose email is canonicalized below
+ALTER TABLE subscriptions
+    DROP CONSTRAINT subscriptions_requestor_fkey,
+    DROP CONSTRAINT subscriptions_target_fkey,
+    ADD CONSTRAINT subscriptions_requestor_fkey FOREIGN KEY (requestor) REFERENCES users(email) ON UPDATE CASCADE,
+    ADD CONSTRAINT subscriptions_target_fkey FOREIGN KEY (target) REFERENCES users(email) ON UPDATE CASCADE;
+
+-- Canonicalize the emails that do not collide with another user once lowercased,
+-- the others are listed by the user_email_duplicates view to be merged by hand
+UPDATE users u
+SET email = LOWER(TRIM(u.email)), updated_at = NOW()
+WHERE u.email <> LOWER(TRIM(u.email))
+  AND NOT EXISTS (
+      SELECT 1 FROM users o
+      WHERE o.id <> u.id AND LOWER(TRIM(o.email)) = LOWER(TRIM(u.email))
+  );
+
+-- Emails are looked up by their canonical form
+CREATE INDEX users_email_lower_idx ON users (LOWER(email));
+
+-- Users sharing the same canonical email
+CREATE VIEW user_email_duplicates AS
+SELECT LOWER(TRIM(email)) AS canonical_email, id, email, created_at
+FROM users
+WHERE LOWER(TRIM(email)) IN (
+    SELECT LOWER(TRIM(email)) FROM users GROUP BY LOWER(TRIM(email)) HAVING COUNT(*) > 1
+);
@@ -0,0 +1,2 @@
+-- Drop user_erasures table
+DROP TABLE IF EXISTS user_erasures;
@@ -0,0 +1,18 @@
+-- Create user_erasures table, the audit trail of the users erased on request. The email is
+-- only kept as a keyed hash so the record itself holds no personal data.
+CREATE TABLE user_erasures (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL, -- Not a foreign key, the user may be gone
+    email_hash CHAR(64) NOT NULL, -- Hex HMAC-SHA256 of the canonical email, keyed with ACCOUNTS_ERASURE_KEY
+    mode VARCHAR(20) NOT NULL, -- delete or anonymize
+    reason TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    friendships INT NOT NULL DEFAULT 0,
+    subscriptions INT NOT NULL DEFAULT 0,
+    blocks INT NOT NULL DEFAULT 0,
+    updates INT NOT NULL DEFAULT 0,
+    erased_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Looks up the erasures of an email
+CREATE INDEX user_erasures_email_hash_idx ON user_erasures (email_hash);
@@ -0,0 +1,5 @@
+-- Drop the account state of the users, the accounts pending purge become active again
+DROP INDEX IF EXISTS users_deleted_idx;
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS status_changed_at;
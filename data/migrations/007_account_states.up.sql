@@ -0,0 +1,9 @@
+-- Add the account state of the users. Only active users appear in friend lists, common
+-- friends and recipients; the others keep their relations so they can be restored.
+ALTER TABLE users
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'deactivated', 'suspended', 'deleted')),
+    ADD COLUMN status_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Speeds up looking for the deleted accounts to purge
+CREATE INDEX users_deleted_idx ON users (status_changed_at) WHERE status = 'deleted';
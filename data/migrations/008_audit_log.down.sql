@@ -0,0 +1,3 @@
+-- Drop audit_log table along with its triggers
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,39 @@
+-- Create audit_log table, one row per mutation of the social graph. Emails are stored as is
+-- rather than as foreign keys so the log outlives the users; erasing a user replaces their
+-- email with a pseudonym.
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor TEXT NOT NULL DEFAULT '', -- Who made the change, empty when unknown
+    action VARCHAR(100) NOT NULL, -- The type of the domain event recorded with the change
+    requestor VARCHAR(255) NOT NULL,
+    target VARCHAR(255) NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    client_ip TEXT NOT NULL DEFAULT '',
+    before JSONB NOT NULL DEFAULT '{}', -- State of the relation before the change
+    after JSONB NOT NULL DEFAULT '{}', -- State of the relation after the change
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Speeds up listing the entries by time, overall and per user
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+CREATE INDEX audit_log_requestor_idx ON audit_log (requestor, created_at);
+CREATE INDEX audit_log_target_idx ON audit_log (target, created_at);
+
+-- Keep the audit log append-only. The only update allowed is the redaction of the emails of an
+-- erased user, made in a transaction setting audit_log.redact to on, which may change nothing
+-- but the actor, the requestor and the target.
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_setting('audit_log.redact', true) = 'on'
+        AND (NEW.id, NEW.action, NEW.request_id, NEW.client_ip, NEW.before, NEW.after, NEW.created_at)
+            IS NOT DISTINCT FROM (OLD.id, OLD.action, OLD.request_id, OLD.client_ip, OLD.before, OLD.after, OLD.created_at) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE PROCEDURE audit_log_append_only();
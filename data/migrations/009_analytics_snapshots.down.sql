@@ -0,0 +1,2 @@
+-- Drop analytics_snapshots table
+DROP TABLE IF EXISTS analytics_snapshots;
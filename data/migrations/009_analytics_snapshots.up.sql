@@ -0,0 +1,9 @@
+-- Create analytics_snapshots table, the reports on the social graph computed periodically
+CREATE TABLE analytics_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    report JSONB NOT NULL,
+    computed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Finds the latest snapshot
+CREATE INDEX analytics_snapshots_computed_at_idx ON analytics_snapshots (computed_at);
@@ -0,0 +1,10 @@
+-- Drop the counting triggers and the user_stats table
+DROP TRIGGER IF EXISTS blocks_count ON blocks;
+DROP TRIGGER IF EXISTS subscriptions_count ON subscriptions;
+DROP TRIGGER IF EXISTS friend_connections_count ON friend_connections;
+DROP TRIGGER IF EXISTS users_add_stats ON users;
+DROP FUNCTION IF EXISTS user_stats_count_blocks();
+DROP FUNCTION IF EXISTS user_stats_count_subscriptions();
+DROP FUNCTION IF EXISTS user_stats_count_friends();
+DROP FUNCTION IF EXISTS user_stats_add_user();
+DROP TABLE IF EXISTS user_stats;
@@ -0,0 +1,86 @@
+-- Create user_stats table, the counters of the relations of every user kept up to date by the
+-- triggers below. Drift is repaired by the periodic reconciliation.
+CREATE TABLE user_stats (
+    user_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    friends INT NOT NULL DEFAULT 0,
+    followers INT NOT NULL DEFAULT 0, -- Users subscribed to the updates of the user
+    following INT NOT NULL DEFAULT 0, -- Users whose updates the user subscribed to
+    blocked INT NOT NULL DEFAULT 0, -- Users the user blocked
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Count the relations of the existing users
+INSERT INTO user_stats (user_id, friends, followers, following, blocked)
+SELECT u.id,
+    (SELECT COUNT(*) FROM friend_connections f WHERE u.id IN (f.user_id1, f.user_id2)),
+    (SELECT COUNT(*) FROM subscriptions s WHERE s.target = u.email),
+    (SELECT COUNT(*) FROM subscriptions s WHERE s.requestor = u.email),
+    (SELECT COUNT(*) FROM blocks b WHERE b.requestor = u.id)
+FROM users u;
+
+-- Every user gets counters
+CREATE FUNCTION user_stats_add_user() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO user_stats (user_id) VALUES (NEW.id) ON CONFLICT (user_id) DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_add_stats AFTER INSERT ON users
+    FOR EACH ROW EXECUTE PROCEDURE user_stats_add_user();
+
+-- Count the friendships of both users
+CREATE FUNCTION user_stats_count_friends() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        UPDATE user_stats SET friends = friends - 1, updated_at = NOW()
+        WHERE user_id IN (OLD.user_id1, OLD.user_id2);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE user_stats SET friends = friends + 1, updated_at = NOW()
+        WHERE user_id IN (NEW.user_id1, NEW.user_id2);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER friend_connections_count AFTER INSERT OR UPDATE OR DELETE ON friend_connections
+    FOR EACH ROW EXECUTE PROCEDURE user_stats_count_friends();
+
+-- Count the subscriptions of the requestor and the target. Subscriptions only change on
+-- update when an email is renamed, which does not change the counters.
+CREATE FUNCTION user_stats_count_subscriptions() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE user_stats SET following = following + 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = NEW.requestor);
+        UPDATE user_stats SET followers = followers + 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = NEW.target);
+    ELSE
+        UPDATE user_stats SET following = following - 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = OLD.requestor);
+        UPDATE user_stats SET followers = followers - 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = OLD.target);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_count AFTER INSERT OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE PROCEDURE user_stats_count_subscriptions();
+
+-- Count the blocks of the requestor
+CREATE FUNCTION user_stats_count_blocks() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        UPDATE user_stats SET blocked = blocked - 1, updated_at = NOW() WHERE user_id = OLD.requestor;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE user_stats SET blocked = blocked + 1, updated_at = NOW() WHERE user_id = NEW.requestor;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER blocks_count AFTER INSERT OR UPDATE OR DELETE ON blocks
+    FOR EACH ROW EXECUTE PROCEDURE user_stats_count_blocks();
@@ -0,0 +1,40 @@
+-- Drop the privacy of the users. Pending subscriptions are deleted rather than approved.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'subscriptions' AND column_name = 'status') THEN
+        DELETE FROM subscriptions WHERE status = 'pending';
+    END IF;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS subscriptions_count ON subscriptions;
+CREATE OR REPLACE FUNCTION user_stats_count_subscriptions() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE user_stats SET following = following + 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = NEW.requestor);
+        UPDATE user_stats SET followers = followers + 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = NEW.target);
+    ELSE
+        UPDATE user_stats SET following = following - 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = OLD.requestor);
+        UPDATE user_stats SET followers = followers - 1, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = OLD.target);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DO $$
+BEGIN
+    IF to_regclass('subscriptions') IS NOT NULL AND to_regclass('user_stats') IS NOT NULL THEN
+        CREATE TRIGGER subscriptions_count AFTER INSERT OR DELETE ON subscriptions
+            FOR EACH ROW EXECUTE PROCEDURE user_stats_count_subscriptions();
+    END IF;
+END;
+$$;
+
+DROP INDEX IF EXISTS subscriptions_pending_idx;
+ALTER TABLE IF EXISTS subscriptions
+    DROP COLUMN IF EXISTS status;
+ALTER TABLE IF EXISTS users
+    DROP COLUMN IF EXISTS private;
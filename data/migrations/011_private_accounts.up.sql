@@ -0,0 +1,52 @@
+-- Let users make their account private. Subscriptions to a private user are pending until
+-- the user approves them, only approved subscribers receive the updates.
+ALTER TABLE users
+    ADD COLUMN private BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE subscriptions
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'approved'
+        CHECK (status IN ('approved', 'pending'));
+
+-- Speeds up listing the pending subscription requests of a user
+CREATE INDEX subscriptions_pending_idx ON subscriptions (target, created_at) WHERE status = 'pending';
+
+-- Only count the approved subscriptions, pending ones are counted once approved. The status
+-- is the only column of a subscription updated other than the emails, which are renamed by
+-- cascade and do not change the counters.
+CREATE OR REPLACE FUNCTION user_stats_count_subscriptions() RETURNS trigger AS $$
+DECLARE
+    delta INT := 0;
+    requestor_email VARCHAR(255);
+    target_email VARCHAR(255);
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        requestor_email := OLD.requestor;
+        target_email := OLD.target;
+    ELSE
+        requestor_email := NEW.requestor;
+        target_email := NEW.target;
+    END IF;
+    IF TG_OP IN ('DELETE', 'UPDATE') THEN
+        IF OLD.status = 'approved' THEN
+            delta := delta - 1;
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.status = 'approved' THEN
+            delta := delta + 1;
+        END IF;
+    END IF;
+
+    IF delta <> 0 THEN
+        UPDATE user_stats SET following = following + delta, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = requestor_email);
+        UPDATE user_stats SET followers = followers + delta, updated_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE email = target_email);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER subscriptions_count ON subscriptions;
+CREATE TRIGGER subscriptions_count AFTER INSERT OR DELETE OR UPDATE OF status ON subscriptions
+    FOR EACH ROW EXECUTE PROCEDURE user_stats_count_subscriptions();
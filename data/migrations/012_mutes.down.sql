@@ -0,0 +1,2 @@
+-- Drop the mutes table
+DROP TABLE IF EXISTS mutes;
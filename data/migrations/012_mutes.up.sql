@@ -0,0 +1,16 @@
+-- Create mutes table. A user muting another stops receiving their updates, without changing
+-- the friendship or the subscriptions between them. Mutes without expiry last until removed.
+CREATE TABLE mutes (
+    id SERIAL PRIMARY KEY,
+    requestor INT NOT NULL, -- The user who muted
+    target INT NOT NULL, -- The muted user
+    expires_at TIMESTAMPTZ, -- When the mute ends, NULL when it lasts until removed
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (requestor, target),
+    FOREIGN KEY (requestor) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (target) REFERENCES users(id) ON DELETE CASCADE,
+    CHECK (requestor <> target) -- Ensures a user cannot mute themselves
+);
+
+-- Speeds up looking for the users who muted the sender of an update
+CREATE INDEX mutes_target_idx ON mutes (target);
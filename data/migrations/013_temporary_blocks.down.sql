@@ -0,0 +1,14 @@
+-- Expired blocks would apply again without their expiry
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'blocks' AND column_name = 'expires_at') THEN
+        DELETE FROM blocks WHERE expires_at <= NOW();
+    END IF;
+END;
+$$;
+
+DROP INDEX IF EXISTS blocks_expires_at_idx;
+ALTER TABLE IF EXISTS blocks
+    DROP COLUMN IF EXISTS reason,
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS expires_at;
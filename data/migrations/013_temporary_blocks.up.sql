@@ -0,0 +1,10 @@
+-- Let blocks lapse and record why they were made. An expired block no longer applies, the
+-- sweeper deletes it and records a BlockExpired event.
+ALTER TABLE blocks
+    ADD COLUMN expires_at TIMESTAMPTZ, -- When the block lapses, NULL when it lasts until removed
+    ADD COLUMN category VARCHAR(20) NOT NULL DEFAULT 'other'
+        CHECK (category IN ('spam', 'harassment', 'abuse', 'other')),
+    ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+
+-- Speeds up looking for the expired blocks
+CREATE INDEX blocks_expires_at_idx ON blocks (expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,75 @@
+// Package migrations embeds the SQL migration files so they ship with the binaries.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FS holds the *.up.sql and *.down.sql migration files.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Migration is a single versioned migration.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// All returns the embedded migrations ordered by version.
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			continue
+		}
+
+		b, err := FS.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			m.Name = strings.TrimSuffix(name, ".up.sql")
+			m.Up = string(b)
+		case strings.HasSuffix(name, ".down.sql"):
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func Latest() (int, error) {
+	all, err := All()
+	if err != nil || len(all) == 0 {
+		return 0, err
+	}
+	return all[len(all)-1].Version, nil
+}
@@ -0,0 +1,55 @@
+// Package analytics periodically snapshots aggregate statistics on the social graph.
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/service"
+)
+
+// Snapshotter periodically computes the statistics of the social graph, so that reading
+// them does not scan the whole graph.
+type Snapshotter struct {
+	svc      service.AnalyticsService
+	interval time.Duration
+}
+
+// NewSnapshotter creates a new Snapshotter.
+func NewSnapshotter(svc service.AnalyticsService, cfg config.Analytics) *Snapshotter {
+	return &Snapshotter{svc: svc, interval: cfg.SnapshotInterval}
+}
+
+// Run snapshots the statistics until ctx is cancelled.
+func (s *Snapshotter) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Dur("interval", s.interval).Msg("Starting analytics snapshots")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SnapshotOnce(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to snapshot analytics")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping analytics snapshots")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SnapshotOnce computes and stores a snapshot of the statistics.
+func (s *Snapshotter) SnapshotOnce(ctx context.Context) error {
+	report, err := s.svc.ComputeSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info().Int64("snapshot_id", report.ID).Int("users", report.Totals.Users).Msg("Snapshotted analytics")
+	return nil
+}
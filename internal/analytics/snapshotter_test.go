@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotter_SnapshotOnce tests the SnapshotOnce method of the Snapshotter.
+func TestSnapshotter_SnapshotOnce(t *testing.T) {
+	tcs := map[string]struct {
+		err error // Error returned by the service
+	}{
+		"success": {},
+		"service_error": {
+			err: errors.New(response.ErrMsgStoreAnalytics),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockAnalyticsService)
+			snapshotter := NewSnapshotter(mockService, config.Analytics{SnapshotInterval: time.Hour})
+			if tc.err != nil {
+				mockService.On("ComputeSnapshot", mock.Anything).Return(nil, tc.err).Once()
+			} else {
+				mockService.On("ComputeSnapshot", mock.Anything).Return(&repository.AnalyticsReport{ID: 1}, nil).Once()
+			}
+
+			// When
+			err := snapshotter.SnapshotOnce(context.Background())
+
+			// Then
+			require.Equal(t, tc.err, err)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestSnapshotter_Run tests that the Snapshotter snapshots on start and stops with its context.
+func TestSnapshotter_Run(t *testing.T) {
+	// Given
+	mockService := new(service.MockAnalyticsService)
+	snapshotter := NewSnapshotter(mockService, config.Analytics{SnapshotInterval: time.Hour})
+
+	done := make(chan struct{})
+	mockService.On("ComputeSnapshot", mock.Anything).
+		Run(func(mock.Arguments) { close(done) }).Return(&repository.AnalyticsReport{ID: 1}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		snapshotter.Run(ctx)
+		close(stopped)
+	}()
+
+	// When
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("analytics were not snapshotted")
+	}
+	cancel()
+
+	// Then
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("snapshotter did not stop")
+	}
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,66 @@
+// Package audit carries who makes a change to the social graph, from the request or command
+// making it down to the audit log.
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Actions of the changes recorded in the audit log without a domain event, as they are kept
+// from the other user. The other actions are the domain event types.
+const (
+	ActionMuted   = "Muted"
+	ActionUnmuted = "Unmuted"
+)
+
+// IsValidAction reports whether action is the action of an audit entry.
+func IsValidAction(action string) bool {
+	return action == ActionMuted || action == ActionUnmuted || events.IsValidType(action)
+}
+
+// Source describes where a change comes from.
+type Source struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying src.
+func NewContext(ctx context.Context, src Source) context.Context {
+	return context.WithValue(ctx, contextKey{}, src)
+}
+
+// FromContext returns the source carried by ctx, empty when there is none.
+func FromContext(ctx context.Context) Source {
+	src, _ := ctx.Value(contextKey{}).(Source)
+	return src
+}
+
+// Middleware records the source of the request in its context: the authenticated principal
+// as the actor, the request ID set by the RequestID middleware and the client IP. The actor
+// is empty for unauthenticated requests, so install the auth middleware first. The client IP
+// is the remote address of the connection; behind a proxy, install the RealIP middleware
+// first.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src := Source{
+			RequestID: middleware.GetReqID(r.Context()),
+			ClientIP:  r.RemoteAddr,
+		}
+		if p, ok := auth.FromContext(r.Context()); ok {
+			src.Actor = p.Email
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			src.ClientIP = host
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), src)))
+	})
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMiddleware tests that the Middleware records the source of the request in its context.
+func TestMiddleware(t *testing.T) {
+	tcs := map[string]struct {
+		principal  *auth.Principal // Authenticated principal of the request, if any
+		actor      string          // X-Actor header of the request, which is not trusted
+		remoteAddr string          // Remote address of the request
+		expSource  Source          // Expected source in the context
+	}{
+		"authenticated": {
+			principal:  &auth.Principal{Email: "admin@example.com", Admin: true},
+			remoteAddr: "10.0.0.1:51234",
+			expSource:  Source{Actor: "admin@example.com", RequestID: "req-1", ClientIP: "10.0.0.1"},
+		},
+		"actor_header_ignored": {
+			principal:  &auth.Principal{Email: "andy@example.com"},
+			actor:      "admin@example.com",
+			remoteAddr: "10.0.0.1:51234",
+			expSource:  Source{Actor: "andy@example.com", RequestID: "req-1", ClientIP: "10.0.0.1"},
+		},
+		"unauthenticated": {
+			remoteAddr: "[::1]:51234",
+			expSource:  Source{RequestID: "req-1", ClientIP: "::1"},
+		},
+		"remote_address_without_port": {
+			remoteAddr: "10.0.0.1",
+			expSource:  Source{RequestID: "req-1", ClientIP: "10.0.0.1"},
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			var got Source
+			h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = FromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/friend/create", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.RequestIDKey, "req-1"))
+			req.RemoteAddr = tc.remoteAddr
+			if tc.actor != "" {
+				req.Header.Set("X-Actor", tc.actor)
+			}
+			if tc.principal != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), *tc.principal))
+			}
+
+			// When
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			// Then
+			require.Equal(t, tc.expSource, got)
+		})
+	}
+}
+
+// TestFromContext_Empty tests that FromContext returns an empty source when there is none.
+func TestFromContext_Empty(t *testing.T) {
+	require.Equal(t, Source{}, FromContext(context.Background()))
+}
+
+// TestIsValidAction tests that the actions are the domain event types and the audit only actions.
+func TestIsValidAction(t *testing.T) {
+	require.True(t, IsValidAction(events.Blocked))
+	require.True(t, IsValidAction(ActionMuted))
+	require.True(t, IsValidAction(ActionUnmuted))
+	require.False(t, IsValidAction("Deleted"))
+}
@@ -0,0 +1,175 @@
+// Package auth authenticates the API requests. Clients send a bearer token signed with the
+// shared secret, naming the user they act as and whether they are an administrator.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// Errors returned when verifying a token.
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// tokenHeader is the header of every token: HMAC-SHA256 signed JWTs.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Principal is the authenticated user a request acts as.
+type Principal struct {
+	Email string
+	Admin bool
+}
+
+// claims is the payload of a token.
+type claims struct {
+	Subject   string `json:"sub"`
+	Admin     bool   `json:"admin,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx and whether the request is authenticated.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// NewToken returns a token for p signed with secret, valid until expiresAt.
+func NewToken(secret string, p Principal, expiresAt time.Time) (string, error) {
+	payload, err := json.Marshal(claims{
+		Subject:   emailaddr.Normalize(p.Email),
+		Admin:     p.Admin,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	signed := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + sign(secret, signed), nil
+}
+
+// Verify checks the signature and expiry of token and returns the principal it names.
+func Verify(secret, token string, now time.Time) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return Principal{}, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(secret, parts[0]+"."+parts[1]))) {
+		return Principal{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Subject == "" {
+		return Principal{}, ErrInvalidToken
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return Principal{}, ErrTokenExpired
+	}
+
+	return Principal{Email: emailaddr.Normalize(c.Subject), Admin: c.Admin}, nil
+}
+
+// sign returns the encoded HMAC-SHA256 of s keyed with secret.
+func sign(secret, s string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware authenticates the requests carrying a bearer token in their Authorization
+// header, recording the principal in their context. Requests without a token go through
+// unauthenticated, those with an invalid or expired token are refused.
+func Middleware(secret string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				response.RespondErr(r.Context(), w, http.StatusUnauthorized, response.ErrMsgInvalidToken)
+				return
+			}
+			p, err := Verify(secret, strings.TrimSpace(token), time.Now())
+			if err != nil {
+				response.RespondErr(r.Context(), w, http.StatusUnauthorized, response.ErrMsgInvalidToken)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+		})
+	}
+}
+
+// RequireUser refuses the unauthenticated requests.
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := FromContext(r.Context()); !ok {
+			response.RespondErr(r.Context(), w, http.StatusUnauthorized, response.ErrMsgUnauthenticated)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin refuses the requests not made by an administrator.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := FromContext(r.Context())
+		if !ok {
+			response.RespondErr(r.Context(), w, http.StatusUnauthorized, response.ErrMsgUnauthenticated)
+			return
+		}
+		if !p.Admin {
+			response.RespondErr(r.Context(), w, http.StatusForbidden, response.ErrMsgForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireOwner refuses the requests on the resources of the user named by the email URL
+// parameter unless made by that user, or by an administrator when admin is true.
+func RequireOwner(admin bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := FromContext(r.Context())
+			if !ok {
+				response.RespondErr(r.Context(), w, http.StatusUnauthorized, response.ErrMsgUnauthenticated)
+				return
+			}
+			if p.Email != emailaddr.Normalize(chi.URLParam(r, "email")) && !(admin && p.Admin) {
+				response.RespondErr(r.Context(), w, http.StatusForbidden, response.ErrMsgForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret-test-secret-test-secret"
+
+// TestVerify tests the verification of the tokens.
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	valid, err := NewToken(testSecret, Principal{Email: "Andy@Example.com", Admin: true}, now.Add(time.Hour))
+	require.NoError(t, err)
+	expired, err := NewToken(testSecret, Principal{Email: "andy@example.com"}, now.Add(-time.Second))
+	require.NoError(t, err)
+	forged, err := NewToken("another-secret", Principal{Email: "andy@example.com", Admin: true}, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	tcs := map[string]struct {
+		token  string    // Token to verify
+		exp    Principal // Expected principal
+		expErr error     // Expected error
+	}{
+		"valid": {
+			token: valid,
+			exp:   Principal{Email: "andy@example.com", Admin: true},
+		},
+		"expired": {
+			token:  expired,
+			expErr: ErrTokenExpired,
+		},
+		"wrong_secret": {
+			token:  forged,
+			expErr: ErrInvalidToken,
+		},
+		"malformed": {
+			token:  "not-a-token",
+			expErr: ErrInvalidToken,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// When
+			p, err := Verify(testSecret, tc.token, now)
+
+			// Then
+			require.Equal(t, tc.expErr, err)
+			require.Equal(t, tc.exp, p)
+		})
+	}
+}
+
+// TestGuards tests the middlewares authenticating the requests and refusing them.
+func TestGuards(t *testing.T) {
+	token := func(p Principal) string {
+		tok, err := NewToken(testSecret, p, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		return "Bearer " + tok
+	}
+	user := token(Principal{Email: "andy@example.com"})
+	admin := token(Principal{Email: "root@example.com", Admin: true})
+
+	tcs := map[string]struct {
+		path    string // Requested path
+		header  string // Authorization header
+		expCode int    // Expected HTTP response code
+	}{
+		"user_unauthenticated": {
+			path:    "/user",
+			expCode: http.StatusUnauthorized,
+		},
+		"user_invalid_token": {
+			path:    "/user",
+			header:  "Bearer nope",
+			expCode: http.StatusUnauthorized,
+		},
+		"user_authenticated": {
+			path:    "/user",
+			header:  user,
+			expCode: http.StatusOK,
+		},
+		"admin_by_user": {
+			path:    "/admin",
+			header:  user,
+			expCode: http.StatusForbidden,
+		},
+		"admin_by_admin": {
+			path:    "/admin",
+			header:  admin,
+			expCode: http.StatusOK,
+		},
+		"owner_by_owner": {
+			path:    "/owner/Andy@example.com",
+			header:  user,
+			expCode: http.StatusOK,
+		},
+		"owner_by_other_user": {
+			path:    "/owner/lisa@example.com",
+			header:  user,
+			expCode: http.StatusForbidden,
+		},
+		"owner_by_admin": {
+			path:    "/owner/lisa@example.com",
+			header:  admin,
+			expCode: http.StatusForbidden,
+		},
+		"owner_or_admin_by_admin": {
+			path:    "/owner-or-admin/lisa@example.com",
+			header:  admin,
+			expCode: http.StatusOK,
+		},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	r := chi.NewRouter()
+	r.Use(Middleware(testSecret))
+	r.With(RequireUser).Get("/user", ok)
+	r.With(RequireAdmin).Get("/admin", ok)
+	r.With(RequireOwner(false)).Get("/owner/{email}", ok)
+	r.With(RequireOwner(true)).Get("/owner-or-admin/{email}", ok)
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package blocks lifts the blocks whose expiry passed.
+package blocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/service"
+)
+
+// actor names the sweeper in the audit log.
+const actor = "block-sweeper"
+
+// Sweeper periodically deletes the blocks whose expiry passed, recording a BlockExpired event
+// for each. Expired blocks no longer apply even before they are swept.
+type Sweeper struct {
+	svc      service.FriendService
+	interval time.Duration
+}
+
+// NewSweeper creates a new Sweeper.
+func NewSweeper(svc service.FriendService, cfg config.Blocks) *Sweeper {
+	return &Sweeper{svc: svc, interval: cfg.SweepInterval}
+}
+
+// Run sweeps the expired blocks until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Dur("interval", s.interval).Msg("Starting expired block sweep")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to sweep expired blocks")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping expired block sweep")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce deletes the expired blocks, returning how many were deleted.
+func (s *Sweeper) SweepOnce(ctx context.Context) (int, error) {
+	n, err := s.svc.ExpireBlocks(audit.NewContext(ctx, audit.Source{Actor: actor}))
+	if n > 0 {
+		logger.FromContext(ctx).Info().Int("expired", n).Msg("Expired blocks")
+	}
+	return n, err
+}
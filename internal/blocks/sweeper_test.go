@@ -0,0 +1,84 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSweeper_SweepOnce tests the SweepOnce method of the Sweeper.
+func TestSweeper_SweepOnce(t *testing.T) {
+	tcs := map[string]struct {
+		expired int   // Blocks expired by the service
+		err     error // Error returned by the service
+	}{
+		"success": {
+			expired: 3,
+		},
+		"nothing_expired": {},
+		"service_error": {
+			expired: 1,
+			err:     errors.New(response.ErrMsgExpireBlocks),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			sweeper := NewSweeper(mockService, config.Blocks{SweepInterval: time.Hour})
+			mockService.On("ExpireBlocks", mock.MatchedBy(func(ctx context.Context) bool {
+				return audit.FromContext(ctx).Actor == actor
+			})).Return(tc.expired, tc.err).Once()
+
+			// When
+			n, err := sweeper.SweepOnce(context.Background())
+
+			// Then
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.expired, n)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestSweeper_Run tests that the Sweeper sweeps on start and stops with its context.
+func TestSweeper_Run(t *testing.T) {
+	// Given
+	mockService := new(service.MockFriendService)
+	sweeper := NewSweeper(mockService, config.Blocks{SweepInterval: time.Hour})
+
+	done := make(chan struct{})
+	mockService.On("ExpireBlocks", mock.Anything).Run(func(mock.Arguments) { close(done) }).Return(0, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		sweeper.Run(ctx)
+		close(stopped)
+	}()
+
+	// When
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expired blocks were not swept")
+	}
+	cancel()
+
+	// Then
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not stop")
+	}
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,280 @@
+// Package bulk reads and writes the relations between users in bulk, as CSV or JSON Lines.
+//
+// CSV files hold one relation per record with the type, requestor and target columns, then
+// the category, reason and expires_at columns holding the details of the blocks, which may be
+// left out. A header naming the columns may come first. Expiries are written in RFC 3339.
+// JSON Lines files hold one relation object per line, for example
+// {"type":"block","requestor":"a@example.com","target":"b@example.com","category":"spam"}.
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// Supported file formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// MaxRows is the maximum number of rows of an imported file.
+const MaxRows = 100000
+
+// maxLineSize bounds the length of a JSON Lines line.
+const maxLineSize = 1 << 20
+
+// header names the columns of the CSV files.
+var header = []string{"type", "requestor", "target", "category", "reason", "expires_at"}
+
+// relationColumns is the number of columns of the records leaving out the block details.
+const relationColumns = 3
+
+// Row is a relation read from a file. Err describes why the row could not be read.
+type Row struct {
+	Line int
+	repository.Relation
+	Err string
+}
+
+// RowError is a row that was not imported and the reason why.
+type RowError struct {
+	Line int `json:"line"`
+	repository.Relation
+	Error string `json:"error"`
+}
+
+// Report summarizes an import. In a dry run Valid rows are reported but nothing is created.
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	Total  int  `json:"total"`
+	Valid  int  `json:"valid"`
+	// Created and Existing split the valid rows between the relations created and those
+	// that already existed.
+	Created  int        `json:"created"`
+	Existing int        `json:"existing"`
+	Failed   int        `json:"failed"`
+	Errors   []RowError `json:"errors"`
+}
+
+// IsValidFormat reports whether format is a supported file format.
+func IsValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatJSONL
+}
+
+// ContentType returns the media type of the files in the format.
+func ContentType(format string) string {
+	if format == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// FormatFromContentType guesses the file format from a media type, csv unless it
+// denotes JSON Lines.
+func FormatFromContentType(contentType string) string {
+	switch strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]) {
+	case "application/x-ndjson", "application/jsonl", "application/json-lines", "application/x-jsonlines":
+		return FormatJSONL
+	}
+	return FormatCSV
+}
+
+// Read reads the rows of a file in the format. Rows that cannot be parsed are returned with
+// their Err set, the error is reserved for unreadable input and files over MaxRows rows.
+func Read(r io.Reader, format string) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatJSONL:
+		return readJSONL(r)
+	}
+	return nil, errors.New(response.ErrMsgInvalidBulkFormat)
+}
+
+// readCSV reads the rows of a CSV file.
+func readCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var rows []Row
+	first := true
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, Row{Line: parseErr.StartLine, Err: response.ErrMsgMalformedRow + ": " + parseErr.Err.Error()})
+			if len(rows) > MaxRows {
+				return nil, errors.New(response.ErrMsgTooManyRows)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		if first {
+			first = false
+			if isHeader(record) {
+				continue
+			}
+		}
+
+		row := Row{Line: line}
+		if rel, err := parseRecord(record); err != nil {
+			row.Err = response.ErrMsgMalformedRow + ": " + err.Error()
+		} else {
+			row.Relation = rel
+		}
+		rows = append(rows, row)
+		if len(rows) > MaxRows {
+			return nil, errors.New(response.ErrMsgTooManyRows)
+		}
+	}
+}
+
+// parseRecord returns the relation described by a CSV record.
+func parseRecord(record []string) (repository.Relation, error) {
+	if len(record) != relationColumns && len(record) != len(header) {
+		return repository.Relation{}, errors.New("expected type, requestor and target columns, optionally followed by category, reason and expires_at")
+	}
+	for i := range record {
+		record[i] = strings.TrimSpace(record[i])
+	}
+
+	rel := repository.Relation{Type: record[0], Requestor: record[1], Target: record[2]}
+	if len(record) == relationColumns {
+		return rel, nil
+	}
+	rel.Category, rel.Reason = record[3], record[4]
+	if record[5] != "" {
+		expiresAt, err := time.Parse(time.RFC3339, record[5])
+		if err != nil {
+			return repository.Relation{}, errors.New("expires_at must be an RFC 3339 time")
+		}
+		rel.ExpiresAt = &expiresAt
+	}
+	return rel, nil
+}
+
+// isHeader reports whether the record names the CSV columns, with or without those of the
+// block details.
+func isHeader(record []string) bool {
+	if len(record) != relationColumns && len(record) != len(header) {
+		return false
+	}
+	for i := range record {
+		if strings.ToLower(strings.TrimSpace(record[i])) != header[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readJSONL reads the rows of a JSON Lines file, skipping blank lines.
+func readJSONL(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var rows []Row
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := Row{Line: line}
+		if err := json.Unmarshal([]byte(text), &row.Relation); err != nil {
+			row.Err = response.ErrMsgMalformedRow + ": " + err.Error()
+		}
+		rows = append(rows, row)
+		if len(rows) > MaxRows {
+			return nil, errors.New(response.ErrMsgTooManyRows)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Writer writes relations to a file.
+type Writer interface {
+	Write(rel repository.Relation) error
+	// Flush writes any buffered data, and the CSV header when no relation was written.
+	Flush() error
+}
+
+// NewWriter creates a Writer writing relations to w in the format.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	}
+	return nil, errors.New(response.ErrMsgInvalidBulkFormat)
+}
+
+// csvWriter writes relations as CSV records, preceded by a header.
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (cw *csvWriter) writeHeader() error {
+	if cw.wroteHeader {
+		return nil
+	}
+	cw.wroteHeader = true
+	return cw.w.Write(header)
+}
+
+func (cw *csvWriter) Write(rel repository.Relation) error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	var expiresAt string
+	if rel.ExpiresAt != nil {
+		expiresAt = rel.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return cw.w.Write([]string{rel.Type, rel.Requestor, rel.Target, rel.Category, rel.Reason, expiresAt})
+}
+
+func (cw *csvWriter) Flush() error {
+	if err := cw.writeHeader(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonlWriter writes relations as JSON Lines.
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (jw *jsonlWriter) Write(rel repository.Relation) error {
+	return jw.enc.Encode(rel)
+}
+
+func (jw *jsonlWriter) Flush() error {
+	return jw.w.Flush()
+}
@@ -0,0 +1,150 @@
+package bulk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRead tests that Read parses the rows of CSV and JSON Lines files.
+func TestRead(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tcs := map[string]struct {
+		format   string // File format
+		input    string // File content
+		expRows  []Row  // Expected rows
+		expError string // Expected error message
+	}{
+		"csv_with_header": {
+			format: FormatCSV,
+			input:  "type,requestor,target\nfriendship,a@example.com,b@example.com\nblock, c@example.com ,d@example.com\n",
+			expRows: []Row{
+				{Line: 2, Relation: repository.Relation{Type: "friendship", Requestor: "a@example.com", Target: "b@example.com"}},
+				{Line: 3, Relation: repository.Relation{Type: "block", Requestor: "c@example.com", Target: "d@example.com"}},
+			},
+		},
+		"csv_without_header": {
+			format: FormatCSV,
+			input:  "subscription,a@example.com,b@example.com\n",
+			expRows: []Row{
+				{Line: 1, Relation: repository.Relation{Type: "subscription", Requestor: "a@example.com", Target: "b@example.com"}},
+			},
+		},
+		"csv_malformed_row": {
+			format: FormatCSV,
+			input:  "friendship,a@example.com\nblock,c@example.com,d@example.com\n",
+			expRows: []Row{
+				{Line: 1, Err: response.ErrMsgMalformedRow + ": expected type, requestor and target columns, optionally followed by category, reason and expires_at"},
+				{Line: 2, Relation: repository.Relation{Type: "block", Requestor: "c@example.com", Target: "d@example.com"}},
+			},
+		},
+		"csv_with_block_details": {
+			format: FormatCSV,
+			input: "type,requestor,target,category,reason,expires_at\n" +
+				"block,a@example.com,b@example.com,spam, Sends ads ,2030-01-02T03:04:05Z\n" +
+				"friendship,a@example.com,c@example.com,,,\n" +
+				"block,a@example.com,d@example.com,other,,tomorrow\n",
+			expRows: []Row{
+				{Line: 2, Relation: repository.Relation{Type: "block", Requestor: "a@example.com", Target: "b@example.com",
+					Category: "spam", Reason: "Sends ads", ExpiresAt: &expiresAt}},
+				{Line: 3, Relation: repository.Relation{Type: "friendship", Requestor: "a@example.com", Target: "c@example.com"}},
+				{Line: 4, Err: response.ErrMsgMalformedRow + ": expires_at must be an RFC 3339 time"},
+			},
+		},
+		"jsonl_with_block_details": {
+			format: FormatJSONL,
+			input:  "{\"type\":\"block\",\"requestor\":\"a@example.com\",\"target\":\"b@example.com\",\"category\":\"spam\",\"expires_at\":\"2030-01-02T03:04:05Z\"}\n",
+			expRows: []Row{
+				{Line: 1, Relation: repository.Relation{Type: "block", Requestor: "a@example.com", Target: "b@example.com",
+					Category: "spam", ExpiresAt: &expiresAt}},
+			},
+		},
+		"jsonl": {
+			format: FormatJSONL,
+			input:  "{\"type\":\"friendship\",\"requestor\":\"a@example.com\",\"target\":\"b@example.com\"}\n\n{not json}\n",
+			expRows: []Row{
+				{Line: 1, Relation: repository.Relation{Type: "friendship", Requestor: "a@example.com", Target: "b@example.com"}},
+				{Line: 3, Err: response.ErrMsgMalformedRow + ": invalid character 'n' looking for beginning of object key string"},
+			},
+		},
+		"too_many_rows": {
+			format:   FormatJSONL,
+			input:    strings.Repeat("{}\n", MaxRows+1),
+			expError: response.ErrMsgTooManyRows,
+		},
+		"invalid_format": {
+			format:   "xml",
+			expError: response.ErrMsgInvalidBulkFormat,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// When
+			rows, err := Read(strings.NewReader(tc.input), tc.format)
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expRows, rows)
+		})
+	}
+}
+
+// TestWriter tests that the written relations read back the same.
+func TestWriter(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	relations := []repository.Relation{
+		{Type: repository.RelationFriendship, Requestor: "a@example.com", Target: "b@example.com"},
+		{Type: repository.RelationBlock, Requestor: "c@example.com", Target: "a@example.com", Category: repository.BlockCategoryOther},
+		{Type: repository.RelationBlock, Requestor: "c@example.com", Target: "b@example.com",
+			Category: repository.BlockCategorySpam, Reason: "Sends ads, daily", ExpiresAt: &expiresAt},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			// Given
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format)
+			require.NoError(t, err)
+
+			// When
+			for _, rel := range relations {
+				require.NoError(t, w.Write(rel))
+			}
+			require.NoError(t, w.Flush())
+
+			// Then
+			rows, err := Read(&buf, format)
+			require.NoError(t, err)
+			require.Len(t, rows, len(relations))
+			for i, row := range rows {
+				require.Empty(t, row.Err)
+				require.Equal(t, relations[i], row.Relation)
+			}
+		})
+	}
+}
+
+// TestWriter_Empty tests that an empty CSV export still holds the header.
+func TestWriter_Empty(t *testing.T) {
+	// Given
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV)
+	require.NoError(t, err)
+
+	// When
+	require.NoError(t, w.Flush())
+
+	// Then
+	require.Equal(t, "type,requestor,target,category,reason,expires_at\n", buf.String())
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Domain event types.
+const (
+	FriendshipCreated = "FriendshipCreated"
+	FriendshipRemoved = "FriendshipRemoved"
+	Subscribed        = "Subscribed"
+	Blocked           = "Blocked"
+	Unblocked         = "Unblocked"
+
+	SubscriptionRequested = "SubscriptionRequested"
+	SubscriptionApproved  = "SubscriptionApproved"
+	SubscriptionDenied    = "SubscriptionDenied"
+	PrivacyChanged        = "PrivacyChanged"
+
+	BlockExpired = "BlockExpired"
+)
+
+// Types lists every domain event type.
+var Types = []string{FriendshipCreated, FriendshipRemoved, Subscribed, Blocked, Unblocked,
+	SubscriptionRequested, SubscriptionApproved, SubscriptionDenied, PrivacyChanged, BlockExpired}
+
+// IsValidType reports whether t is a known event type.
+func IsValidType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is a change of the social graph that downstream services are notified about.
+type Event struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// FriendshipCreatedPayload is the payload of a FriendshipCreated event.
+type FriendshipCreatedPayload struct {
+	Friends []string `json:"friends"`
+}
+
+// FriendshipRemovedPayload is the payload of a FriendshipRemoved event.
+type FriendshipRemovedPayload struct {
+	Friends []string `json:"friends"`
+}
+
+// SubscribedPayload is the payload of a Subscribed event.
+type SubscribedPayload struct {
+	Requestor string `json:"requestor"`
+	Target    string `json:"target"`
+}
+
+// SubscriptionRequestPayload is the payload of the SubscriptionRequested, SubscriptionApproved
+// and SubscriptionDenied events, about a subscription to the updates of a private user.
+type SubscriptionRequestPayload struct {
+	Requestor string `json:"requestor"`
+	Target    string `json:"target"`
+}
+
+// PrivacyChangedPayload is the payload of a PrivacyChanged event.
+type PrivacyChangedPayload struct {
+	Email   string `json:"email"`
+	Private bool   `json:"private"`
+	// Policy is the policy applied to the existing subscribers when the user became private.
+	Policy string `json:"policy,omitempty"`
+	// Subscriptions is the number of existing subscriptions approved, turned pending or removed.
+	Subscriptions int `json:"subscriptions"`
+}
+
+// BlockedPayload is the payload of a Blocked event. The reason given for the block is left
+// out, it is only kept for trust and safety.
+type BlockedPayload struct {
+	Requestor string `json:"requestor"`
+	Target    string `json:"target"`
+	Category  string `json:"category,omitempty"`
+	// ExpiresAt is when the block lapses, unset for a block lasting until removed.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// BlockExpiredPayload is the payload of a BlockExpired event, recorded when a block lapses.
+type BlockExpiredPayload struct {
+	Requestor string    `json:"requestor"`
+	Target    string    `json:"target"`
+	Category  string    `json:"category"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UnblockedPayload is the payload of an Unblocked event.
+type UnblockedPayload struct {
+	Requestor string `json:"requestor"`
+	Target    string `json:"target"`
+}
+
+// New creates an event of the given type, encoding payload as JSON.
+func New(eventType string, payload interface{}) (Event, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, errors.Wrapf(err, "failed to encode %s payload", eventType)
+	}
+
+	return Event{
+		Type:       eventType,
+		Payload:    b,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// Subjects returns the email addresses of the users the event is about.
+func (e Event) Subjects() []string {
+	var p struct {
+		Friends   []string `json:"friends"`
+		Email     string   `json:"email"`
+		Requestor string   `json:"requestor"`
+		Target    string   `json:"target"`
+	}
+	if err := json.Unmarshal(e.Payload, &p); err != nil {
+		return nil
+	}
+
+	subjects := append([]string(nil), p.Friends...)
+	for _, email := range []string{p.Email, p.Requestor, p.Target} {
+		if email != "" {
+			subjects = append(subjects, email)
+		}
+	}
+	return subjects
+}
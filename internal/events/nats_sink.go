@@ -0,0 +1,133 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// natsSink publishes events to a NATS compatible broker using the plain text
+// client protocol. Every publish is followed by a PING so a PONG confirms the
+// broker has processed the message before the event is marked as published.
+type natsSink struct {
+	addr    string
+	prefix  string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewNATSSink creates a Sink publishing events to the broker at url (e.g. nats://localhost:4222).
+// Events are published on the subject "<prefix>.<event type>".
+func NewNATSSink(url, prefix string, timeout time.Duration) Sink {
+	return &natsSink{
+		addr:    strings.TrimPrefix(url, "nats://"),
+		prefix:  prefix,
+		timeout: timeout,
+	}
+}
+
+// Name returns the name of the sink.
+func (s *natsSink) Name() string {
+	return "nats"
+}
+
+// Publish publishes the event and waits for the broker to acknowledge it.
+func (s *natsSink) Publish(ctx context.Context, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.publish(ctx, s.prefix+"."+event.Type, b); err != nil {
+		// Drop the connection so the next publish reconnects.
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// publish writes a PUB followed by a PING and waits for the PONG.
+func (s *natsSink) publish(ctx context.Context, subject string, payload []byte) error {
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetDeadline(deadline)
+
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		return errors.Wrap(err, "failed to publish to NATS")
+	}
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return errors.Wrap(err, "failed to read NATS acknowledgement")
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return errors.Wrap(err, "failed to answer NATS ping")
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.Errorf("NATS error: %s", line)
+		}
+	}
+}
+
+// connect dials the broker, reads its INFO line and sends CONNECT.
+func (s *natsSink) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: s.timeout}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to NATS")
+	}
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	r := bufio.NewReader(conn)
+	info, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return errors.Errorf("unexpected NATS greeting %q: %v", info, err)
+	}
+
+	if _, err := conn.Write([]byte(`CONNECT {"verbose":false,"pedantic":false,"name":"friend-management"}` + "\r\n")); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to send NATS CONNECT")
+	}
+
+	s.conn = conn
+	s.r = r
+	return nil
+}
+
+// close drops the current connection, if any.
+func (s *natsSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+		s.r = nil
+	}
+}
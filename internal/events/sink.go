@@ -0,0 +1,112 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sink publishes events to a downstream system. Publish must return an error
+// unless the event was durably handed over, so the relay can retry it.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event Event) error
+}
+
+// writerSink writes every event as a JSON line to an io.Writer.
+type writerSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+	sync func() error
+}
+
+// NewStdoutSink creates a Sink writing events as JSON lines to stdout.
+func NewStdoutSink() Sink {
+	return &writerSink{name: "stdout", w: os.Stdout}
+}
+
+// NewFileSink creates a Sink appending events as JSON lines to the file at path.
+func NewFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open event file %s", path)
+	}
+	return &writerSink{name: "file", w: f, sync: f.Sync}, nil
+}
+
+// Name returns the name of the sink.
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+// Publish writes the event as a single JSON line.
+func (s *writerSink) Publish(ctx context.Context, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		return errors.Wrapf(err, "failed to write event to %s", s.name)
+	}
+	if s.sync != nil {
+		return s.sync()
+	}
+	return nil
+}
+
+// webhookSink POSTs every event as JSON to a URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a Sink POSTing events as JSON to url. Any non 2xx response is a failure.
+func NewWebhookSink(url string, timeout time.Duration) Sink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Name returns the name of the sink.
+func (s *webhookSink) Name() string {
+	return "webhook"
+}
+
+// Publish POSTs the event to the webhook URL.
+func (s *webhookSink) Publish(ctx context.Context, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Event-Id", fmt.Sprint(event.ID))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call webhook")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestWebhookSink_Publish tests the Publish method of the webhook sink.
+func TestWebhookSink_Publish(t *testing.T) {
+	tcs := map[string]struct {
+		status int  // Status code returned by the receiver
+		expErr bool // Whether an error is expected
+	}{
+		"success": {
+			status: http.StatusNoContent,
+		},
+		"receiver_error": {
+			status: http.StatusInternalServerError,
+			expErr: true,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			var received Event
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, FriendshipCreated, r.Header.Get("X-Event-Type"))
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			event, err := New(FriendshipCreated, FriendshipCreatedPayload{Friends: []string{"andy@example.com", "john@example.com"}})
+			require.NoError(t, err)
+			event.ID = 42
+
+			// When
+			err = NewWebhookSink(srv.URL, time.Second).Publish(context.Background(), event)
+
+			// Then
+			if tc.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, int64(42), received.ID)
+			require.JSONEq(t, string(event.Payload), string(received.Payload))
+		})
+	}
+}
+
+// TestNATSSink_Publish tests that the NATS sink speaks the client protocol and waits for the PONG.
+func TestNATSSink_Publish(t *testing.T) {
+	// Given
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("INFO {}\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := r.ReadString('\n')
+				received <- line + payload
+			case strings.HasPrefix(line, "PING"):
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+
+	event, err := New(Blocked, BlockedPayload{Requestor: "andy@example.com", Target: "john@example.com"})
+	require.NoError(t, err)
+
+	// When
+	err = NewNATSSink("nats://"+ln.Addr().String(), "friend-management", time.Second).Publish(context.Background(), event)
+
+	// Then
+	require.NoError(t, err)
+	msg := <-received
+	require.True(t, strings.HasPrefix(msg, "PUB friend-management.Blocked "))
+	require.Contains(t, msg, `"requestor":"andy@example.com"`)
+}
@@ -0,0 +1,104 @@
+package fanout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+)
+
+// sweepBatchSize is the maximum number of pending updates retried per sweep.
+const sweepBatchSize = 100
+
+// Worker fans out in the background the updates queued by the UpdateService. It also
+// periodically retries the updates left pending, e.g. because the queue was full, the
+// fan-out failed or the server stopped before finishing it.
+type Worker struct {
+	svc           service.UpdateService
+	repo          repository.UpdateRepository
+	queue         <-chan int64
+	workers       int
+	sweepInterval time.Duration
+	staleAfter    time.Duration
+	now           func() time.Time
+}
+
+// NewWorker creates a new Worker consuming queue.
+func NewWorker(svc service.UpdateService, repo repository.UpdateRepository, queue <-chan int64, cfg config.Updates) *Worker {
+	return &Worker{
+		svc:           svc,
+		repo:          repo,
+		queue:         queue,
+		workers:       cfg.Workers,
+		sweepInterval: cfg.SweepInterval,
+		staleAfter:    cfg.StaleAfter,
+		now:           time.Now,
+	}
+}
+
+// Run fans out updates until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Int("workers", w.workers).Msg("Starting update fan-out")
+
+	var wg sync.WaitGroup
+	wg.Add(w.workers)
+	for i := 0; i < w.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-w.queue:
+					w.fanOut(ctx, id)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(w.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.SweepOnce(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to sweep pending updates")
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			log.Info().Msg("Stopping update fan-out")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepOnce fans out the updates that have been pending for longer than the stale delay,
+// returning how many were found.
+func (w *Worker) SweepOnce(ctx context.Context) (int, error) {
+	ids, err := w.repo.ListPendingUpdates(ctx, w.now().Add(-w.staleAfter), sweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		w.fanOut(ctx, id)
+	}
+	return len(ids), nil
+}
+
+// fanOut fans out a single update, logging failures; the sweeper retries them later.
+func (w *Worker) fanOut(ctx context.Context, id int64) {
+	if err := w.svc.FanOut(ctx, id); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int64("update_id", id).Msg("Failed to fan out update")
+	}
+}
@@ -0,0 +1,92 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorker_SweepOnce tests the SweepOnce method of the Worker.
+func TestWorker_SweepOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		pending   []int64 // Updates returned by ListPendingUpdates
+		fanoutErr error   // Error returned by FanOut
+	}{
+		"success": {
+			pending: []int64{1, 2},
+		},
+		"no_pending_updates": {
+			pending: []int64{},
+		},
+		"fanout_error_continues": {
+			pending:   []int64{1, 2},
+			fanoutErr: errors.New("connection refused"),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUpdateRepo)
+			mockService := new(service.MockUpdateService)
+			worker := NewWorker(mockService, mockRepo, nil, config.Updates{Workers: 1, StaleAfter: time.Minute})
+			worker.now = func() time.Time { return now }
+
+			mockRepo.On("ListPendingUpdates", mock.Anything, now.Add(-time.Minute), sweepBatchSize).Return(tc.pending, nil).Once()
+			for _, id := range tc.pending {
+				mockService.On("FanOut", mock.Anything, id).Return(tc.fanoutErr).Once()
+			}
+
+			// When
+			n, err := worker.SweepOnce(context.Background())
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, len(tc.pending), n)
+			mockRepo.AssertExpectations(t)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestWorker_Run tests that queued updates are fanned out by the background workers.
+func TestWorker_Run(t *testing.T) {
+	// Given
+	mockRepo := new(repository.MockUpdateRepo)
+	mockService := new(service.MockUpdateService)
+	queue := make(chan int64, 1)
+	worker := NewWorker(mockService, mockRepo, queue, config.Updates{Workers: 2, SweepInterval: time.Hour, StaleAfter: time.Minute})
+
+	done := make(chan struct{})
+	mockRepo.On("ListPendingUpdates", mock.Anything, mock.AnythingOfType("time.Time"), sweepBatchSize).Return([]int64{}, nil)
+	mockService.On("FanOut", mock.Anything, int64(7)).Run(func(mock.Arguments) { close(done) }).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		worker.Run(ctx)
+		close(stopped)
+	}()
+
+	// When
+	queue <- 7
+
+	// Then
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued update was not fanned out")
+	}
+	cancel()
+	<-stopped
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+)
+
+// Output formats of the analytics.
+const (
+	analyticsFormatJSON = "json"
+	analyticsFormatCSV  = "csv"
+)
+
+// AnalyticsHandler creates a new HTTP handler returning the latest statistics on the social graph.
+// The format query parameter selects JSON, the default, or CSV, and refresh=true computes the
+// statistics anew instead of returning the latest snapshot.
+func AnalyticsHandler(analyticsService service.AnalyticsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = analyticsFormatJSON
+		}
+		if format != analyticsFormatJSON && format != analyticsFormatCSV {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgInvalidAnalyticsFormat)
+			return
+		}
+		refresh, err := parseBoolParam(r, "refresh")
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var report *repository.AnalyticsReport
+		if refresh {
+			report, err = analyticsService.ComputeSnapshot(ctx)
+		} else {
+			report, err = analyticsService.GetLatestSnapshot(ctx)
+		}
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if format == analyticsFormatJSON {
+			response.RespondSuccess(ctx, w, report)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="analytics.csv"`)
+		if err := writeAnalyticsCSV(w, report); err != nil {
+			// The status is already written, the response can only be cut short
+			logger.FromContext(ctx).Error().Err(err).Msg("Failed to write analytics")
+		}
+	}
+}
+
+// writeAnalyticsCSV writes the report as metric,key,value rows, key being empty for the
+// metrics having a single value.
+func writeAnalyticsCSV(w io.Writer, report *repository.AnalyticsReport) error {
+	itoa := strconv.Itoa
+	rows := [][]string{
+		{"metric", "key", "value"},
+		{"computed_at", "", report.ComputedAt.UTC().Format(time.RFC3339)},
+		{"users", "", itoa(report.Totals.Users)},
+		{"friendships", "", itoa(report.Totals.Friendships)},
+		{"subscriptions", "", itoa(report.Totals.Subscriptions)},
+		{"blocks", "", itoa(report.Totals.Blocks)},
+	}
+	for _, d := range report.DegreeDistribution {
+		rows = append(rows, []string{"degree", itoa(d.Degree), itoa(d.Users)})
+	}
+	for _, u := range report.MostFollowed {
+		rows = append(rows, []string{"most_followed", u.Email, itoa(u.Count)})
+	}
+	for _, u := range report.MostBlocked {
+		rows = append(rows, []string{"most_blocked", u.Email, itoa(u.Count)})
+	}
+	rows = append(rows,
+		[]string{"components", "count", itoa(report.Components.Count)},
+		[]string{"components", "largest", itoa(report.Components.Largest)},
+		[]string{"components", "isolated", itoa(report.Components.Isolated)},
+		[]string{"clustering_coefficient", "", strconv.FormatFloat(report.ClusteringCoefficient, 'f', 6, 64)},
+	)
+
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnalyticsHandler tests the AnalyticsHandler function.
+func TestAnalyticsHandler(t *testing.T) {
+	report := &repository.AnalyticsReport{
+		ID:                 1,
+		Totals:             repository.GraphTotals{Users: 5, Friendships: 4},
+		DegreeDistribution: []repository.DegreeCount{{Degree: 0, Users: 1}, {Degree: 2, Users: 2}},
+		MostFollowed:       []repository.UserCount{{Email: "kate@example.com", Count: 2}},
+		Components:         repository.ComponentStats{Count: 2, Largest: 4, Isolated: 1},
+		ComputedAt:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tcs := map[string]struct {
+		query     string // Query string of the request
+		expMethod string // Service method expected to be called, if any
+		err       error  // Error returned by the service method
+		expCode   int    // Expected HTTP response code
+		expBody   string // Expected fragment of the response body
+	}{
+		"json": {
+			expMethod: "GetLatestSnapshot",
+			expCode:   http.StatusOK,
+			expBody:   `"components":{"count":2,"largest":4,"isolated":1}`,
+		},
+		"csv": {
+			query:     "?format=csv",
+			expMethod: "GetLatestSnapshot",
+			expCode:   http.StatusOK,
+			expBody:   "degree,2,2\nmost_followed,kate@example.com,2\n",
+		},
+		"refresh": {
+			query:     "?refresh=true",
+			expMethod: "ComputeSnapshot",
+			expCode:   http.StatusOK,
+			expBody:   `"users":5`,
+		},
+		"invalid_format": {
+			query:   "?format=xml",
+			expCode: http.StatusBadRequest,
+			expBody: response.ErrMsgInvalidAnalyticsFormat,
+		},
+		"invalid_refresh": {
+			query:   "?refresh=maybe",
+			expCode: http.StatusBadRequest,
+		},
+		"service_error": {
+			expMethod: "GetLatestSnapshot",
+			err:       errors.New(response.ErrMsgGetAnalytics),
+			expCode:   http.StatusInternalServerError,
+			expBody:   response.ErrMsgGetAnalytics,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockAnalyticsService)
+			if tc.expMethod != "" {
+				if tc.err != nil {
+					mockService.On(tc.expMethod, mock.Anything).Return(nil, tc.err).Once()
+				} else {
+					mockService.On(tc.expMethod, mock.Anything).Return(report, nil).Once()
+				}
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/analytics"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			AnalyticsHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/pkg/errors"
+)
+
+// AuditLogHandler creates a new HTTP handler listing the audit log of the graph changes,
+// newest first. It accepts the user, action, from and to query parameters, from and to as
+// RFC 3339 times, along with limit and offset.
+func AuditLogHandler(auditService service.AuditService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		q := r.URL.Query()
+		filter := repository.AuditFilter{User: q.Get("user"), Action: q.Get("action")}
+		if filter.From, err = parseTimeParam(r, "from"); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if filter.To, err = parseTimeParam(r, "to"); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		entries, err := auditService.ListAuditEntries(ctx, filter, limit, offset)
+		if err != nil {
+			response.RespondErr(ctx, w, auditErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"entries": entries,
+			"count":   len(entries),
+			"limit":   limit,
+			"offset":  offset,
+		})
+	}
+}
+
+// parseTimeParam parses an optional RFC 3339 time query parameter, the zero time when unset.
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
+
+// auditErrStatus maps an audit service error to the HTTP status code of the response.
+func auditErrStatus(err error) int {
+	msg := errors.Cause(err).Error()
+	switch {
+	case strings.HasPrefix(msg, response.ErrMsgInvalidAuditAction), msg == response.ErrMsgInvalidTimeRange:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditLogHandler tests the AuditLogHandler function.
+func TestAuditLogHandler(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		query     string                 // Query string of the request
+		expFilter repository.AuditFilter // Expected filter passed to the service
+		expCall   bool                   // Whether the service is expected to be called
+		err       error                  // Error returned by the service
+		expCode   int                    // Expected HTTP response code
+	}{
+		"no_filter": {
+			expCall: true,
+			expCode: http.StatusOK,
+		},
+		"all_filters": {
+			query:     "?user=andy@example.com&action=Blocked&from=2024-01-01T00:00:00Z&to=2024-01-02T00:00:00Z",
+			expFilter: repository.AuditFilter{User: "andy@example.com", Action: events.Blocked, From: from, To: from.Add(24 * time.Hour)},
+			expCall:   true,
+			expCode:   http.StatusOK,
+		},
+		"invalid_from": {
+			query:   "?from=yesterday",
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_limit": {
+			query:   "?limit=0",
+			expCode: http.StatusBadRequest,
+		},
+		"unknown_action": {
+			query:     "?action=Deleted",
+			expFilter: repository.AuditFilter{Action: "Deleted"},
+			expCall:   true,
+			err:       errors.New(response.ErrMsgInvalidAuditAction + `: "Deleted"`),
+			expCode:   http.StatusBadRequest,
+		},
+		"service_error": {
+			expCall: true,
+			err:     errors.New(response.ErrMsgGetAuditEntries),
+			expCode: http.StatusInternalServerError,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockAuditService)
+			if tc.expCall {
+				if tc.err != nil {
+					mockService.On("ListAuditEntries", mock.Anything, tc.expFilter, defaultPageLimit, 0).Return(nil, tc.err).Once()
+				} else {
+					mockService.On("ListAuditEntries", mock.Anything, tc.expFilter, defaultPageLimit, 0).
+						Return([]repository.AuditEntry{{ID: 1, Action: events.Blocked}}, nil).Once()
+				}
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			AuditLogHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/pkg/errors"
+)
+
+// maxImportSize bounds the size of an imported file.
+const maxImportSize = 32 << 20
+
+// ImportHandler creates a new HTTP handler importing friendships, subscriptions and blocks
+// from the CSV or JSON Lines file in the request body. The format query parameter selects
+// the format, guessed from the Content-Type header when absent. With dry_run=true the rows
+// are only validated. The response reports the rows that were not imported.
+func ImportHandler(bulkService service.BulkService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = bulk.FormatFromContentType(r.Header.Get("Content-Type"))
+		}
+		if !bulk.IsValidFormat(format) {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgInvalidBulkFormat)
+			return
+		}
+
+		dryRun, err := parseBoolParam(r, "dry_run")
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rows, err := bulk.Read(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				response.RespondErr(ctx, w, http.StatusRequestEntityTooLarge, err.Error())
+				return
+			}
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		report, err := bulkService.Import(ctx, rows, dryRun)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, report)
+	}
+}
+
+// ExportHandler creates a new HTTP handler exporting the friendships, subscriptions and
+// blocks of the user given by the email query parameter, or of the whole graph without it.
+// The format query parameter selects CSV, the default, or JSON Lines.
+func ExportHandler(bulkService service.BulkService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = bulk.FormatCSV
+		}
+		if !bulk.IsValidFormat(format) {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgInvalidBulkFormat)
+			return
+		}
+
+		email := emailaddr.Normalize(r.URL.Query().Get("email"))
+		if email != "" {
+			if err := validate.Var(email, "email"); err != nil {
+				response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgInvalidEmail)
+				return
+			}
+		}
+
+		writer, err := bulk.NewWriter(w, format)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// The headers are sent with the first relation so errors occurring before it
+		// can still be reported with a proper status
+		started := false
+		start := func() {
+			if started {
+				return
+			}
+			started = true
+			w.Header().Set("Content-Type", bulk.ContentType(format))
+			w.Header().Set("Content-Disposition", `attachment; filename="relations.`+format+`"`)
+		}
+
+		err = bulkService.Export(ctx, email, func(rel repository.Relation) error {
+			start()
+			return writer.Write(rel)
+		})
+		if err != nil && !started {
+			status := http.StatusInternalServerError
+			if errors.Cause(err).Error() == response.ErrMsgUserNotFound {
+				status = http.StatusNotFound
+			}
+			response.RespondErr(ctx, w, status, err.Error())
+			return
+		}
+		if err != nil {
+			// Part of the file is already sent, the truncated file is all we can do
+			logger.FromContext(ctx).Error().Err(err).Msg("Export interrupted")
+			return
+		}
+
+		start()
+		if err := writer.Flush(); err != nil {
+			logger.FromContext(ctx).Error().Err(err).Msg("Failed to flush export")
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportHandler tests the ImportHandler function.
+func TestImportHandler(t *testing.T) {
+	rows := []bulk.Row{
+		{Line: 1, Relation: repository.Relation{Type: "friendship", Requestor: "a@example.com", Target: "b@example.com"}},
+	}
+
+	tcs := map[string]struct {
+		query       string // Query string of the request
+		contentType string // Content-Type of the request
+		body        string // Request body
+		expCall     bool   // Whether the service method is expected to be called
+		expDryRun   bool   // Expected dry run flag given to the service
+		err         error  // Error returned by the service method
+		expCode     int    // Expected HTTP response code
+		expBody     string // Expected fragment of the response body
+	}{
+		"csv": {
+			body:    "friendship,a@example.com,b@example.com\n",
+			expCall: true,
+			expCode: http.StatusOK,
+			expBody: `"created":1`,
+		},
+		"jsonl_from_content_type": {
+			contentType: "application/x-ndjson",
+			query:       "?dry_run=true",
+			body:        `{"type":"friendship","requestor":"a@example.com","target":"b@example.com"}`,
+			expCall:     true,
+			expDryRun:   true,
+			expCode:     http.StatusOK,
+		},
+		"invalid_format": {
+			query:   "?format=xml",
+			expCode: http.StatusBadRequest,
+			expBody: response.ErrMsgInvalidBulkFormat,
+		},
+		"invalid_dry_run": {
+			query:   "?dry_run=maybe",
+			expCode: http.StatusBadRequest,
+		},
+		"service_error": {
+			body:    "friendship,a@example.com,b@example.com\n",
+			expCall: true,
+			err:     errors.New(response.ErrMsgImportRelations),
+			expCode: http.StatusInternalServerError,
+			expBody: response.ErrMsgImportRelations,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockBulkService)
+			if tc.expCall {
+				if tc.err != nil {
+					mockService.On("Import", mock.Anything, rows, tc.expDryRun).Return(nil, tc.err).Once()
+				} else {
+					mockService.On("Import", mock.Anything, rows, tc.expDryRun).Return(&bulk.Report{DryRun: tc.expDryRun, Total: 1, Valid: 1, Created: 1}, nil).Once()
+				}
+			}
+			req := httptest.NewRequest(http.MethodPost, "/bulk/import"+tc.query, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rr := httptest.NewRecorder()
+
+			// When
+			ImportHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestExportHandler tests the ExportHandler function.
+func TestExportHandler(t *testing.T) {
+	relations := []repository.Relation{
+		{Type: repository.RelationBlock, Requestor: "a@example.com", Target: "b@example.com", Category: repository.BlockCategorySpam, Reason: "Sends ads"},
+	}
+
+	tcs := map[string]struct {
+		query          string // Query string of the request
+		expEmail       string // Expected email given to the service
+		expCall        bool   // Whether the service method is expected to be called
+		err            error  // Error returned by the service method
+		expCode        int    // Expected HTTP response code
+		expContentType string // Expected Content-Type of the response
+		expBody        string // Expected fragment of the response body
+	}{
+		"csv": {
+			expCall:        true,
+			expCode:        http.StatusOK,
+			expContentType: "text/csv",
+			expBody:        "type,requestor,target,category,reason,expires_at\nblock,a@example.com,b@example.com,spam,Sends ads,\n",
+		},
+		"jsonl_for_user": {
+			query:          "?format=jsonl&email=A@Example.com",
+			expEmail:       "a@example.com",
+			expCall:        true,
+			expCode:        http.StatusOK,
+			expContentType: "application/x-ndjson",
+			expBody:        `{"type":"block","requestor":"a@example.com","target":"b@example.com","category":"spam","reason":"Sends ads"}`,
+		},
+		"invalid_email": {
+			query:   "?email=not-an-email",
+			expCode: http.StatusBadRequest,
+			expBody: response.ErrMsgInvalidEmail,
+		},
+		"unknown_user": {
+			query:    "?email=nobody@example.com",
+			expEmail: "nobody@example.com",
+			expCall:  true,
+			err:      errors.New(response.ErrMsgUserNotFound),
+			expCode:  http.StatusNotFound,
+			expBody:  response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockBulkService)
+			if tc.expCall {
+				if tc.err != nil {
+					mockService.On("Export", mock.Anything, tc.expEmail, mock.Anything).Return(nil, tc.err).Once()
+				} else {
+					mockService.On("Export", mock.Anything, tc.expEmail, mock.Anything).Return(relations, nil).Once()
+				}
+			}
+			req := httptest.NewRequest(http.MethodGet, "/bulk/export"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			ExportHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			if tc.expContentType != "" {
+				require.Equal(t, tc.expContentType, rr.Header().Get("Content-Type"))
+			}
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
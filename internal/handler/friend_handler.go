@@ -1,12 +1,21 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/mention"
 	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
 	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
 )
 
 var validate = validator.New()
@@ -21,6 +30,13 @@ type CommonFriendsRequest struct {
 	Friends []string `json:"friends" validate:"required,min=2,max=2,dive,email"`
 }
 
+// RelationshipsRequest defines the structure of the request for retrieving the relationships
+// between a viewer and a batch of targets.
+type RelationshipsRequest struct {
+	Email   string   `json:"email" validate:"required,email"`
+	Targets []string `json:"targets" validate:"required,min=1,dive,email"`
+}
+
 // SubscribeRequest defines the structure of the request for subscribing to updates.
 type SubscribeRequest struct {
 	Requestor string `json:"requestor" validate:"required,email"`
@@ -29,6 +45,12 @@ type SubscribeRequest struct {
 type BlockUpdatesRequest struct {
 	Requestor string `json:"requestor" validate:"required,email"`
 	Target    string `json:"target" validate:"required,email"`
+	// Category is spam, harassment, abuse or other, the default.
+	Category string `json:"category,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// ExpiresAt lifts the block at the given time. The block lasts until the target is
+	// unblocked when it is omitted.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 type getRecipientsRequest struct {
@@ -39,6 +61,16 @@ type getRecipientsRequest struct {
 type getRecipientsResponse struct {
 	Success    bool     `json:"success"`
 	Recipients []string `json:"recipients"`
+	// Sources tells for each recipient whether they are a friend, a subscriber or were mentioned
+	Sources  []service.Recipient `json:"sources"`
+	Mentions []mention.Mention   `json:"mentions"`
+}
+
+// getRecipientsExplainResponse is the response of the recipients endpoint in explain mode.
+type getRecipientsExplainResponse struct {
+	getRecipientsResponse
+	// Excluded lists the candidates that do not receive the update and why
+	Excluded []service.Exclusion `json:"excluded"`
 }
 
 // NewHandler creates a new HTTP handler for creating a friend connection.
@@ -62,7 +94,7 @@ func NewHandler(friendService service.FriendService) http.HandlerFunc {
 		// Call the friend service to create the friend connection
 		err := friendService.CreateFriend(ctx, req.Friends[0], req.Friends[1])
 		if err != nil {
-			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
 			return
 		}
 
@@ -134,7 +166,95 @@ func CommonFriendsHandler(friendService service.FriendService) http.HandlerFunc
 	}
 }
 
-// SubscribeHandler creates a new HTTP handler for subscribing to updates.
+// FriendSuggestionsHandler creates a new HTTP handler for retrieving the friend suggestions of a user.
+// It accepts the limit and offset query parameters.
+func FriendSuggestionsHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		suggestions, err := friendService.GetFriendSuggestions(ctx, chi.URLParam(r, "email"), limit, offset)
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"suggestions": suggestions,
+			"count":       len(suggestions),
+			"limit":       limit,
+			"offset":      offset,
+		})
+	}
+}
+
+// RelationshipsHandler creates a new HTTP handler for retrieving the relationships between
+// a viewer and a batch of targets.
+func RelationshipsHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RelationshipsRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest+": "+err.Error())
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		relationships, err := friendService.GetRelationships(ctx, req.Email, req.Targets)
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, relationships)
+	}
+}
+
+// RelationshipHandler creates a new HTTP handler returning the relationship between the user a
+// and the user b of the URL, as seen by a.
+func RelationshipHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		rel, err := friendService.GetRelationship(ctx, chi.URLParam(r, "a"), chi.URLParam(r, "b"))
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+		response.RespondSuccess(ctx, w, rel)
+	}
+}
+
+// friendErrStatus maps a friend service error to the HTTP status code of the response.
+func friendErrStatus(err error) int {
+	cause := errors.Cause(err)
+	switch {
+	case cause == sql.ErrNoRows, cause.Error() == response.ErrMsgUserNotFound, cause.Error() == response.ErrMsgSubscriptionNotPending,
+		cause.Error() == response.ErrMsgNotMuted:
+		return http.StatusNotFound
+	case strings.HasPrefix(cause.Error(), response.ErrMsgTooManyTargets), cause.Error() == response.ErrMsgInvalidFollowerPolicy,
+		cause.Error() == response.ErrMsgInvalidExpiry, cause.Error() == response.ErrMsgSelfRelation,
+		cause.Error() == response.ErrMsgInvalidBlockCategory:
+		return http.StatusBadRequest
+	case cause.Error() == response.ErrMsgInactiveUser:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// SubscribeHandler creates a new HTTP handler for subscribing to updates. The response tells
+// whether the subscription is approved or pending, when the target is private.
 func SubscribeHandler(friendService service.FriendService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req SubscribeRequest
@@ -152,18 +272,19 @@ func SubscribeHandler(friendService service.FriendService) http.HandlerFunc {
 		}
 
 		// Call the friend service to subscribe to updates
-		err := friendService.SubscribeUpdates(ctx, req.Requestor, req.Target)
+		status, err := friendService.SubscribeUpdates(ctx, req.Requestor, req.Target)
 		if err != nil {
-			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
 			return
 		}
 
 		// Respond with success
-		response.RespondSuccess(ctx, w, nil)
+		response.RespondSuccess(ctx, w, map[string]string{"status": status})
 	}
 }
 
-// BlockUpdatesHandler creates a new HTTP handler for blocking updates from an email address.
+// BlockUpdatesHandler creates a new HTTP handler for blocking updates from an email address,
+// for good or until the given expiry.
 func BlockUpdatesHandler(friendService service.FriendService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req BlockUpdatesRequest
@@ -176,9 +297,13 @@ func BlockUpdatesHandler(friendService service.FriendService) http.HandlerFunc {
 		}
 
 		// Call the friend service to block updates
-		err := friendService.BlockUpdates(ctx, req.Requestor, req.Target)
+		err := friendService.BlockUpdates(ctx, req.Requestor, req.Target, repository.BlockDetails{
+			Category:  req.Category,
+			Reason:    req.Reason,
+			ExpiresAt: req.ExpiresAt,
+		})
 		if err != nil {
-			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
 			return
 		}
 
@@ -188,6 +313,8 @@ func BlockUpdatesHandler(friendService service.FriendService) http.HandlerFunc {
 }
 
 // GetRecipientsHandler creates a new HTTP handler for get recipient.
+// With the explain=true query parameter the response also lists the excluded candidates
+// along with the reason they are excluded.
 func GetRecipientsHandler(friendService service.FriendService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req getRecipientsRequest
@@ -196,21 +323,55 @@ func GetRecipientsHandler(friendService service.FriendService) http.HandlerFunc
 			return
 		}
 
-		recipients, err := friendService.GetEligibleRecipients(r.Context(), req.Sender, req.Text)
+		explain, err := parseBoolParam(r, "explain")
+		if err != nil {
+			response.RespondErr(r.Context(), w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		result, err := friendService.GetRecipients(r.Context(), req.Sender, req.Text)
 		if err != nil {
-			response.RespondErr(r.Context(), w, http.StatusInternalServerError, err.Error())
+			response.RespondErr(r.Context(), w, friendErrStatus(err), err.Error())
 			return
 		}
 
 		res := getRecipientsResponse{
 			Success:    true,
-			Recipients: recipients,
+			Recipients: make([]string, 0, len(result.Recipients)),
+			Sources:    result.Recipients,
+			Mentions:   result.Mentions,
+		}
+		for _, recipient := range result.Recipients {
+			res.Recipients = append(res.Recipients, recipient.Email)
+		}
+		if res.Mentions == nil {
+			res.Mentions = []mention.Mention{}
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(res); err != nil {
-			response.RespondErr(r.Context(), w, http.StatusInternalServerError, err.Error())
+		if explain {
+			excluded := result.Excluded
+			if excluded == nil {
+				excluded = []service.Exclusion{}
+			}
+			response.RespondJSON(r.Context(), w, http.StatusOK, getRecipientsExplainResponse{
+				getRecipientsResponse: res,
+				Excluded:              excluded,
+			})
 			return
 		}
+		response.RespondJSON(r.Context(), w, http.StatusOK, res)
+	}
+}
+
+// parseBoolParam parses an optional boolean query parameter, false when absent.
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
 	}
+	return b, nil
 }
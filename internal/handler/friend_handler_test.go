@@ -2,15 +2,20 @@ package handler
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
 	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -47,6 +52,12 @@ func TestNewHandler_CreateFriend(t *testing.T) {
 			expCode:  http.StatusInternalServerError,
 			expError: response.ErrMsgCreateFriend,
 		},
+		"inactive_requestor": {
+			input:    []string{"test1@example.com", "test2@example.com"},
+			mockFn:   mockService{expCall: true, input: []string{"test1@example.com", "test2@example.com"}, err: errors.New(response.ErrMsgInactiveUser)},
+			expCode:  http.StatusForbidden,
+			expError: response.ErrMsgInactiveUser,
+		},
 	}
 
 	// Iterate over each test case
@@ -224,11 +235,203 @@ func TestCommonFriendsHandler_GetCommonFriends(t *testing.T) {
 	}
 }
 
+// TestFriendSuggestionsHandler_GetFriendSuggestions tests the FriendSuggestionsHandler function.
+func TestFriendSuggestionsHandler_GetFriendSuggestions(t *testing.T) {
+	tcs := map[string]struct {
+		path    string // Request path
+		expCall bool   // Whether the service method is expected to be called
+		limit   int    // Expected limit
+		offset  int    // Expected offset
+		err     error  // Error returned by the service method
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			path:    "/users/andy@example.com/suggestions?limit=5&offset=10",
+			expCall: true,
+			limit:   5,
+			offset:  10,
+			expCode: http.StatusOK,
+			expBody: `"mutual_friends":["john@example.com","lisa@example.com"]`,
+		},
+		"invalid_limit": {
+			path:    "/users/andy@example.com/suggestions?limit=0",
+			expCode: http.StatusBadRequest,
+		},
+		"user_not_found": {
+			path:    "/users/andy@example.com/suggestions",
+			expCall: true,
+			limit:   defaultPageLimit,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgUserNotFound,
+		},
+		"service_error": {
+			path:    "/users/andy@example.com/suggestions",
+			expCall: true,
+			limit:   defaultPageLimit,
+			err:     errors.New(response.ErrMsgGetSuggestions),
+			expCode: http.StatusInternalServerError,
+			expBody: response.ErrMsgGetSuggestions,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.expCall {
+				var suggestions []repository.Suggestion
+				if tc.err == nil {
+					suggestions = []repository.Suggestion{
+						{Email: "kate@example.com", MutualCount: 2, MutualFriends: []string{"john@example.com", "lisa@example.com"}},
+					}
+				}
+				mockService.On("GetFriendSuggestions", mock.Anything, "andy@example.com", tc.limit, tc.offset).
+					Return(suggestions, tc.err).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/users/{email}/suggestions", FriendSuggestionsHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRelationshipsHandler_GetRelationships tests the RelationshipsHandler function.
+func TestRelationshipsHandler_GetRelationships(t *testing.T) {
+	tcs := map[string]struct {
+		body    string // Request body
+		expCall bool   // Whether the service method is expected to be called
+		err     error  // Error returned by the service method
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			body:    `{"email":"andy@example.com","targets":["kate@example.com","ghost@example.com"]}`,
+			expCall: true,
+			expCode: http.StatusOK,
+			expBody: `"not_found":["ghost@example.com"]`,
+		},
+		"no_targets": {
+			body:    `{"email":"andy@example.com","targets":[]}`,
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_target": {
+			body:    `{"email":"andy@example.com","targets":["kate"]}`,
+			expCode: http.StatusBadRequest,
+		},
+		"too_many_targets": {
+			body:    `{"email":"andy@example.com","targets":["kate@example.com","ghost@example.com"]}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgTooManyTargets + ": at most 100"),
+			expCode: http.StatusBadRequest,
+		},
+		"viewer_not_found": {
+			body:    `{"email":"andy@example.com","targets":["kate@example.com","ghost@example.com"]}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.expCall {
+				targets := []string{"kate@example.com", "ghost@example.com"}
+				if tc.err != nil {
+					mockService.On("GetRelationships", mock.Anything, "andy@example.com", targets).Return(nil, tc.err).Once()
+				} else {
+					mockService.On("GetRelationships", mock.Anything, "andy@example.com", targets).Return(&service.Relationships{
+						Relationships: []repository.Relationship{{Email: "andy@example.com", Target: "kate@example.com", Friends: true}},
+						NotFound:      []string{"ghost@example.com"},
+					}, nil).Once()
+				}
+			}
+			req := httptest.NewRequest(http.MethodPost, "/friend/relationships", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// When
+			RelationshipsHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestRelationshipHandler tests the RelationshipHandler function.
+func TestRelationshipHandler(t *testing.T) {
+	tcs := map[string]struct {
+		err     error  // Error returned by the service method
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			expCode: http.StatusOK,
+			expBody: `"following":true,"followed_by":false`,
+		},
+		"same_user": {
+			err:     errors.New(response.ErrMsgSelfRelation),
+			expCode: http.StatusBadRequest,
+		},
+		"user_not_found": {
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+		},
+		"service_error": {
+			err:     errors.New(response.ErrMsgGetRelationship),
+			expCode: http.StatusInternalServerError,
+			expBody: response.ErrMsgGetRelationship,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.err != nil {
+				mockService.On("GetRelationship", mock.Anything, "andy@example.com", "kate@example.com").Return(nil, tc.err).Once()
+			} else {
+				mockService.On("GetRelationship", mock.Anything, "andy@example.com", "kate@example.com").
+					Return(&repository.Relationship{Email: "andy@example.com", Target: "kate@example.com", Following: true}, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/v2/users/{a}/relationship/{b}", RelationshipHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, "/v2/users/andy@example.com/relationship/kate@example.com", nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 // TestSubscribeHandler_SubscribeUpdates tests the SubscribeHandler function for subscribing to updates.
 func TestSubscribeHandler_SubscribeUpdates(t *testing.T) {
 	type mockService struct {
 		expCall bool             // Whether the service method is expected to be called
 		input   SubscribeRequest // Input data expected to be passed to the service method
+		status  string           // Status of the subscription returned by the service method
 		err     error            // Error expected to be returned by the service method
 	}
 
@@ -237,12 +440,26 @@ func TestSubscribeHandler_SubscribeUpdates(t *testing.T) {
 		req      SubscribeRequest // Input request data
 		mockFn   mockService      // Function to set up mock
 		expCode  int              // expected HTTP response code
+		expBody  string           // expected fragment of the response body
 		expError string           // expected error message
 	}{
 		"success": {
 			req:     SubscribeRequest{Requestor: "subscriber@example.com", Target: "target@example.com"},
-			mockFn:  mockService{expCall: true, input: SubscribeRequest{Requestor: "subscriber@example.com", Target: "target@example.com"}, err: nil},
+			mockFn:  mockService{expCall: true, input: SubscribeRequest{Requestor: "subscriber@example.com", Target: "target@example.com"}, status: "approved", err: nil},
+			expCode: http.StatusOK,
+			expBody: `"status":"approved"`,
+		},
+		"pending": {
+			req:     SubscribeRequest{Requestor: "subscriber@example.com", Target: "private@example.com"},
+			mockFn:  mockService{expCall: true, input: SubscribeRequest{Requestor: "subscriber@example.com", Target: "private@example.com"}, status: "pending", err: nil},
 			expCode: http.StatusOK,
+			expBody: `"status":"pending"`,
+		},
+		"unknown_target": {
+			req:      SubscribeRequest{Requestor: "subscriber@example.com", Target: "nobody@example.com"},
+			mockFn:   mockService{expCall: true, input: SubscribeRequest{Requestor: "subscriber@example.com", Target: "nobody@example.com"}, err: pkgerrors.Wrap(sql.ErrNoRows, response.ErrMsgUserNotFound)},
+			expCode:  http.StatusNotFound,
+			expError: response.ErrMsgUserNotFound,
 		},
 		"invalid_json": {
 			req:      SubscribeRequest{},                                              // Invalid JSON data
@@ -260,7 +477,7 @@ func TestSubscribeHandler_SubscribeUpdates(t *testing.T) {
 			// Given
 			mockFriendService := new(service.MockFriendService)
 			if tc.mockFn.expCall {
-				mockFriendService.On("SubscribeUpdates", mock.Anything, tc.mockFn.input.Requestor, tc.mockFn.input.Target).Return(tc.mockFn.err)
+				mockFriendService.On("SubscribeUpdates", mock.Anything, tc.mockFn.input.Requestor, tc.mockFn.input.Target).Return(tc.mockFn.status, tc.mockFn.err)
 			}
 			subscribeHandler := SubscribeHandler(mockFriendService)
 
@@ -283,6 +500,7 @@ func TestSubscribeHandler_SubscribeUpdates(t *testing.T) {
 			if tc.expError != "" {
 				require.True(t, strings.Contains(rr.Body.String(), tc.expError))
 			}
+			require.Contains(t, rr.Body.String(), tc.expBody)
 			// Assert that the expected calls to the mock service were made
 			mockFriendService.AssertExpectations(t)
 		})
@@ -294,9 +512,12 @@ func TestBlockUpdatesHandler_BlockUpdates(t *testing.T) {
 	type mockService struct {
 		expCall bool
 		input   BlockUpdatesRequest
+		details repository.BlockDetails
 		err     error
 	}
 
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
 	// Define test cases for different scenarios
 	tcs := map[string]struct {
 		req      BlockUpdatesRequest // Input request data
@@ -309,6 +530,26 @@ func TestBlockUpdatesHandler_BlockUpdates(t *testing.T) {
 			mockFn:  mockService{expCall: true, input: BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com"}, err: nil},
 			expCode: http.StatusOK,
 		},
+		"temporary_block": {
+			req: BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com", Category: "spam", Reason: "Sends ads", ExpiresAt: &expiresAt},
+			mockFn: mockService{
+				expCall: true,
+				input:   BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com"},
+				details: repository.BlockDetails{Category: "spam", Reason: "Sends ads", ExpiresAt: &expiresAt},
+			},
+			expCode: http.StatusOK,
+		},
+		"invalid_category": {
+			req: BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com", Category: "rude"},
+			mockFn: mockService{
+				expCall: true,
+				input:   BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com"},
+				details: repository.BlockDetails{Category: "rude"},
+				err:     errors.New(response.ErrMsgInvalidBlockCategory),
+			},
+			expCode:  http.StatusBadRequest,
+			expError: response.ErrMsgInvalidBlockCategory,
+		},
 		"service_error": {
 			req:      BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com"},
 			mockFn:   mockService{expCall: true, input: BlockUpdatesRequest{Requestor: "andy@example.com", Target: "john@example.com"}, err: errors.New("Failed to block updates")},
@@ -323,7 +564,7 @@ func TestBlockUpdatesHandler_BlockUpdates(t *testing.T) {
 			// Given
 			mockFriendService := new(service.MockFriendService)
 			if tc.mockFn.expCall {
-				mockFriendService.On("BlockUpdates", mock.Anything, tc.mockFn.input.Requestor, tc.mockFn.input.Target).Return(tc.mockFn.err)
+				mockFriendService.On("BlockUpdates", mock.Anything, tc.mockFn.input.Requestor, tc.mockFn.input.Target, tc.mockFn.details).Return(tc.mockFn.err)
 			}
 			blockUpdatesHandler := BlockUpdatesHandler(mockFriendService)
 
@@ -357,21 +598,42 @@ func TestGetRecipientsHandler_GetRecipients(t *testing.T) {
 	type mockService struct {
 		expCall bool                 // Whether the service method is expected to be called
 		input   getRecipientsRequest // Input data expected to be passed to the service method
-		output  []string             // Output data to be returned by the service method
+		output  *service.Recipients  // Output data to be returned by the service method
 		err     error                // Error expected to be returned by the service method
 	}
 
 	// Define test cases for different scenarios
 	tcs := map[string]struct {
 		req      getRecipientsRequest // Input request data
+		query    string               // Query string of the request
 		mockFn   mockService          // Function to set up mock
 		expCode  int                  // expected HTTP response code
 		expError string               // expected error message
+		expBody  string               // expected fragment of the response body
 	}{
 		"success": {
-			req:     getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"},
-			mockFn:  mockService{expCall: true, input: getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"}, output: []string{"recipient@example.com"}, err: nil},
+			req: getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"},
+			mockFn: mockService{expCall: true, input: getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"}, output: &service.Recipients{
+				Recipients: []service.Recipient{{Email: "recipient@example.com", Sources: []string{service.SourceFriend}}},
+			}, err: nil},
 			expCode: http.StatusOK,
+			expBody: `"recipients":["recipient@example.com"],"sources":[{"email":"recipient@example.com","sources":["friend"]}],"mentions":[]}`,
+		},
+		"explain": {
+			req:   getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"},
+			query: "?explain=true",
+			mockFn: mockService{expCall: true, input: getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"}, output: &service.Recipients{
+				Recipients: []service.Recipient{{Email: "recipient@example.com", Sources: []string{service.SourceFriend}}},
+				Excluded:   []service.Exclusion{{Email: "blocker@example.com", Sources: []string{service.SourceFriend}, Reason: service.ExclusionBlockedSender}},
+			}, err: nil},
+			expCode: http.StatusOK,
+			expBody: `"excluded":[{"email":"blocker@example.com","sources":["friend"],"reason":"blocked_sender"}]`,
+		},
+		"invalid_explain": {
+			req:      getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"},
+			query:    "?explain=maybe",
+			expCode:  http.StatusBadRequest,
+			expError: "explain must be true or false",
 		},
 		"error": {
 			req:      getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"},
@@ -379,6 +641,12 @@ func TestGetRecipientsHandler_GetRecipients(t *testing.T) {
 			expCode:  http.StatusInternalServerError,
 			expError: response.ErrMsgDecodeRequest,
 		},
+		"inactive_sender": {
+			req:      getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"},
+			mockFn:   mockService{expCall: true, input: getRecipientsRequest{Sender: "sender@example.com", Text: "Hello World!"}, output: nil, err: errors.New(response.ErrMsgInactiveUser)},
+			expCode:  http.StatusForbidden,
+			expError: response.ErrMsgInactiveUser,
+		},
 	}
 
 	// Iterate over each test case
@@ -387,14 +655,14 @@ func TestGetRecipientsHandler_GetRecipients(t *testing.T) {
 			// Given
 			mockFriendService := new(service.MockFriendService)
 			if tc.mockFn.expCall {
-				mockFriendService.On("GetEligibleRecipients", mock.Anything, tc.mockFn.input.Sender, tc.mockFn.input.Text).Return(tc.mockFn.output, tc.mockFn.err)
+				mockFriendService.On("GetRecipients", mock.Anything, tc.mockFn.input.Sender, tc.mockFn.input.Text).Return(tc.mockFn.output, tc.mockFn.err)
 			}
 			getRecipientsHandler := GetRecipientsHandler(mockFriendService)
 
 			// Marshal input data to JSON
 			body, _ := json.Marshal(tc.req)
 			// Create HTTP request with the JSON payload
-			req, err := http.NewRequest("POST", "/recipients", bytes.NewBuffer(body))
+			req, err := http.NewRequest("POST", "/recipients"+tc.query, bytes.NewBuffer(body))
 			require.NoError(t, err)
 
 			rr := httptest.NewRecorder()
@@ -410,6 +678,9 @@ func TestGetRecipientsHandler_GetRecipients(t *testing.T) {
 			if tc.expError != "" {
 				require.True(t, strings.Contains(rr.Body.String(), tc.expError))
 			}
+			if tc.expBody != "" {
+				require.Contains(t, rr.Body.String(), tc.expBody)
+			}
 			// Assert that the expected calls to the mock service were made
 			mockFriendService.AssertExpectations(t)
 		})
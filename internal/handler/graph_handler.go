@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// FriendPathRequest defines the structure of the request for finding the friendship path between two users.
+type FriendPathRequest struct {
+	Friends []string `json:"friends" validate:"required,min=2,max=2,dive,email"`
+	// MaxDepth is the largest number of friendships searched, 0 meaning the configured limit.
+	MaxDepth int `json:"max_depth" validate:"min=0"`
+}
+
+// FriendPathHandler creates a new HTTP handler for finding the shortest friendship path between two users.
+func FriendPathHandler(graphService service.GraphService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req FriendPathRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest+": "+err.Error())
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		path, err := graphService.GetFriendPath(ctx, req.Friends[0], req.Friends[1], req.MaxDepth)
+		respondPath(ctx, w, path, err)
+	}
+}
+
+// UserPathHandler creates a new HTTP handler for finding the shortest friendship path from the user a
+// to the user b of the URL. It accepts the max_depth query parameter.
+func UserPathHandler(graphService service.GraphService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var maxDepth int
+		if v := r.URL.Query().Get("max_depth"); v != "" {
+			var err error
+			if maxDepth, err = strconv.Atoi(v); err != nil || maxDepth < 1 {
+				response.RespondErr(ctx, w, http.StatusBadRequest, "max_depth must be a positive integer")
+				return
+			}
+		}
+
+		path, err := graphService.GetFriendPath(ctx, chi.URLParam(r, "a"), chi.URLParam(r, "b"), maxDepth)
+		respondPath(ctx, w, path, err)
+	}
+}
+
+// respondPath writes the result of a friendship path search.
+func respondPath(ctx context.Context, w http.ResponseWriter, path []string, err error) {
+	if err != nil {
+		response.RespondErr(ctx, w, graphErrStatus(err), err.Error())
+		return
+	}
+
+	body := map[string]interface{}{
+		"success":   true,
+		"connected": path != nil,
+		"path":      []string{},
+	}
+	if path != nil {
+		body["path"] = path
+		body["degree"] = len(path) - 1
+	}
+	response.RespondSuccess(ctx, w, body)
+}
+
+// graphErrStatus maps a graph service error to the HTTP status code of the response.
+func graphErrStatus(err error) int {
+	cause := errors.Cause(err)
+	switch {
+	case cause == sql.ErrNoRows, cause.Error() == response.ErrMsgUserNotFound:
+		return http.StatusNotFound
+	case strings.HasPrefix(cause.Error(), response.ErrMsgInvalidPathDepth), cause.Error() == response.ErrMsgSelfRelation:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFriendPathHandler tests the FriendPathHandler function.
+func TestFriendPathHandler(t *testing.T) {
+	tcs := map[string]struct {
+		body     string   // Request body
+		expCall  bool     // Whether the service method is expected to be called
+		maxDepth int      // Expected maximum depth
+		path     []string // Path returned by the service method
+		err      error    // Error returned by the service method
+		expCode  int      // Expected HTTP response code
+		expBody  string   // Expected fragment of the response body
+	}{
+		"connected": {
+			body:     `{"friends":["andy@example.com","kate@example.com"],"max_depth":3}`,
+			expCall:  true,
+			maxDepth: 3,
+			path:     []string{"andy@example.com", "john@example.com", "kate@example.com"},
+			expCode:  http.StatusOK,
+			expBody:  `"degree":2`,
+		},
+		"not_connected": {
+			body:    `{"friends":["andy@example.com","kate@example.com"]}`,
+			expCall: true,
+			expCode: http.StatusOK,
+			expBody: `"connected":false`,
+		},
+		"one_email": {
+			body:    `{"friends":["andy@example.com"]}`,
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_body": {
+			body:    `{"friends":`,
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_max_depth": {
+			body:     `{"friends":["andy@example.com","kate@example.com"],"max_depth":9}`,
+			expCall:  true,
+			maxDepth: 9,
+			err:      errors.New(response.ErrMsgInvalidPathDepth + ": must be between 1 and 6"),
+			expCode:  http.StatusBadRequest,
+		},
+		"user_not_found": {
+			body:    `{"friends":["andy@example.com","kate@example.com"]}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockGraphService)
+			if tc.expCall {
+				mockService.On("GetFriendPath", mock.Anything, "andy@example.com", "kate@example.com", tc.maxDepth).
+					Return(tc.path, tc.err).Once()
+			}
+			req := httptest.NewRequest(http.MethodPost, "/friend/path", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// When
+			FriendPathHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUserPathHandler tests the UserPathHandler function.
+func TestUserPathHandler(t *testing.T) {
+	tcs := map[string]struct {
+		query    string // Query string of the request
+		expCall  bool   // Whether the service method is expected to be called
+		maxDepth int    // Expected maximum depth
+		expCode  int    // Expected HTTP response code
+	}{
+		"success": {
+			expCall: true,
+			expCode: http.StatusOK,
+		},
+		"with_max_depth": {
+			query:    "?max_depth=2",
+			expCall:  true,
+			maxDepth: 2,
+			expCode:  http.StatusOK,
+		},
+		"invalid_max_depth": {
+			query:   "?max_depth=none",
+			expCode: http.StatusBadRequest,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockGraphService)
+			if tc.expCall {
+				mockService.On("GetFriendPath", mock.Anything, "andy@example.com", "kate@example.com", tc.maxDepth).
+					Return([]string{"andy@example.com", "john@example.com", "kate@example.com"}, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/v2/users/{a}/path/{b}", UserPathHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, "/v2/users/andy@example.com/path/kate@example.com"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
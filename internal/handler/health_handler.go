@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/health"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+)
+
+// statusResponse defines the structure of the detailed status response.
+type statusResponse struct {
+	Build         health.BuildInfo `json:"build"`
+	UptimeSeconds float64          `json:"uptime_seconds"`
+	Readiness     health.Report    `json:"readiness"`
+	DBPool        sql.DBStats      `json:"db_pool"`
+}
+
+// LivenessHandler creates a new HTTP handler reporting that the process is alive.
+// It does not check any dependency so a database outage does not restart the server.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.RespondJSON(r.Context(), w, http.StatusOK, map[string]string{"status": health.StatusOK})
+	}
+}
+
+// ReadinessHandler creates a new HTTP handler reporting whether the server can serve traffic.
+// It responds 503 when any of the registered checks fails.
+func ReadinessHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
+
+		code := http.StatusOK
+		if report.Status != health.StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		response.RespondJSON(r.Context(), w, code, report)
+	}
+}
+
+// StatusHandler creates a new HTTP handler returning build info, uptime, readiness and pool statistics.
+func StatusHandler(checker *health.Checker, db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.RespondJSON(r.Context(), w, http.StatusOK, statusResponse{
+			Build:         health.Build(),
+			UptimeSeconds: checker.Uptime().Seconds(),
+			Readiness:     checker.Check(r.Context()),
+			DBPool:        db.Stats(),
+		})
+	}
+}
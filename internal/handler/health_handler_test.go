@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/health"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLivenessHandler tests the LivenessHandler function.
+func TestLivenessHandler(t *testing.T) {
+	// Given
+	req, err := http.NewRequest("GET", "/healthz", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	// When
+	LivenessHandler().ServeHTTP(rr, req)
+
+	// Then
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+// TestReadinessHandler tests the ReadinessHandler function.
+func TestReadinessHandler(t *testing.T) {
+	tcs := map[string]struct {
+		checks    map[string]health.CheckFunc // Registered readiness checks
+		expCode   int                         // expected HTTP response code
+		expStatus string                      // expected overall status
+	}{
+		"all_checks_pass": {
+			checks: map[string]health.CheckFunc{
+				"database": func(ctx context.Context) error { return nil },
+			},
+			expCode:   http.StatusOK,
+			expStatus: health.StatusOK,
+		},
+		"database_down": {
+			checks: map[string]health.CheckFunc{
+				"database": func(ctx context.Context) error { return errors.New("connection refused") },
+				"schema":   func(ctx context.Context) error { return nil },
+			},
+			expCode:   http.StatusServiceUnavailable,
+			expStatus: health.StatusUnavailable,
+		},
+		"check_times_out": {
+			checks: map[string]health.CheckFunc{
+				"database": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			expCode:   http.StatusServiceUnavailable,
+			expStatus: health.StatusUnavailable,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			checker := health.NewChecker(10 * time.Millisecond)
+			for name, fn := range tc.checks {
+				checker.Register(name, fn)
+			}
+
+			req, err := http.NewRequest("GET", "/readyz", nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// When
+			ReadinessHandler(checker).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), `"status":"`+tc.expStatus+`"`)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// MuteRequest defines the structure of the request for muting the updates of a user.
+type MuteRequest struct {
+	Requestor string `json:"requestor" validate:"required,email"`
+	Target    string `json:"target" validate:"required,email"`
+	// ExpiresAt ends the mute at the given time. The mute lasts until the target is unmuted
+	// when it is omitted.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// MuteHandler creates a new HTTP handler muting the updates of the target for the requestor,
+// without the target being told and without changing their friendship or subscriptions.
+func MuteHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req MuteRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := friendService.MuteUpdates(ctx, req.Requestor, req.Target, req.ExpiresAt); err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, nil)
+	}
+}
+
+// UnmuteHandler creates a new HTTP handler letting the updates of the target reach the
+// requestor again.
+func UnmuteHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SubscribeRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := friendService.UnmuteUpdates(ctx, req.Requestor, req.Target); err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, nil)
+	}
+}
+
+// MutesHandler creates a new HTTP handler listing the users currently muted by a user.
+func MutesHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		mutes, err := friendService.ListMutes(ctx, chi.URLParam(r, "email"))
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"mutes": mutes,
+			"count": len(mutes),
+		})
+	}
+}
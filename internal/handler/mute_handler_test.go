@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMuteHandler tests the MuteHandler function.
+func TestMuteHandler(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		body         string     // Request body
+		expCall      bool       // Whether the service is expected to be called
+		expExpiresAt *time.Time // Expiry expected to be passed to the service
+		err          error      // Error returned by the service
+		expCode      int        // Expected HTTP response code
+		expBody      string     // Expected fragment of the response body
+	}{
+		"success": {
+			body:    `{"requestor": "andy@example.com", "target": "john@example.com"}`,
+			expCall: true,
+			expCode: http.StatusOK,
+		},
+		"success_with_expiry": {
+			body:         `{"requestor": "andy@example.com", "target": "john@example.com", "expires_at": "2030-01-01T00:00:00Z"}`,
+			expCall:      true,
+			expExpiresAt: &expiresAt,
+			expCode:      http.StatusOK,
+		},
+		"invalid_email": {
+			body:    `{"requestor": "andy", "target": "john@example.com"}`,
+			expCode: http.StatusBadRequest,
+			expBody: "Requestor",
+		},
+		"expiry_in_the_past": {
+			body:         `{"requestor": "andy@example.com", "target": "john@example.com", "expires_at": "2030-01-01T00:00:00Z"}`,
+			expCall:      true,
+			expExpiresAt: &expiresAt,
+			err:          errors.New(response.ErrMsgInvalidExpiry),
+			expCode:      http.StatusBadRequest,
+			expBody:      response.ErrMsgInvalidExpiry,
+		},
+		"self_mute": {
+			body:    `{"requestor": "andy@example.com", "target": "john@example.com"}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgSelfRelation),
+			expCode: http.StatusBadRequest,
+			expBody: response.ErrMsgSelfRelation,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.expCall {
+				mockService.On("MuteUpdates", mock.Anything, "andy@example.com", "john@example.com", tc.expExpiresAt).Return(tc.err).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/friend/mute", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// When
+			MuteHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUnmuteHandler tests the UnmuteHandler function.
+func TestUnmuteHandler(t *testing.T) {
+	tcs := map[string]struct {
+		err     error  // Error returned by the service
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			expCode: http.StatusOK,
+		},
+		"not_muted": {
+			err:     errors.New(response.ErrMsgNotMuted),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgNotMuted,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			mockService.On("UnmuteUpdates", mock.Anything, "andy@example.com", "john@example.com").Return(tc.err).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/friend/unmute", strings.NewReader(`{"requestor": "andy@example.com", "target": "john@example.com"}`))
+			rr := httptest.NewRecorder()
+
+			// When
+			UnmuteHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestMutesHandler tests the MutesHandler function.
+func TestMutesHandler(t *testing.T) {
+	tcs := map[string]struct {
+		mutes   []repository.Mute // Mutes returned by the service
+		err     error             // Error returned by the service
+		expCode int               // Expected HTTP response code
+		expBody string            // Expected fragment of the response body
+	}{
+		"success": {
+			mutes: []repository.Mute{
+				{Target: "john@example.com", CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+			},
+			expCode: http.StatusOK,
+			expBody: `"count":1,"mutes":[{"target":"john@example.com","expires_at":null,"created_at":"2024-05-01T10:00:00Z"}]`,
+		},
+		"user_not_found": {
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.err != nil {
+				mockService.On("ListMutes", mock.Anything, "andy@example.com").Return(nil, tc.err).Once()
+			} else {
+				mockService.On("ListMutes", mock.Anything, "andy@example.com").Return(tc.mutes, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/users/{email}/mutes", MutesHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, "/users/andy@example.com/mutes", nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// SetPrivacyRequest defines the structure of the request for making a user private or public.
+type SetPrivacyRequest struct {
+	Private *bool `json:"private" validate:"required"`
+	// Policy is applied to the existing subscribers when the user becomes private: keep,
+	// review or remove. It defaults to keep.
+	Policy string `json:"policy"`
+}
+
+// SetPrivacyHandler creates a new HTTP handler making a user private or public. Subscriptions
+// to a private user are pending until the user approves them.
+func SetPrivacyHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetPrivacyRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		change, err := friendService.SetPrivacy(ctx, chi.URLParam(r, "email"), *req.Private, req.Policy)
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, change)
+	}
+}
+
+// SubscriptionRequestsHandler creates a new HTTP handler listing the pending subscriptions to
+// the updates of a user, oldest first.
+func SubscriptionRequestsHandler(friendService service.FriendService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		requests, err := friendService.ListSubscriptionRequests(ctx, chi.URLParam(r, "email"), limit, offset)
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"requests": requests,
+			"count":    len(requests),
+			"limit":    limit,
+			"offset":   offset,
+		})
+	}
+}
+
+// ApproveSubscriptionHandler creates a new HTTP handler approving the pending subscription of
+// the requestor to the updates of the target.
+func ApproveSubscriptionHandler(friendService service.FriendService) http.HandlerFunc {
+	return subscriptionDecisionHandler(friendService.ApproveSubscription)
+}
+
+// DenySubscriptionHandler creates a new HTTP handler denying the pending subscription of the
+// requestor to the updates of the target.
+func DenySubscriptionHandler(friendService service.FriendService) http.HandlerFunc {
+	return subscriptionDecisionHandler(friendService.DenySubscription)
+}
+
+// subscriptionDecisionHandler creates a new HTTP handler calling decide with the requestor and
+// the target of a pending subscription. Only the target, or an administrator, may decide.
+func subscriptionDecisionHandler(decide func(ctx context.Context, requestor, target string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SubscribeRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		p, ok := auth.FromContext(ctx)
+		if !ok {
+			response.RespondErr(ctx, w, http.StatusUnauthorized, response.ErrMsgUnauthenticated)
+			return
+		}
+		if p.Email != emailaddr.Normalize(req.Target) && !p.Admin {
+			response.RespondErr(ctx, w, http.StatusForbidden, response.ErrMsgForbidden)
+			return
+		}
+
+		if err := decide(ctx, req.Requestor, req.Target); err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, nil)
+	}
+}
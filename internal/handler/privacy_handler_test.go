@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSetPrivacyHandler tests the SetPrivacyHandler function.
+func TestSetPrivacyHandler(t *testing.T) {
+	tcs := map[string]struct {
+		body       string // Request body
+		expCall    bool   // Whether the service is expected to be called
+		expPrivate bool   // Privacy expected to be passed to the service
+		expPolicy  string // Policy expected to be passed to the service
+		err        error  // Error returned by the service
+		expCode    int    // Expected HTTP response code
+		expBody    string // Expected fragment of the response body
+	}{
+		"private": {
+			body:       `{"private": true, "policy": "review"}`,
+			expCall:    true,
+			expPrivate: true,
+			expPolicy:  "review",
+			expCode:    http.StatusOK,
+			expBody:    `"private":true,"policy":"review","subscriptions":2`,
+		},
+		"missing_private": {
+			body:    `{"policy": "review"}`,
+			expCode: http.StatusBadRequest,
+			expBody: "Private",
+		},
+		"invalid_policy": {
+			body:       `{"private": true, "policy": "forget"}`,
+			expCall:    true,
+			expPrivate: true,
+			expPolicy:  "forget",
+			err:        errors.New(response.ErrMsgInvalidFollowerPolicy),
+			expCode:    http.StatusBadRequest,
+			expBody:    response.ErrMsgInvalidFollowerPolicy,
+		},
+		"user_not_found": {
+			body:    `{"private": false}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.expCall {
+				if tc.err != nil {
+					mockService.On("SetPrivacy", mock.Anything, "andy@example.com", tc.expPrivate, tc.expPolicy).Return(nil, tc.err).Once()
+				} else {
+					mockService.On("SetPrivacy", mock.Anything, "andy@example.com", tc.expPrivate, tc.expPolicy).Return(&service.PrivacyChange{
+						Email: "andy@example.com", Private: tc.expPrivate, Policy: tc.expPolicy, Subscriptions: 2,
+					}, nil).Once()
+				}
+			}
+
+			r := chi.NewRouter()
+			r.Put("/users/{email}/privacy", SetPrivacyHandler(mockService))
+			req := httptest.NewRequest(http.MethodPut, "/users/andy@example.com/privacy", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestSubscriptionRequestsHandler tests the SubscriptionRequestsHandler function.
+func TestSubscriptionRequestsHandler(t *testing.T) {
+	tcs := map[string]struct {
+		query   string // Query string of the request
+		expCall bool   // Whether the service is expected to be called
+		err     error  // Error returned by the service
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			query:   "?limit=10&offset=5",
+			expCall: true,
+			expCode: http.StatusOK,
+			expBody: `"count":1,"limit":10,"offset":5,"requests":[{"requestor":"john@example.com","created_at":"2024-05-01T10:00:00Z"}]`,
+		},
+		"invalid_limit": {
+			query:   "?limit=0",
+			expCode: http.StatusBadRequest,
+			expBody: "limit must be between",
+		},
+		"user_not_found": {
+			expCall: true,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			if tc.expCall {
+				call := mockService.On("ListSubscriptionRequests", mock.Anything, "andy@example.com", mock.AnythingOfType("int"), mock.AnythingOfType("int"))
+				if tc.err != nil {
+					call.Return(nil, tc.err).Once()
+				} else {
+					call.Return([]repository.SubscriptionRequest{
+						{Requestor: "john@example.com", CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+					}, nil).Once()
+				}
+			}
+
+			r := chi.NewRouter()
+			r.Get("/users/{email}/subscription-requests", SubscriptionRequestsHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, "/users/andy@example.com/subscription-requests"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestApproveSubscriptionHandler tests the ApproveSubscriptionHandler and DenySubscriptionHandler functions.
+func TestApproveSubscriptionHandler(t *testing.T) {
+	tcs := map[string]struct {
+		deny      bool            // Whether the request is denied rather than approved
+		principal *auth.Principal // Authenticated user making the request
+		body      string          // Request body
+		expCall   bool            // Whether the service is expected to be called
+		err       error           // Error returned by the service
+		expCode   int             // Expected HTTP response code
+		expBody   string          // Expected fragment of the response body
+	}{
+		"approve": {
+			principal: &auth.Principal{Email: "andy@example.com"},
+			body:      `{"requestor": "john@example.com", "target": "andy@example.com"}`,
+			expCall:   true,
+			expCode:   http.StatusOK,
+			expBody:   `"success":true`,
+		},
+		"deny": {
+			deny:      true,
+			principal: &auth.Principal{Email: "andy@example.com"},
+			body:      `{"requestor": "john@example.com", "target": "andy@example.com"}`,
+			expCall:   true,
+			expCode:   http.StatusOK,
+			expBody:   `"success":true`,
+		},
+		"admin": {
+			principal: &auth.Principal{Email: "admin@example.com", Admin: true},
+			body:      `{"requestor": "john@example.com", "target": "andy@example.com"}`,
+			expCall:   true,
+			expCode:   http.StatusOK,
+			expBody:   `"success":true`,
+		},
+		"requestor": {
+			principal: &auth.Principal{Email: "john@example.com"},
+			body:      `{"requestor": "john@example.com", "target": "andy@example.com"}`,
+			expCode:   http.StatusForbidden,
+			expBody:   response.ErrMsgForbidden,
+		},
+		"unauthenticated": {
+			body:    `{"requestor": "john@example.com", "target": "andy@example.com"}`,
+			expCode: http.StatusUnauthorized,
+			expBody: response.ErrMsgUnauthenticated,
+		},
+		"not_pending": {
+			principal: &auth.Principal{Email: "andy@example.com"},
+			body:      `{"requestor": "john@example.com", "target": "andy@example.com"}`,
+			expCall:   true,
+			err:       errors.New(response.ErrMsgSubscriptionNotPending),
+			expCode:   http.StatusNotFound,
+			expBody:   response.ErrMsgSubscriptionNotPending,
+		},
+		"invalid_email": {
+			body:    `{"requestor": "john", "target": "andy@example.com"}`,
+			expCode: http.StatusBadRequest,
+			expBody: "Requestor",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockFriendService)
+			handler, method := ApproveSubscriptionHandler(mockService), "ApproveSubscription"
+			if tc.deny {
+				handler, method = DenySubscriptionHandler(mockService), "DenySubscription"
+			}
+			if tc.expCall {
+				mockService.On(method, mock.Anything, "john@example.com", "andy@example.com").Return(tc.err).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/friend/subscribe/approve", strings.NewReader(tc.body))
+			if tc.principal != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), *tc.principal))
+			}
+			rr := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
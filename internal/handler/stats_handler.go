@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// UserStatsHandler creates a new HTTP handler returning the counters of the relations of a user,
+// read from the stored counters rather than counted.
+func UserStatsHandler(statsService service.StatsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		stats, err := statsService.GetUserStats(ctx, chi.URLParam(r, "email"))
+		if err != nil {
+			response.RespondErr(ctx, w, friendErrStatus(err), err.Error())
+			return
+		}
+		response.RespondSuccess(ctx, w, stats)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserStatsHandler tests the UserStatsHandler function.
+func TestUserStatsHandler(t *testing.T) {
+	tcs := map[string]struct {
+		err     error  // Error returned by the service
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			expCode: http.StatusOK,
+			expBody: `"friends":3,"followers":10,"following":2,"blocked":1`,
+		},
+		"user_not_found": {
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgUserNotFound,
+		},
+		"service_error": {
+			err:     errors.New(response.ErrMsgGetUserStats),
+			expCode: http.StatusInternalServerError,
+			expBody: response.ErrMsgGetUserStats,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockStatsService)
+			if tc.err != nil {
+				mockService.On("GetUserStats", mock.Anything, "andy@example.com").Return(nil, tc.err).Once()
+			} else {
+				mockService.On("GetUserStats", mock.Anything, "andy@example.com").Return(&repository.UserStats{
+					Email: "andy@example.com", Friends: 3, Followers: 10, Following: 2, Blocked: 1,
+				}, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/users/{email}/stats", UserStatsHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, "/users/andy@example.com/stats", nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/metrics"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/stream"
+	"github.com/gorilla/websocket"
+)
+
+// wsReadLimit bounds the messages accepted from WebSocket clients, which are not expected to send any.
+const wsReadLimit = 512
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// streamMessage is the WebSocket frame carrying an update.
+type streamMessage struct {
+	Type string               `json:"type"`
+	Data repository.InboxItem `json:"data"`
+}
+
+// StreamHandler creates a new HTTP handler streaming the updates of the authenticated user in
+// real time. The optional email query parameter must name that user. WebSocket upgrade requests
+// are served over WebSocket, any other request as Server-Sent Events.
+func StreamHandler(hub *stream.Hub, cfg config.Stream) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		p, ok := auth.FromContext(ctx)
+		if !ok {
+			response.RespondErr(ctx, w, http.StatusUnauthorized, response.ErrMsgUnauthenticated)
+			return
+		}
+		if email := r.URL.Query().Get("email"); email != "" && emailaddr.Normalize(email) != p.Email {
+			response.RespondErr(ctx, w, http.StatusForbidden, response.ErrMsgInvalidStreamUser)
+			return
+		}
+
+		sub, err := hub.Subscribe(p.Email)
+		if err == stream.ErrTooManyConnections {
+			response.RespondErr(ctx, w, http.StatusTooManyRequests, err.Error())
+			return
+		}
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		defer hub.Unsubscribe(sub)
+
+		if websocket.IsWebSocketUpgrade(r) {
+			serveWebSocket(w, r, sub, cfg)
+			return
+		}
+		serveSSE(w, r, sub, cfg)
+	}
+}
+
+// serveSSE streams the updates as Server-Sent Events, sending a comment line as heartbeat.
+func serveSSE(w http.ResponseWriter, r *http.Request, sub *stream.Subscriber, cfg config.Stream) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	metrics.StreamConnections.WithLabelValues("sse").Inc()
+	defer metrics.StreamConnections.WithLabelValues("sse").Dec()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// write sends a chunk and flushes it, bounded by the write timeout
+	write := func(format string, args ...interface{}) error {
+		rc.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if err := write("retry: %d\n\n", cfg.HeartbeatInterval.Milliseconds()); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			if sub.Err() == stream.ErrSlowConsumer {
+				// Tell the client to catch up from its inbox after reconnecting
+				write("event: overflow\ndata: {}\n\n")
+			}
+			return
+		case <-heartbeat.C:
+			err = write(": ping\n\n")
+		case item := <-sub.C:
+			b, _ := json.Marshal(item)
+			err = write("id: %d\nevent: update\ndata: %s\n\n", item.UpdateID, b)
+		}
+		if err != nil {
+			logger.FromContext(ctx).Debug().Err(err).Msg("SSE stream closed")
+			return
+		}
+	}
+}
+
+// serveWebSocket streams the updates as JSON text frames, pinging the client as heartbeat
+// and closing the connection when it stops answering.
+func serveWebSocket(w http.ResponseWriter, r *http.Request, sub *stream.Subscriber, cfg config.Stream) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already responded with the error
+		return
+	}
+	defer conn.Close()
+
+	metrics.StreamConnections.WithLabelValues("websocket").Inc()
+	defer metrics.StreamConnections.WithLabelValues("websocket").Dec()
+
+	// The client must answer pings within two heartbeats
+	pongWait := 2 * cfg.HeartbeatInterval
+	conn.SetReadLimit(wsReadLimit)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	// Reading is required to process pongs and close frames; client messages are discarded
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closed:
+			return
+		case <-sub.Done():
+			code := websocket.CloseGoingAway
+			if sub.Err() == stream.ErrSlowConsumer {
+				code = websocket.CloseTryAgainLater
+			}
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, sub.Err().Error()),
+				time.Now().Add(cfg.WriteTimeout))
+			return
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(cfg.WriteTimeout))
+		case item := <-sub.C:
+			conn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+			err = conn.WriteJSON(streamMessage{Type: "update", Data: item})
+		}
+		if err != nil {
+			logger.FromContext(r.Context()).Debug().Err(err).Msg("WebSocket stream closed")
+			return
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/auth"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/stream"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+var testStreamCfg = config.Stream{
+	BufferSize:            4,
+	MaxConnectionsPerUser: 1,
+	HeartbeatInterval:     50 * time.Millisecond,
+	WriteTimeout:          time.Second,
+}
+
+// testAuthSecret signs the tokens of the requests made to test servers.
+const testAuthSecret = "test-secret-test-secret-test-secret"
+
+// bearer returns the Authorization header authenticating the requests as email.
+func bearer(t *testing.T, email string) http.Header {
+	token, err := auth.NewToken(testAuthSecret, auth.Principal{Email: email}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+// waitForStreams waits until the hub has n open streams.
+func waitForStreams(t *testing.T, hub *stream.Hub, n int) {
+	require.Eventually(t, func() bool { return hub.Connections() == n }, 5*time.Second, 5*time.Millisecond)
+}
+
+// TestStreamHandler_SSE tests that updates and heartbeats are streamed as Server-Sent Events.
+func TestStreamHandler_SSE(t *testing.T) {
+	// Given
+	hub := stream.NewHub(testStreamCfg)
+	srv := httptest.NewServer(auth.Middleware(testAuthSecret)(StreamHandler(hub, testStreamCfg)))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"?email=john@example.com", nil)
+	require.NoError(t, err)
+	req.Header = bearer(t, "john@example.com")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	waitForStreams(t, hub, 1)
+
+	// When
+	hub.Publish([]string{"john@example.com"}, repository.InboxItem{UpdateID: 42, Sender: "lisa@example.com", Text: "hello"})
+
+	// Then
+	r := bufio.NewReader(resp.Body)
+	var sawUpdate, sawPing bool
+	for !(sawUpdate && sawPing) {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		switch {
+		case strings.HasPrefix(line, "id: 42"):
+			sawUpdate = true
+		case strings.HasPrefix(line, "data: ") && sawUpdate && !sawPing:
+			require.Contains(t, line, `"text":"hello"`)
+		case strings.HasPrefix(line, ": ping"):
+			sawPing = true
+		}
+	}
+}
+
+// TestStreamHandler_WebSocket tests that updates are streamed over WebSocket.
+func TestStreamHandler_WebSocket(t *testing.T) {
+	// Given
+	hub := stream.NewHub(testStreamCfg)
+	srv := httptest.NewServer(auth.Middleware(testAuthSecret)(StreamHandler(hub, testStreamCfg)))
+	defer srv.Close()
+
+	pinged := make(chan struct{}, 1)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), bearer(t, "john@example.com"))
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second))
+	})
+	waitForStreams(t, hub, 1)
+
+	// When
+	hub.Publish([]string{"john@example.com"}, repository.InboxItem{UpdateID: 42, Text: "hello"})
+
+	// Then
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg streamMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	require.Equal(t, "update", msg.Type)
+	require.Equal(t, int64(42), msg.Data.UpdateID)
+
+	// Pings are answered while reading
+	go conn.ReadMessage()
+	select {
+	case <-pinged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no heartbeat received")
+	}
+}
+
+// TestStreamHandler_Rejected tests the requests refused by the StreamHandler.
+func TestStreamHandler_Rejected(t *testing.T) {
+	tcs := map[string]struct {
+		query     string // Query string of the request
+		principal string // Email of the authenticated user, none when empty
+		open      bool   // Whether the user already has a stream open
+		expCode   int    // Expected HTTP response code
+	}{
+		"unauthenticated": {
+			query:   "?email=john@example.com",
+			expCode: http.StatusUnauthorized,
+		},
+		"other_user": {
+			query:     "?email=lisa@example.com",
+			principal: "john@example.com",
+			expCode:   http.StatusForbidden,
+		},
+		"too_many_connections": {
+			query:     "?email=john@example.com",
+			principal: "john@example.com",
+			open:      true,
+			expCode:   http.StatusTooManyRequests,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			hub := stream.NewHub(testStreamCfg)
+			if tc.open {
+				_, err := hub.Subscribe("john@example.com")
+				require.NoError(t, err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/stream"+tc.query, nil)
+			if tc.principal != "" {
+				req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{Email: tc.principal}))
+			}
+			rr := httptest.NewRecorder()
+
+			// When
+			StreamHandler(hub, testStreamCfg).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+		})
+	}
+}
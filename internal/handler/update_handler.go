@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// PostUpdateRequest defines the structure of the request for posting an update.
+type PostUpdateRequest struct {
+	Sender string `json:"sender" validate:"required,email"`
+	Text   string `json:"text" validate:"required"`
+}
+
+// PostUpdateHandler creates a new HTTP handler for posting an update. It responds
+// 200 OK once the update reached every inbox, or 202 Accepted while it is fanned out
+// in the background.
+func PostUpdateHandler(updateService service.UpdateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PostUpdateRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		update, err := updateService.PostUpdate(ctx, req.Sender, req.Text)
+		if err != nil {
+			response.RespondErr(ctx, w, updateErrStatus(err), err.Error())
+			return
+		}
+
+		code := http.StatusOK
+		if update.FanoutStatus == repository.FanoutStatusPending {
+			code = http.StatusAccepted
+		}
+		response.RespondJSON(ctx, w, code, map[string]interface{}{
+			"success": true,
+			"data":    update,
+		})
+	}
+}
+
+// InboxHandler creates a new HTTP handler for retrieving the inbox of a user.
+// It accepts the limit and offset query parameters.
+func InboxHandler(updateService service.UpdateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		items, err := updateService.GetInbox(ctx, emailaddr.Normalize(chi.URLParam(r, "email")), limit, offset)
+		if err != nil {
+			response.RespondErr(ctx, w, updateErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"updates": items,
+			"count":   len(items),
+			"limit":   limit,
+			"offset":  offset,
+		})
+	}
+}
+
+// updateErrStatus maps an update service error to the HTTP status code of the response.
+func updateErrStatus(err error) int {
+	cause := errors.Cause(err)
+	switch {
+	case cause == sql.ErrNoRows:
+		return http.StatusNotFound
+	case cause.Error() == response.ErrMsgInvalidUpdateText:
+		return http.StatusBadRequest
+	case cause.Error() == response.ErrMsgInactiveUser:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostUpdateHandler tests the PostUpdateHandler function.
+func TestPostUpdateHandler(t *testing.T) {
+	tcs := map[string]struct {
+		body     map[string]string  // Request body
+		expCall  bool               // Whether the service method is expected to be called
+		update   *repository.Update // Update returned by the service method
+		err      error              // Error returned by the service method
+		expCode  int                // Expected HTTP response code
+		expError string             // Expected error message
+	}{
+		"fanned_out": {
+			body:    map[string]string{"sender": "lisa@example.com", "text": "hello"},
+			expCall: true,
+			update:  &repository.Update{ID: 1, FanoutStatus: repository.FanoutStatusDone, RecipientCount: 2},
+			expCode: http.StatusOK,
+		},
+		"fanning_out_in_background": {
+			body:    map[string]string{"sender": "lisa@example.com", "text": "hello"},
+			expCall: true,
+			update:  &repository.Update{ID: 1, FanoutStatus: repository.FanoutStatusPending},
+			expCode: http.StatusAccepted,
+		},
+		"invalid_sender": {
+			body:    map[string]string{"sender": "lisa", "text": "hello"},
+			expCode: http.StatusBadRequest,
+		},
+		"unknown_sender": {
+			body:     map[string]string{"sender": "lisa@example.com", "text": "hello"},
+			expCall:  true,
+			err:      pkgerrors.Wrap(sql.ErrNoRows, response.ErrMsgUserNotFound),
+			expCode:  http.StatusNotFound,
+			expError: response.ErrMsgUserNotFound,
+		},
+		"sender_lookup_error": {
+			body:     map[string]string{"sender": "lisa@example.com", "text": "hello"},
+			expCall:  true,
+			err:      pkgerrors.Wrap(errors.New("connection refused"), response.ErrMsgGetUserByEmail),
+			expCode:  http.StatusInternalServerError,
+			expError: response.ErrMsgGetUserByEmail,
+		},
+		"inactive_sender": {
+			body:     map[string]string{"sender": "lisa@example.com", "text": "hello"},
+			expCall:  true,
+			err:      errors.New(response.ErrMsgInactiveUser),
+			expCode:  http.StatusForbidden,
+			expError: response.ErrMsgInactiveUser,
+		},
+		"create_error": {
+			body:     map[string]string{"sender": "lisa@example.com", "text": "hello"},
+			expCall:  true,
+			err:      errors.New(response.ErrMsgCreateUpdate),
+			expCode:  http.StatusInternalServerError,
+			expError: response.ErrMsgCreateUpdate,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockUpdateService := new(service.MockUpdateService)
+			if tc.expCall {
+				mockUpdateService.On("PostUpdate", mock.Anything, tc.body["sender"], tc.body["text"]).Return(tc.update, tc.err).Once()
+			}
+
+			body, _ := json.Marshal(tc.body)
+			req, err := http.NewRequest(http.MethodPost, "/updates", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// When
+			PostUpdateHandler(mockUpdateService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			if tc.expError != "" {
+				require.Contains(t, rr.Body.String(), tc.expError)
+			}
+			mockUpdateService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestInboxHandler tests the InboxHandler function.
+func TestInboxHandler(t *testing.T) {
+	tcs := map[string]struct {
+		path    string // Request path
+		expCall bool   // Whether the service method is expected to be called
+		limit   int    // Expected limit
+		offset  int    // Expected offset
+		err     error  // Error returned by the service method
+		expCode int    // Expected HTTP response code
+	}{
+		"success": {
+			path:    "/users/john@example.com/inbox?limit=2&offset=4",
+			expCall: true,
+			limit:   2,
+			offset:  4,
+			expCode: http.StatusOK,
+		},
+		"invalid_offset": {
+			path:    "/users/john@example.com/inbox?offset=-1",
+			expCode: http.StatusBadRequest,
+		},
+		"unknown_user": {
+			path:    "/users/john@example.com/inbox",
+			expCall: true,
+			limit:   defaultPageLimit,
+			err:     pkgerrors.Wrap(sql.ErrNoRows, response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockUpdateService := new(service.MockUpdateService)
+			if tc.expCall {
+				mockUpdateService.On("GetInbox", mock.Anything, "john@example.com", tc.limit, tc.offset).
+					Return([]repository.InboxItem{{UpdateID: 1, Sender: "lisa@example.com", Text: "hello"}}, tc.err).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/users/{email}/inbox", InboxHandler(mockUpdateService))
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			mockUpdateService.AssertExpectations(t)
+		})
+	}
+}
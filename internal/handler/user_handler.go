@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// EraseUserRequest defines the structure of the optional request body for erasing a user.
+type EraseUserRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// SetUserStatusRequest defines the structure of the request for changing the account state of a user.
+type SetUserStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+// DuplicateEmailsHandler creates a new HTTP handler reporting the users sharing the same
+// canonical email.
+func DuplicateEmailsHandler(userService service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		duplicates, err := userService.ListDuplicateEmails(ctx)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"duplicates": duplicates,
+			"count":      len(duplicates),
+		})
+	}
+}
+
+// UserDataHandler creates a new HTTP handler exporting all the data held about a user
+// as a JSON archive.
+func UserDataHandler(userService service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		archive, err := userService.ExportUserData(ctx, emailaddr.Normalize(chi.URLParam(r, "email")))
+		if err != nil {
+			response.RespondErr(ctx, w, userErrStatus(err), err.Error())
+			return
+		}
+
+		w.Header().Set("Content-Disposition", `attachment; filename="user-data.json"`)
+		response.RespondSuccess(ctx, w, archive)
+	}
+}
+
+// EraseUserHandler creates a new HTTP handler erasing a user. The mode query parameter
+// selects whether the user is deleted or anonymized, and the request body may give the
+// reason of the erasure.
+func EraseUserHandler(userService service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req EraseUserRequest
+		ctx := r.Context()
+
+		// The body is optional
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		erasure, err := userService.EraseUser(ctx, emailaddr.Normalize(chi.URLParam(r, "email")),
+			r.URL.Query().Get("mode"), req.Reason)
+		if err != nil {
+			response.RespondErr(ctx, w, userErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, erasure)
+	}
+}
+
+// SetUserStatusHandler creates a new HTTP handler changing the account state of a user.
+// Deactivated, suspended and deleted users are hidden until set back to active.
+func SetUserStatusHandler(userService service.UserService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SetUserStatusRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		user, err := userService.SetUserStatus(ctx, emailaddr.Normalize(chi.URLParam(r, "email")), req.Status)
+		if err != nil {
+			response.RespondErr(ctx, w, userErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, user)
+	}
+}
+
+// userErrStatus maps a user service error to the HTTP status code of the response.
+func userErrStatus(err error) int {
+	switch errors.Cause(err).Error() {
+	case response.ErrMsgUserNotFound:
+		return http.StatusNotFound
+	case response.ErrMsgInvalidErasureMode, response.ErrMsgInvalidUserStatus:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
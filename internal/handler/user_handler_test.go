@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDuplicateEmailsHandler tests the DuplicateEmailsHandler function.
+func TestDuplicateEmailsHandler(t *testing.T) {
+	tcs := map[string]struct {
+		duplicates []repository.DuplicateEmail // Duplicates returned by the service
+		err        error                       // Error returned by the service
+		expCode    int                         // Expected HTTP response code
+		expBody    string                      // Expected fragment of the response body
+	}{
+		"success": {
+			duplicates: []repository.DuplicateEmail{{
+				CanonicalEmail: "andy@example.com",
+				Users:          []repository.DuplicateUser{{ID: 1, Email: "andy@example.com"}, {ID: 7, Email: "Andy@Example.com"}},
+			}},
+			expCode: http.StatusOK,
+			expBody: `"canonical_email":"andy@example.com"`,
+		},
+		"service_error": {
+			err:     errors.New(response.ErrMsgGetDuplicateEmails),
+			expCode: http.StatusInternalServerError,
+			expBody: response.ErrMsgGetDuplicateEmails,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockUserService)
+			if tc.err != nil {
+				mockService.On("ListDuplicateEmails", mock.Anything).Return(nil, tc.err).Once()
+			} else {
+				mockService.On("ListDuplicateEmails", mock.Anything).Return(tc.duplicates, nil).Once()
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/users/duplicate-emails", nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			DuplicateEmailsHandler(mockService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUserDataHandler tests the UserDataHandler function.
+func TestUserDataHandler(t *testing.T) {
+	tcs := map[string]struct {
+		err     error  // Error returned by the service
+		expCode int    // Expected HTTP response code
+		expBody string // Expected fragment of the response body
+	}{
+		"success": {
+			expCode: http.StatusOK,
+			expBody: `"friends":[{"email":"john@example.com"`,
+		},
+		"user_not_found": {
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+			expBody: response.ErrMsgUserNotFound,
+		},
+		"service_error": {
+			err:     errors.New(response.ErrMsgExportUserData),
+			expCode: http.StatusInternalServerError,
+			expBody: response.ErrMsgExportUserData,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockUserService)
+			if tc.err != nil {
+				mockService.On("ExportUserData", mock.Anything, "andy@example.com").Return(nil, tc.err).Once()
+			} else {
+				mockService.On("ExportUserData", mock.Anything, "andy@example.com").Return(&repository.UserArchive{
+					Friends: []repository.ArchiveLink{{Email: "john@example.com"}},
+				}, nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/users/{email}/data", UserDataHandler(mockService))
+			req := httptest.NewRequest(http.MethodGet, "/users/Andy@example.com/data", nil)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.expBody)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestEraseUserHandler tests the EraseUserHandler function.
+func TestEraseUserHandler(t *testing.T) {
+	tcs := map[string]struct {
+		query     string // Query string of the request
+		body      string // Request body
+		expMode   string // Expected mode passed to the service
+		expReason string // Expected reason passed to the service
+		expCall   bool   // Whether the service is expected to be called
+		err       error  // Error returned by the service
+		expCode   int    // Expected HTTP response code
+	}{
+		"success_without_body": {
+			expCall: true,
+			expCode: http.StatusOK,
+		},
+		"success_anonymize_with_reason": {
+			query:     "?mode=anonymize",
+			body:      `{"reason":"requested by user"}`,
+			expMode:   "anonymize",
+			expReason: "requested by user",
+			expCall:   true,
+			expCode:   http.StatusOK,
+		},
+		"invalid_body": {
+			body:    `{"reason":`,
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_mode": {
+			query:   "?mode=shred",
+			expMode: "shred",
+			expCall: true,
+			err:     errors.New(response.ErrMsgInvalidErasureMode),
+			expCode: http.StatusBadRequest,
+		},
+		"user_not_found": {
+			expCall: true,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockUserService)
+			if tc.expCall {
+				if tc.err != nil {
+					mockService.On("EraseUser", mock.Anything, "andy@example.com", tc.expMode, tc.expReason).Return(nil, tc.err).Once()
+				} else {
+					mockService.On("EraseUser", mock.Anything, "andy@example.com", tc.expMode, tc.expReason).
+						Return(&repository.Erasure{ID: 1, Mode: "delete"}, nil).Once()
+				}
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/users/{email}", EraseUserHandler(mockService))
+			req := httptest.NewRequest(http.MethodDelete, "/users/andy@example.com"+tc.query, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestSetUserStatusHandler tests the SetUserStatusHandler function.
+func TestSetUserStatusHandler(t *testing.T) {
+	tcs := map[string]struct {
+		body    string // Request body
+		expCall bool   // Whether the service is expected to be called
+		err     error  // Error returned by the service
+		expCode int    // Expected HTTP response code
+	}{
+		"success": {
+			body:    `{"status":"deactivated"}`,
+			expCall: true,
+			expCode: http.StatusOK,
+		},
+		"missing_status": {
+			body:    `{}`,
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_body": {
+			body:    `{"status":`,
+			expCode: http.StatusBadRequest,
+		},
+		"invalid_status": {
+			body:    `{"status":"deactivated"}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgInvalidUserStatus),
+			expCode: http.StatusBadRequest,
+		},
+		"user_not_found": {
+			body:    `{"status":"deactivated"}`,
+			expCall: true,
+			err:     errors.New(response.ErrMsgUserNotFound),
+			expCode: http.StatusNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockUserService)
+			if tc.expCall {
+				if tc.err != nil {
+					mockService.On("SetUserStatus", mock.Anything, "andy@example.com", "deactivated").Return(nil, tc.err).Once()
+				} else {
+					mockService.On("SetUserStatus", mock.Anything, "andy@example.com", "deactivated").
+						Return(&models.User{ID: 1, Email: "andy@example.com", Status: "deactivated"}, nil).Once()
+				}
+			}
+
+			r := chi.NewRouter()
+			r.Put("/admin/users/{email}/status", SetUserStatusHandler(mockService))
+			req := httptest.NewRequest(http.MethodPut, "/admin/users/andy@example.com/status", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+)
+
+// Paging bounds of the list endpoints.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// RegisterWebhookRequest defines the structure of the request for registering a webhook.
+type RegisterWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types"`
+	Emails     []string `json:"emails" validate:"dive,email"`
+}
+
+// RegisterWebhookHandler creates a new HTTP handler for registering a webhook.
+// The response holds the secret used to sign the payloads, which is not returned again.
+func RegisterWebhookHandler(webhookService service.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RegisterWebhookRequest
+		ctx := r.Context()
+
+		// Decode the JSON data from the request body
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, response.ErrMsgDecodeRequest)
+			return
+		}
+
+		// Validate the request
+		if err := validate.Struct(req); err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		webhook, err := webhookService.RegisterWebhook(ctx, req.URL, req.EventTypes, req.Emails)
+		if err != nil {
+			response.RespondErr(ctx, w, webhookErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"webhook": webhook,
+			"secret":  webhook.Secret,
+		})
+	}
+}
+
+// ListWebhooksHandler creates a new HTTP handler for listing the webhooks.
+func ListWebhooksHandler(webhookService service.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		webhooks, err := webhookService.ListWebhooks(ctx)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"webhooks": webhooks,
+			"count":    len(webhooks),
+		})
+	}
+}
+
+// DeleteWebhookHandler creates a new HTTP handler for deleting a webhook.
+func DeleteWebhookHandler(webhookService service.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusNotFound, response.ErrMsgWebhookNotFound)
+			return
+		}
+
+		if err := webhookService.DeleteWebhook(ctx, id); err != nil {
+			response.RespondErr(ctx, w, webhookErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, nil)
+	}
+}
+
+// WebhookDeliveriesHandler creates a new HTTP handler for querying the delivery log of a webhook.
+// It accepts the status, limit and offset query parameters.
+func WebhookDeliveriesHandler(webhookService service.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusNotFound, response.ErrMsgWebhookNotFound)
+			return
+		}
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		deliveries, err := webhookService.ListDeliveries(ctx, id, r.URL.Query().Get("status"), limit, offset)
+		if err != nil {
+			response.RespondErr(ctx, w, webhookErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"deliveries": deliveries,
+			"count":      len(deliveries),
+		})
+	}
+}
+
+// WebhookDeadLettersHandler creates a new HTTP handler for querying the dead letters of a webhook.
+// It accepts the limit and offset query parameters.
+func WebhookDeadLettersHandler(webhookService service.WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusNotFound, response.ErrMsgWebhookNotFound)
+			return
+		}
+		limit, offset, err := pageParams(r)
+		if err != nil {
+			response.RespondErr(ctx, w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		deadLetters, err := webhookService.ListDeadLetters(ctx, id, limit, offset)
+		if err != nil {
+			response.RespondErr(ctx, w, webhookErrStatus(err), err.Error())
+			return
+		}
+
+		response.RespondSuccess(ctx, w, map[string]interface{}{
+			"dead_letters": deadLetters,
+			"count":        len(deadLetters),
+		})
+	}
+}
+
+// webhookErrStatus maps a webhook service error to the HTTP status code of the response.
+func webhookErrStatus(err error) int {
+	msg := errors.Cause(err).Error()
+	switch {
+	case msg == response.ErrMsgWebhookNotFound:
+		return http.StatusNotFound
+	case msg == response.ErrMsgInvalidWebhookURL, msg == response.ErrMsgForbiddenWebhookURL,
+		strings.HasPrefix(msg, response.ErrMsgInvalidEventType),
+		strings.HasPrefix(msg, response.ErrMsgGetWebhookDeliveries+": unknown status"):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pageParams parses the limit and offset query parameters.
+func pageParams(r *http.Request) (limit, offset int, err error) {
+	limit, offset = defaultPageLimit, 0
+
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, errors.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must not be negative")
+		}
+	}
+	return limit, offset, nil
+}
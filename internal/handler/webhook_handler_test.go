@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRegisterWebhookHandler tests the RegisterWebhookHandler function.
+func TestRegisterWebhookHandler(t *testing.T) {
+	tcs := map[string]struct {
+		body     map[string]interface{} // Request body
+		expCall  bool                   // Whether the service method is expected to be called
+		err      error                  // Error returned by the service method
+		expCode  int                    // Expected HTTP response code
+		expError string                 // Expected error message
+	}{
+		"success": {
+			body:    map[string]interface{}{"url": "https://example.com/hooks", "event_types": []string{"Blocked"}},
+			expCall: true,
+			expCode: http.StatusOK,
+		},
+		"invalid_email_filter": {
+			body:    map[string]interface{}{"url": "https://example.com/hooks", "emails": []string{"not-an-email"}},
+			expCode: http.StatusBadRequest,
+		},
+		"unknown_event_type": {
+			body:     map[string]interface{}{"url": "https://example.com/hooks", "event_types": []string{"Unfriended"}},
+			expCall:  true,
+			err:      errors.New(response.ErrMsgInvalidEventType + ": Unfriended"),
+			expCode:  http.StatusBadRequest,
+			expError: response.ErrMsgInvalidEventType,
+		},
+		"create_error": {
+			body:     map[string]interface{}{"url": "https://example.com/hooks"},
+			expCall:  true,
+			err:      errors.New(response.ErrMsgCreateWebhook),
+			expCode:  http.StatusInternalServerError,
+			expError: response.ErrMsgCreateWebhook,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockWebhookService := new(service.MockWebhookService)
+			if tc.expCall {
+				var webhook *repository.Webhook
+				if tc.err == nil {
+					webhook = &repository.Webhook{ID: 1, URL: "https://example.com/hooks", Secret: "s3cret"}
+				}
+				mockWebhookService.On("RegisterWebhook", mock.Anything, "https://example.com/hooks", mock.Anything, mock.Anything).
+					Return(webhook, tc.err).Once()
+			}
+
+			body, _ := json.Marshal(tc.body)
+			req, err := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(body))
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// When
+			RegisterWebhookHandler(mockWebhookService).ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			if tc.expError != "" {
+				require.Contains(t, rr.Body.String(), tc.expError)
+			}
+			if tc.expCode == http.StatusOK {
+				require.Contains(t, rr.Body.String(), `"secret":"s3cret"`)
+			}
+			mockWebhookService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestWebhookDeliveriesHandler tests the WebhookDeliveriesHandler function.
+func TestWebhookDeliveriesHandler(t *testing.T) {
+	tcs := map[string]struct {
+		path     string // Request path
+		expCall  bool   // Whether the service method is expected to be called
+		status   string // Expected status filter
+		limit    int    // Expected limit
+		offset   int    // Expected offset
+		err      error  // Error returned by the service method
+		expCode  int    // Expected HTTP response code
+		expError string // Expected error message
+	}{
+		"success": {
+			path:    "/webhooks/3/deliveries?status=dead&limit=10&offset=20",
+			expCall: true,
+			status:  "dead",
+			limit:   10,
+			offset:  20,
+			expCode: http.StatusOK,
+		},
+		"default_paging": {
+			path:    "/webhooks/3/deliveries",
+			expCall: true,
+			limit:   defaultPageLimit,
+			expCode: http.StatusOK,
+		},
+		"invalid_limit": {
+			path:    "/webhooks/3/deliveries?limit=0",
+			expCode: http.StatusBadRequest,
+		},
+		"webhook_not_found": {
+			path:     "/webhooks/3/deliveries",
+			expCall:  true,
+			limit:    defaultPageLimit,
+			err:      errors.New(response.ErrMsgWebhookNotFound),
+			expCode:  http.StatusNotFound,
+			expError: response.ErrMsgWebhookNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockWebhookService := new(service.MockWebhookService)
+			if tc.expCall {
+				mockWebhookService.On("ListDeliveries", mock.Anything, 3, tc.status, tc.limit, tc.offset).
+					Return([]repository.WebhookDelivery{{ID: 1, WebhookID: 3}}, tc.err).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/webhooks/{id}/deliveries", WebhookDeliveriesHandler(mockWebhookService))
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, tc.expCode, rr.Code)
+			if tc.expError != "" {
+				require.Contains(t, rr.Body.String(), tc.expError)
+			}
+			mockWebhookService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Package mention finds the users mentioned in the text of an update.
+//
+// Two forms are recognized: email addresses, written bare or prefixed with "@"
+// ("john@example.com", "@john@example.com"), and handles ("@john"). Text inside
+// backtick code spans and URLs is ignored, and a mention preceded by a backslash
+// ("\@john") is escaped. Surrounding quotes and punctuation are not part of a mention.
+package mention
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of mentions.
+const (
+	KindEmail    = "email"
+	KindUsername = "username"
+)
+
+// maxUsernameLength is the maximum length of a handle.
+const maxUsernameLength = 30
+
+var (
+	// emailPattern matches an email address optionally prefixed with "@". Domain labels
+	// may not start or end with a hyphen and the top-level domain is letters only.
+	emailPattern = regexp.MustCompile(`@?[A-Za-z0-9._%+-]+@(?:[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,}`)
+
+	// usernamePattern matches a handle prefixed with "@". Dots are allowed inside a handle only.
+	usernamePattern = regexp.MustCompile(`@[A-Za-z0-9_](?:[A-Za-z0-9_.]*[A-Za-z0-9_])?`)
+
+	// urlPattern matches URLs, inside which addresses are not mentions.
+	urlPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.)[^\s<>"]+`)
+
+	// codePattern matches backtick code spans.
+	codePattern = regexp.MustCompile("`[^`]*`")
+)
+
+// Mention is a user mentioned in a text.
+type Mention struct {
+	// Kind is KindEmail or KindUsername.
+	Kind string `json:"kind"`
+	// Value is the lower-cased email address or handle, without the "@" prefix of a handle.
+	Value string `json:"value"`
+	// Text is the mention as written.
+	Text string `json:"text"`
+	// Start and End delimit the mention in the text, counted in characters (runes), End excluded.
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// span is a range of byte offsets, end excluded.
+type span struct{ start, end int }
+
+// Parse returns the mentions found in text, in order of appearance. A user mentioned
+// several times yields several mentions; use Emails or Usernames to de-duplicate.
+func Parse(text string) []Mention {
+	ignored := append(find(urlPattern, text), find(codePattern, text)...)
+
+	var mentions []Mention
+	var emailSpans []span
+	for _, s := range find(emailPattern, text) {
+		if overlaps(s, ignored) || !boundedBy(text, s, isLocalPartByte, isDomainByte) {
+			continue
+		}
+		emailSpans = append(emailSpans, s)
+
+		raw := text[s.start:s.end]
+		mentions = append(mentions, newMention(text, s, KindEmail, strings.ToLower(strings.TrimPrefix(raw, "@"))))
+	}
+
+	for _, s := range find(usernamePattern, text) {
+		raw := text[s.start:s.end]
+		if overlaps(s, ignored) || overlaps(s, emailSpans) || len(raw)-1 > maxUsernameLength ||
+			!boundedBy(text, s, isWordByte, func(b byte) bool { return isWordByte(b) || b == '@' }) {
+			continue
+		}
+		mentions = append(mentions, newMention(text, s, KindUsername, strings.ToLower(raw[1:])))
+	}
+
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].Start < mentions[j].Start })
+	return mentions
+}
+
+// Emails returns the distinct email addresses mentioned, in order of first appearance.
+func Emails(mentions []Mention) []string {
+	return values(mentions, KindEmail)
+}
+
+// Usernames returns the distinct handles mentioned, in order of first appearance.
+func Usernames(mentions []Mention) []string {
+	return values(mentions, KindUsername)
+}
+
+// values returns the distinct values of the mentions of the given kind.
+func values(mentions []Mention, kind string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range mentions {
+		if m.Kind == kind && !seen[m.Value] {
+			seen[m.Value] = true
+			out = append(out, m.Value)
+		}
+	}
+	return out
+}
+
+// newMention creates the mention found at the byte span s of text.
+func newMention(text string, s span, kind, value string) Mention {
+	start := utf8.RuneCountInString(text[:s.start])
+	return Mention{
+		Kind:  kind,
+		Value: value,
+		Text:  text[s.start:s.end],
+		Start: start,
+		End:   start + utf8.RuneCountInString(text[s.start:s.end]),
+	}
+}
+
+// find returns the spans matched by re in text.
+func find(re *regexp.Regexp, text string) []span {
+	var spans []span
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		spans = append(spans, span{loc[0], loc[1]})
+	}
+	return spans
+}
+
+// overlaps reports whether s overlaps any of the spans.
+func overlaps(s span, spans []span) bool {
+	for _, o := range spans {
+		if s.start < o.end && o.start < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// boundedBy reports whether the match at s is a whole token: it is not escaped with a
+// backslash nor glued to the characters around it.
+func boundedBy(text string, s span, continuesBefore, continuesAfter func(byte) bool) bool {
+	if s.start > 0 {
+		prev := text[s.start-1]
+		if prev == '\\' || continuesBefore(prev) {
+			return false
+		}
+	}
+	if s.end < len(text) && continuesAfter(text[s.end]) {
+		return false
+	}
+	return true
+}
+
+// isLocalPartByte reports whether b may appear in the local part of an email address.
+func isLocalPartByte(b byte) bool {
+	return isWordByte(b) || strings.IndexByte(".%+-@", b) >= 0
+}
+
+// isDomainByte reports whether b may continue the domain of an email address.
+func isDomainByte(b byte) bool {
+	return isWordByte(b) || b == '-' || b == '@'
+}
+
+// isWordByte reports whether b is an ASCII letter, digit or underscore, or a byte of a
+// non-ASCII character. The patterns only match ASCII, so a match glued to an accented or
+// other non-ASCII letter is part of a longer word and not a mention.
+func isWordByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') ||
+		b >= utf8.RuneSelf
+}
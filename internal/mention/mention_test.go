@@ -0,0 +1,103 @@
+package mention
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestParse tests the Parse function.
+func TestParse(t *testing.T) {
+	tcs := map[string]struct {
+		text        string    // Update text
+		expMentions []Mention // Expected mentions
+	}{
+		"no_mentions": {
+			text: "Hello World!",
+		},
+		"bare_email": {
+			text:        "Hello kate@example.com",
+			expMentions: []Mention{{Kind: KindEmail, Value: "kate@example.com", Text: "kate@example.com", Start: 6, End: 22}},
+		},
+		"prefixed_email": {
+			text:        "Hi @Kate@Example.com!",
+			expMentions: []Mention{{Kind: KindEmail, Value: "kate@example.com", Text: "@Kate@Example.com", Start: 3, End: 20}},
+		},
+		"username": {
+			text:        "thanks @john.doe.",
+			expMentions: []Mention{{Kind: KindUsername, Value: "john.doe", Text: "@john.doe", Start: 7, End: 16}},
+		},
+		"quoted_and_punctuated": {
+			text: `"kate@example.com", (@john)`,
+			expMentions: []Mention{
+				{Kind: KindEmail, Value: "kate@example.com", Text: "kate@example.com", Start: 1, End: 17},
+				{Kind: KindUsername, Value: "john", Text: "@john", Start: 21, End: 26},
+			},
+		},
+		"escaped": {
+			text: `not a mention: \@john \kate@example.com`,
+		},
+		"code_span": {
+			text: "run `git log --author=kate@example.com` @john",
+			expMentions: []Mention{
+				{Kind: KindUsername, Value: "john", Text: "@john", Start: 40, End: 45},
+			},
+		},
+		"email_in_url": {
+			text: "see https://example.com/?ref=kate@example.com and www.example.com/@john",
+		},
+		"pipe_in_tld": {
+			text: "kate@example.c|m",
+		},
+		"unicode_offsets": {
+			text:        "héllo @john",
+			expMentions: []Mention{{Kind: KindUsername, Value: "john", Text: "@john", Start: 6, End: 11}},
+		},
+		"accented_local_part": {
+			text: "héllo@example.com hi",
+		},
+		"non_ascii_local_part": {
+			text: "ünïcode@x.io and 日本@example.com",
+		},
+		"accented_word_before_handle": {
+			text: "café@example.com",
+		},
+		"accented_word_after_email": {
+			text: "kate@example.comé @johné",
+		},
+		"non_ascii_text_around_mentions": {
+			text: "Привет @john, ça va kate@example.com ?",
+			expMentions: []Mention{
+				{Kind: KindUsername, Value: "john", Text: "@john", Start: 7, End: 12},
+				{Kind: KindEmail, Value: "kate@example.com", Text: "kate@example.com", Start: 20, End: 36},
+			},
+		},
+		"glued_to_word": {
+			text: "foo@bar mail@john abc@",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// When
+			mentions := Parse(tc.text)
+
+			// Then
+			require.Equal(t, tc.expMentions, mentions)
+		})
+	}
+}
+
+// TestEmails tests that mentioned emails are de-duplicated and case-normalized.
+func TestEmails(t *testing.T) {
+	// Given
+	mentions := Parse("Kate@Example.com, kate@example.com and @john@example.com, @kate @KATE")
+
+	// When
+	emails := Emails(mentions)
+	usernames := Usernames(mentions)
+
+	// Then
+	require.Equal(t, []string{"kate@example.com", "john@example.com"}, emails)
+	require.Equal(t, []string{"kate"}, usernames)
+}
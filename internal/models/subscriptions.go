@@ -27,6 +27,7 @@ type Subscription struct {
 	Requestor string    `boil:"requestor" json:"requestor" toml:"requestor" yaml:"requestor"`
 	Target    string    `boil:"target" json:"target" toml:"target" yaml:"target"`
 	CreatedAt time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	Status    string    `boil:"status" json:"status" toml:"status" yaml:"status"`
 
 	R *subscriptionR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L subscriptionL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -37,11 +38,13 @@ var SubscriptionColumns = struct {
 	Requestor string
 	Target    string
 	CreatedAt string
+	Status    string
 }{
 	ID:        "id",
 	Requestor: "requestor",
 	Target:    "target",
 	CreatedAt: "created_at",
+	Status:    "status",
 }
 
 var SubscriptionTableColumns = struct {
@@ -49,11 +52,13 @@ var SubscriptionTableColumns = struct {
 	Requestor string
 	Target    string
 	CreatedAt string
+	Status    string
 }{
 	ID:        "subscriptions.id",
 	Requestor: "subscriptions.requestor",
 	Target:    "subscriptions.target",
 	CreatedAt: "subscriptions.created_at",
+	Status:    "subscriptions.status",
 }
 
 // Generated where
@@ -90,11 +95,13 @@ var SubscriptionWhere = struct {
 	Requestor whereHelperstring
 	Target    whereHelperstring
 	CreatedAt whereHelpertime_Time
+	Status    whereHelperstring
 }{
 	ID:        whereHelperint{field: "\"subscriptions\".\"id\""},
 	Requestor: whereHelperstring{field: "\"subscriptions\".\"requestor\""},
 	Target:    whereHelperstring{field: "\"subscriptions\".\"target\""},
 	CreatedAt: whereHelpertime_Time{field: "\"subscriptions\".\"created_at\""},
+	Status:    whereHelperstring{field: "\"subscriptions\".\"status\""},
 }
 
 // SubscriptionRels is where relationship names are stored.
@@ -135,9 +142,9 @@ func (r *subscriptionR) GetTargetUser() *User {
 type subscriptionL struct{}
 
 var (
-	subscriptionAllColumns            = []string{"id", "requestor", "target", "created_at"}
+	subscriptionAllColumns            = []string{"id", "requestor", "target", "created_at", "status"}
 	subscriptionColumnsWithoutDefault = []string{"requestor", "target"}
-	subscriptionColumnsWithDefault    = []string{"id", "created_at"}
+	subscriptionColumnsWithDefault    = []string{"id", "created_at", "status"}
 	subscriptionPrimaryKeyColumns     = []string{"id"}
 	subscriptionGeneratedColumns      = []string{}
 )
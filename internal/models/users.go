@@ -23,58 +23,88 @@ import (
 
 // User is an object representing the database table.
 type User struct {
-	ID        int       `boil:"id" json:"id" toml:"id" yaml:"id"`
-	Name      string    `boil:"name" json:"name" toml:"name" yaml:"name"`
-	Email     string    `boil:"email" json:"email" toml:"email" yaml:"email"`
-	CreatedAt time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
-	UpdatedAt time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+	ID              int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Name            string    `boil:"name" json:"name" toml:"name" yaml:"name"`
+	Email           string    `boil:"email" json:"email" toml:"email" yaml:"email"`
+	CreatedAt       time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	UpdatedAt       time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+	Status          string    `boil:"status" json:"status" toml:"status" yaml:"status"`
+	StatusChangedAt time.Time `boil:"status_changed_at" json:"status_changed_at" toml:"status_changed_at" yaml:"status_changed_at"`
+	Private         bool      `boil:"private" json:"private" toml:"private" yaml:"private"`
 
 	R *userR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L userL  `boil:"-" json:"-" toml:"-" yaml:"-"`
 }
 
 var UserColumns = struct {
-	ID        string
-	Name      string
-	Email     string
-	CreatedAt string
-	UpdatedAt string
+	ID              string
+	Name            string
+	Email           string
+	CreatedAt       string
+	UpdatedAt       string
+	Status          string
+	StatusChangedAt string
+	Private         string
 }{
-	ID:        "id",
-	Name:      "name",
-	Email:     "email",
-	CreatedAt: "created_at",
-	UpdatedAt: "updated_at",
+	ID:              "id",
+	Name:            "name",
+	Email:           "email",
+	CreatedAt:       "created_at",
+	UpdatedAt:       "updated_at",
+	Status:          "status",
+	StatusChangedAt: "status_changed_at",
+	Private:         "private",
 }
 
 var UserTableColumns = struct {
-	ID        string
-	Name      string
-	Email     string
-	CreatedAt string
-	UpdatedAt string
+	ID              string
+	Name            string
+	Email           string
+	CreatedAt       string
+	UpdatedAt       string
+	Status          string
+	StatusChangedAt string
+	Private         string
 }{
-	ID:        "users.id",
-	Name:      "users.name",
-	Email:     "users.email",
-	CreatedAt: "users.created_at",
-	UpdatedAt: "users.updated_at",
+	ID:              "users.id",
+	Name:            "users.name",
+	Email:           "users.email",
+	CreatedAt:       "users.created_at",
+	UpdatedAt:       "users.updated_at",
+	Status:          "users.status",
+	StatusChangedAt: "users.status_changed_at",
+	Private:         "users.private",
 }
 
 // Generated where
 
+type whereHelperbool struct{ field string }
+
+func (w whereHelperbool) EQ(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperbool) NEQ(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperbool) LT(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperbool) LTE(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperbool) GT(x bool) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperbool) GTE(x bool) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+
 var UserWhere = struct {
-	ID        whereHelperint
-	Name      whereHelperstring
-	Email     whereHelperstring
-	CreatedAt whereHelpertime_Time
-	UpdatedAt whereHelpertime_Time
+	ID              whereHelperint
+	Name            whereHelperstring
+	Email           whereHelperstring
+	CreatedAt       whereHelpertime_Time
+	UpdatedAt       whereHelpertime_Time
+	Status          whereHelperstring
+	StatusChangedAt whereHelpertime_Time
+	Private         whereHelperbool
 }{
-	ID:        whereHelperint{field: "\"users\".\"id\""},
-	Name:      whereHelperstring{field: "\"users\".\"name\""},
-	Email:     whereHelperstring{field: "\"users\".\"email\""},
-	CreatedAt: whereHelpertime_Time{field: "\"users\".\"created_at\""},
-	UpdatedAt: whereHelpertime_Time{field: "\"users\".\"updated_at\""},
+	ID:              whereHelperint{field: "\"users\".\"id\""},
+	Name:            whereHelperstring{field: "\"users\".\"name\""},
+	Email:           whereHelperstring{field: "\"users\".\"email\""},
+	CreatedAt:       whereHelpertime_Time{field: "\"users\".\"created_at\""},
+	UpdatedAt:       whereHelpertime_Time{field: "\"users\".\"updated_at\""},
+	Status:          whereHelperstring{field: "\"users\".\"status\""},
+	StatusChangedAt: whereHelpertime_Time{field: "\"users\".\"status_changed_at\""},
+	Private:         whereHelperbool{field: "\"users\".\"private\""},
 }
 
 // UserRels is where relationship names are stored.
@@ -155,9 +185,9 @@ func (r *userR) GetTargetSubscriptions() SubscriptionSlice {
 type userL struct{}
 
 var (
-	userAllColumns            = []string{"id", "name", "email", "created_at", "updated_at"}
+	userAllColumns            = []string{"id", "name", "email", "created_at", "updated_at", "status", "status_changed_at", "private"}
 	userColumnsWithoutDefault = []string{"name", "email"}
-	userColumnsWithDefault    = []string{"id", "created_at", "updated_at"}
+	userColumnsWithDefault    = []string{"id", "created_at", "updated_at", "status", "status_changed_at", "private"}
 	userPrimaryKeyColumns     = []string{"id"}
 	userGeneratedColumns      = []string{}
 )
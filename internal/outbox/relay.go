@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// Retry backoff bounds for events that failed to publish.
+const (
+	minBackoff = time.Second
+	maxBackoff = 5 * time.Minute
+)
+
+// Relay publishes the events stored in the outbox to the configured sinks.
+// Delivery is at least once: an event is marked as published only after every
+// sink accepted it, so a sink may see the same event more than once.
+type Relay struct {
+	repo         repository.OutboxRepository
+	sinks        []events.Sink
+	pollInterval time.Duration
+	batchSize    int
+	sinkTimeout  time.Duration
+	now          func() time.Time
+}
+
+// NewRelay creates a new Relay.
+func NewRelay(repo repository.OutboxRepository, sinks []events.Sink, cfg config.Outbox) *Relay {
+	return &Relay{
+		repo:         repo,
+		sinks:        sinks,
+		pollInterval: cfg.PollInterval,
+		batchSize:    cfg.BatchSize,
+		sinkTimeout:  cfg.SinkTimeout,
+		now:          time.Now,
+	}
+}
+
+// NewSinks creates the sinks listed in the configuration.
+func NewSinks(cfg config.Outbox) ([]events.Sink, error) {
+	sinks := make([]events.Sink, 0, len(cfg.Sinks))
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "stdout":
+			sinks = append(sinks, events.NewStdoutSink())
+		case "file":
+			sink, err := events.NewFileSink(cfg.FilePath)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "webhook":
+			sinks = append(sinks, events.NewWebhookSink(cfg.WebhookURL, cfg.SinkTimeout))
+		case "nats":
+			sinks = append(sinks, events.NewNATSSink(cfg.NATSURL, cfg.NATSSubjectPrefix, cfg.SinkTimeout))
+		default:
+			return nil, errors.Errorf("unknown event sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// Run relays events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Int("sinks", len(r.sinks)).Msg("Starting outbox relay")
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick.
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to relay outbox events")
+				break
+			}
+			if n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping outbox relay")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce claims a batch of due events and publishes them, returning how many were claimed.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	// Events stay claimed for as long as publishing the whole batch may take.
+	lease := time.Duration(r.batchSize*len(r.sinks)+1) * r.sinkTimeout
+
+	pending, err := r.repo.ClaimPendingEvents(ctx, r.batchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, evt := range pending {
+		if err := r.publish(ctx, evt.Event); err != nil {
+			next := r.now().Add(Backoff(evt.Attempts))
+			logger.FromContext(ctx).Warn().Err(err).Int64("event_id", evt.ID).Str("event_type", evt.Type).
+				Int("attempts", evt.Attempts+1).Time("next_attempt_at", next).Msg("Failed to publish event")
+
+			if err := r.repo.MarkEventFailed(ctx, evt.ID, err.Error(), next); err != nil {
+				return len(pending), err
+			}
+			continue
+		}
+
+		if err := r.repo.MarkEventPublished(ctx, evt.ID); err != nil {
+			return len(pending), err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// publish hands the event to every sink, stopping at the first failure.
+func (r *Relay) publish(ctx context.Context, evt events.Event) error {
+	for _, sink := range r.sinks {
+		sctx, cancel := context.WithTimeout(ctx, r.sinkTimeout)
+		err := sink.Publish(sctx, evt)
+		cancel()
+		if err != nil {
+			return errors.Wrapf(err, "sink %s", sink.Name())
+		}
+	}
+	return nil
+}
+
+// Backoff returns the delay before retrying an event that already failed attempts times.
+// It doubles with every attempt from one second up to five minutes.
+func Backoff(attempts int) time.Duration {
+	if attempts > 16 {
+		return maxBackoff
+	}
+	d := minBackoff << attempts
+	if d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSink records the events it receives and fails while err is set.
+type fakeSink struct {
+	err       error
+	published []events.Event
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Publish(ctx context.Context, event events.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.published = append(s.published, event)
+	return nil
+}
+
+// TestRelay_RelayOnce tests the RelayOnce method of the Relay.
+func TestRelay_RelayOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		pending      []repository.PendingEvent // Events returned by ClaimPendingEvents
+		sinkErr      error                     // Error returned by the sink
+		expPublished int                       // Expected number of events handed to the sink
+		expFailedAt  time.Time                 // Expected next attempt of failed events
+	}{
+		"success": {
+			pending: []repository.PendingEvent{
+				{Event: events.Event{ID: 1, Type: events.FriendshipCreated}},
+				{Event: events.Event{ID: 2, Type: events.Blocked}},
+			},
+			expPublished: 2,
+		},
+		"no_pending_events": {
+			pending:      []repository.PendingEvent{},
+			expPublished: 0,
+		},
+		"sink_error_schedules_retry": {
+			pending: []repository.PendingEvent{
+				{Event: events.Event{ID: 1, Type: events.Subscribed}, Attempts: 2},
+			},
+			sinkErr:      errors.New("connection refused"),
+			expPublished: 0,
+			expFailedAt:  now.Add(4 * time.Second),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockOutboxRepo)
+			sink := &fakeSink{err: tc.sinkErr}
+			relay := NewRelay(mockRepo, []events.Sink{sink}, config.Outbox{BatchSize: 10, SinkTimeout: time.Second})
+			relay.now = func() time.Time { return now }
+
+			mockRepo.On("ClaimPendingEvents", mock.Anything, 10, mock.AnythingOfType("time.Duration")).Return(tc.pending, nil).Once()
+			for _, evt := range tc.pending {
+				if tc.sinkErr != nil {
+					mockRepo.On("MarkEventFailed", mock.Anything, evt.ID, mock.AnythingOfType("string"), tc.expFailedAt).Return(nil).Once()
+				} else {
+					mockRepo.On("MarkEventPublished", mock.Anything, evt.ID).Return(nil).Once()
+				}
+			}
+
+			// When
+			n, err := relay.RelayOnce(context.Background())
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, len(tc.pending), n)
+			require.Len(t, sink.published, tc.expPublished)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestBackoff tests the retry backoff of failed events.
+func TestBackoff(t *testing.T) {
+	require.Equal(t, time.Second, Backoff(0))
+	require.Equal(t, 8*time.Second, Backoff(3))
+	require.Equal(t, 5*time.Minute, Backoff(10))
+	require.Equal(t, 5*time.Minute, Backoff(100))
+}
@@ -0,0 +1,421 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Default values used when the matching environment variable is not set.
+const (
+	DefaultPort        = "5000"
+	DefaultDatabaseURL = "postgres://friend-management:1234@db:5432/friend-management?sslmode=disable"
+	DefaultLogLevel    = "info"
+
+	DefaultHealthCheckTimeout = 2 * time.Second
+
+	DefaultOutboxSinks        = "stdout"
+	DefaultOutboxPollInterval = time.Second
+	DefaultOutboxBatchSize    = 100
+	DefaultOutboxSinkTimeout  = 5 * time.Second
+	DefaultOutboxNATSSubject  = "friend-management"
+
+	DefaultWebhookMaxAttempts  = 8
+	DefaultWebhookPollInterval = time.Second
+	DefaultWebhookBatchSize    = 50
+	DefaultWebhookTimeout      = 10 * time.Second
+
+	DefaultUpdatesAsyncThreshold = 100
+	DefaultUpdatesWorkers        = 4
+	DefaultUpdatesQueueSize      = 1024
+	DefaultUpdatesSweepInterval  = 30 * time.Second
+	DefaultUpdatesStaleAfter     = time.Minute
+
+	DefaultStreamBufferSize            = 64
+	DefaultStreamMaxConnectionsPerUser = 5
+	DefaultStreamHeartbeatInterval     = 25 * time.Second
+	DefaultStreamWriteTimeout          = 10 * time.Second
+
+	DefaultAccountsRetentionPeriod = 30 * 24 * time.Hour
+	DefaultAccountsPurgeInterval   = time.Hour
+
+	DefaultGraphMaxPathDepth = 6
+
+	DefaultAnalyticsSnapshotInterval = time.Hour
+	DefaultAnalyticsTopUsers         = 10
+
+	DefaultStatsReconcileInterval = 6 * time.Hour
+
+	DefaultBlocksSweepInterval = time.Minute
+
+	DefaultAuthTokenTTL = 24 * time.Hour
+
+	// MinSecretLength is the shortest secret accepted to sign tokens or key hashes, in bytes.
+	MinSecretLength = 32
+)
+
+// Supported tracing exporters.
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// Config holds the runtime configuration of the server.
+type Config struct {
+	Port        string
+	DatabaseURL string
+	Log         Log
+	Tracing     Tracing
+	// HealthCheckTimeout bounds each readiness check, e.g. the database ping.
+	HealthCheckTimeout time.Duration
+	Outbox             Outbox
+	Webhooks           Webhooks
+	Updates            Updates
+	Stream             Stream
+	Accounts           Accounts
+	Graph              Graph
+	Analytics          Analytics
+	Stats              Stats
+	Blocks             Blocks
+	Auth               Auth
+}
+
+// Log holds the logging configuration.
+type Log struct {
+	// Level is the minimum level that is written, e.g. "debug", "info", "warn", "error".
+	Level string
+	// JSON switches the output from the human readable console format to JSON lines.
+	JSON bool
+	// RedactEmails masks the local part of email addresses written by the application.
+	RedactEmails bool
+}
+
+// Tracing holds the OpenTelemetry tracing configuration.
+type Tracing struct {
+	// Exporter is one of "none", "stdout" or "otlp". The OTLP exporter is configured
+	// through the standard OTEL_EXPORTER_OTLP_* environment variables.
+	Exporter string
+	// SampleRatio is the fraction of new traces that are sampled, between 0 and 1.
+	SampleRatio float64
+}
+
+// Outbox holds the configuration of the relay publishing the domain events.
+type Outbox struct {
+	// Sinks lists the sinks events are published to: any of "stdout", "file", "webhook" and "nats".
+	// Events are also always delivered to the registered webhooks, whatever the list.
+	Sinks []string
+	// PollInterval is how often the relay looks for pending events.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of events claimed per poll.
+	BatchSize int
+	// SinkTimeout bounds a single publish to a sink.
+	SinkTimeout time.Duration
+
+	FilePath          string
+	WebhookURL        string
+	NATSURL           string
+	NATSSubjectPrefix string
+}
+
+// Webhooks holds the configuration of the worker delivering events to the registered webhooks.
+type Webhooks struct {
+	// MaxAttempts is the number of attempts after which a delivery is dead-lettered.
+	MaxAttempts int
+	// PollInterval is how often the worker looks for due deliveries.
+	PollInterval time.Duration
+	// BatchSize is the maximum number of deliveries claimed per poll.
+	BatchSize int
+	// Timeout bounds a single request to a webhook endpoint.
+	Timeout time.Duration
+	// AllowPrivateNetworks lets webhooks target loopback, private and reserved addresses,
+	// e.g. for local development. It must stay disabled in production.
+	AllowPrivateNetworks bool
+}
+
+// Updates holds the configuration of the fan-out of updates to the inboxes.
+type Updates struct {
+	// AsyncThreshold is the number of friends and subscribers of a sender above which
+	// the fan-out runs in the background instead of within the request.
+	AsyncThreshold int
+	// Workers is the number of background fan-out workers.
+	Workers int
+	// QueueSize is the capacity of the queue feeding the workers.
+	QueueSize int
+	// SweepInterval is how often updates whose fan-out did not complete are looked for.
+	SweepInterval time.Duration
+	// StaleAfter is how old a pending update must be before the sweeper retries it.
+	StaleAfter time.Duration
+}
+
+// Stream holds the configuration of the real-time update streams.
+type Stream struct {
+	// BufferSize is the number of updates buffered per stream before it is dropped as too slow.
+	BufferSize int
+	// MaxConnectionsPerUser limits the streams a user may open at once. 0 means no limit.
+	MaxConnectionsPerUser int
+	// HeartbeatInterval is how often idle streams are pinged to keep them open.
+	HeartbeatInterval time.Duration
+	// WriteTimeout bounds a single write to a stream.
+	WriteTimeout time.Duration
+}
+
+// Accounts holds the configuration of the account lifecycle.
+type Accounts struct {
+	// RetentionPeriod is how long deleted accounts are kept, and can be restored, before being purged.
+	RetentionPeriod time.Duration
+	// PurgeInterval is how often the accounts deleted beyond the retention period are purged.
+	PurgeInterval time.Duration
+	// ErasureKey keys the hash of the emails kept in the records of the erased users, so they
+	// cannot be matched against a list of known emails without it.
+	ErasureKey string
+}
+
+// Graph holds the configuration of the friendship graph queries.
+type Graph struct {
+	// MaxPathDepth is the largest number of friendships searched between two users.
+	MaxPathDepth int
+}
+
+// Analytics holds the configuration of the statistics on the social graph.
+type Analytics struct {
+	// SnapshotInterval is how often the statistics are computed.
+	SnapshotInterval time.Duration
+	// TopUsers is the number of users listed in the rankings, e.g. the most followed users.
+	TopUsers int
+}
+
+// Stats holds the configuration of the counters of the relations of the users.
+type Stats struct {
+	// ReconcileInterval is how often the counters are recounted to repair drift.
+	ReconcileInterval time.Duration
+}
+
+// Blocks holds the configuration of the blocks between users.
+type Blocks struct {
+	// SweepInterval is how often the blocks whose expiry passed are deleted.
+	SweepInterval time.Duration
+}
+
+// Auth holds the configuration of the authentication of the API requests.
+type Auth struct {
+	// Secret signs and verifies the bearer tokens of the requests.
+	Secret string
+	// TokenTTL is how long the tokens issued by friendctl are valid.
+	TokenTTL time.Duration
+}
+
+// Load reads the configuration from the environment, falling back to defaults.
+func Load() (Config, error) {
+	cfg := Config{
+		Port:        getEnv("PORT", DefaultPort),
+		DatabaseURL: getEnv("DATABASE_URL", DefaultDatabaseURL),
+		Log: Log{
+			Level:        strings.ToLower(getEnv("LOG_LEVEL", DefaultLogLevel)),
+			JSON:         getEnvBool("LOG_JSON", false),
+			RedactEmails: getEnvBool("LOG_REDACT_EMAILS", false),
+		},
+		Tracing: Tracing{
+			Exporter:    strings.ToLower(getEnv("TRACING_EXPORTER", TracingExporterNone)),
+			SampleRatio: getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		HealthCheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", DefaultHealthCheckTimeout),
+		Outbox: Outbox{
+			Sinks:             getEnvList("OUTBOX_SINKS", DefaultOutboxSinks),
+			PollInterval:      getEnvDuration("OUTBOX_POLL_INTERVAL", DefaultOutboxPollInterval),
+			BatchSize:         getEnvInt("OUTBOX_BATCH_SIZE", DefaultOutboxBatchSize),
+			SinkTimeout:       getEnvDuration("OUTBOX_SINK_TIMEOUT", DefaultOutboxSinkTimeout),
+			FilePath:          getEnv("OUTBOX_FILE_PATH", "events.jsonl"),
+			WebhookURL:        os.Getenv("OUTBOX_WEBHOOK_URL"),
+			NATSURL:           getEnv("OUTBOX_NATS_URL", "nats://localhost:4222"),
+			NATSSubjectPrefix: getEnv("OUTBOX_NATS_SUBJECT_PREFIX", DefaultOutboxNATSSubject),
+		},
+		Webhooks: Webhooks{
+			MaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", DefaultWebhookMaxAttempts),
+			PollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", DefaultWebhookPollInterval),
+			BatchSize:    getEnvInt("WEBHOOK_BATCH_SIZE", DefaultWebhookBatchSize),
+			Timeout:      getEnvDuration("WEBHOOK_TIMEOUT", DefaultWebhookTimeout),
+
+			AllowPrivateNetworks: getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		},
+		Updates: Updates{
+			AsyncThreshold: getEnvInt("UPDATES_ASYNC_THRESHOLD", DefaultUpdatesAsyncThreshold),
+			Workers:        getEnvInt("UPDATES_WORKERS", DefaultUpdatesWorkers),
+			QueueSize:      getEnvInt("UPDATES_QUEUE_SIZE", DefaultUpdatesQueueSize),
+			SweepInterval:  getEnvDuration("UPDATES_SWEEP_INTERVAL", DefaultUpdatesSweepInterval),
+			StaleAfter:     getEnvDuration("UPDATES_STALE_AFTER", DefaultUpdatesStaleAfter),
+		},
+		Stream: Stream{
+			BufferSize:            getEnvInt("STREAM_BUFFER_SIZE", DefaultStreamBufferSize),
+			MaxConnectionsPerUser: getEnvInt("STREAM_MAX_CONNECTIONS_PER_USER", DefaultStreamMaxConnectionsPerUser),
+			HeartbeatInterval:     getEnvDuration("STREAM_HEARTBEAT_INTERVAL", DefaultStreamHeartbeatInterval),
+			WriteTimeout:          getEnvDuration("STREAM_WRITE_TIMEOUT", DefaultStreamWriteTimeout),
+		},
+		Accounts: Accounts{
+			RetentionPeriod: getEnvDuration("ACCOUNTS_RETENTION_PERIOD", DefaultAccountsRetentionPeriod),
+			PurgeInterval:   getEnvDuration("ACCOUNTS_PURGE_INTERVAL", DefaultAccountsPurgeInterval),
+			ErasureKey:      os.Getenv("ACCOUNTS_ERASURE_KEY"),
+		},
+		Graph: Graph{
+			MaxPathDepth: getEnvInt("GRAPH_MAX_PATH_DEPTH", DefaultGraphMaxPathDepth),
+		},
+		Analytics: Analytics{
+			SnapshotInterval: getEnvDuration("ANALYTICS_SNAPSHOT_INTERVAL", DefaultAnalyticsSnapshotInterval),
+			TopUsers:         getEnvInt("ANALYTICS_TOP_USERS", DefaultAnalyticsTopUsers),
+		},
+		Stats: Stats{
+			ReconcileInterval: getEnvDuration("STATS_RECONCILE_INTERVAL", DefaultStatsReconcileInterval),
+		},
+		Blocks: Blocks{
+			SweepInterval: getEnvDuration("BLOCKS_SWEEP_INTERVAL", DefaultBlocksSweepInterval),
+		},
+		Auth: Auth{
+			Secret:   os.Getenv("AUTH_SECRET"),
+			TokenTTL: getEnvDuration("AUTH_TOKEN_TTL", DefaultAuthTokenTTL),
+		},
+	}
+
+	if _, err := zerolog.ParseLevel(cfg.Log.Level); err != nil {
+		return Config{}, errors.Wrap(err, "invalid LOG_LEVEL")
+	}
+
+	switch cfg.Tracing.Exporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		return Config{}, errors.Errorf("invalid TRACING_EXPORTER %q", cfg.Tracing.Exporter)
+	}
+
+	for _, sink := range cfg.Outbox.Sinks {
+		switch sink {
+		case "stdout", "file", "nats":
+		case "webhook":
+			if cfg.Outbox.WebhookURL == "" {
+				return Config{}, errors.New("OUTBOX_WEBHOOK_URL is required by the webhook sink")
+			}
+		default:
+			return Config{}, errors.Errorf("invalid OUTBOX_SINKS entry %q", sink)
+		}
+	}
+
+	if cfg.Outbox.PollInterval <= 0 {
+		return Config{}, errors.New("OUTBOX_POLL_INTERVAL must be positive")
+	}
+	if cfg.Outbox.BatchSize < 1 {
+		return Config{}, errors.New("OUTBOX_BATCH_SIZE must be at least 1")
+	}
+	if cfg.Webhooks.MaxAttempts < 1 {
+		return Config{}, errors.New("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.Webhooks.PollInterval <= 0 {
+		return Config{}, errors.New("WEBHOOK_POLL_INTERVAL must be positive")
+	}
+	if cfg.Webhooks.BatchSize < 1 {
+		return Config{}, errors.New("WEBHOOK_BATCH_SIZE must be at least 1")
+	}
+	if cfg.Updates.Workers < 1 {
+		return Config{}, errors.New("UPDATES_WORKERS must be at least 1")
+	}
+	if cfg.Updates.QueueSize < 0 {
+		return Config{}, errors.New("UPDATES_QUEUE_SIZE must not be negative")
+	}
+	if cfg.Updates.SweepInterval <= 0 {
+		return Config{}, errors.New("UPDATES_SWEEP_INTERVAL must be positive")
+	}
+	if cfg.Stream.HeartbeatInterval <= 0 {
+		return Config{}, errors.New("STREAM_HEARTBEAT_INTERVAL must be positive")
+	}
+	if cfg.Accounts.PurgeInterval <= 0 {
+		return Config{}, errors.New("ACCOUNTS_PURGE_INTERVAL must be positive")
+	}
+	if len(cfg.Accounts.ErasureKey) < MinSecretLength {
+		return Config{}, errors.Errorf("ACCOUNTS_ERASURE_KEY must be at least %d bytes long", MinSecretLength)
+	}
+	if cfg.Graph.MaxPathDepth < 1 {
+		return Config{}, errors.New("GRAPH_MAX_PATH_DEPTH must be at least 1")
+	}
+	if cfg.Analytics.SnapshotInterval <= 0 {
+		return Config{}, errors.New("ANALYTICS_SNAPSHOT_INTERVAL must be positive")
+	}
+	if cfg.Analytics.TopUsers < 1 {
+		return Config{}, errors.New("ANALYTICS_TOP_USERS must be at least 1")
+	}
+	if cfg.Stats.ReconcileInterval <= 0 {
+		return Config{}, errors.New("STATS_RECONCILE_INTERVAL must be positive")
+	}
+	if cfg.Blocks.SweepInterval <= 0 {
+		return Config{}, errors.New("BLOCKS_SWEEP_INTERVAL must be positive")
+	}
+	if len(cfg.Auth.Secret) < MinSecretLength {
+		return Config{}, errors.Errorf("AUTH_SECRET must be at least %d bytes long", MinSecretLength)
+	}
+	if cfg.Auth.TokenTTL <= 0 {
+		return Config{}, errors.New("AUTH_TOKEN_TTL must be positive")
+	}
+
+	return cfg, nil
+}
+
+// getEnv returns the value of the environment variable or def when it is unset or empty.
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvBool parses the environment variable as a boolean, returning def when it is unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvFloat parses the environment variable as a float, returning def when it is unset or invalid.
+func getEnvFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvDuration parses the environment variable as a duration, returning def when it is unset or invalid.
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvInt parses the environment variable as an int, returning def when it is unset or invalid.
+func getEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// getEnvList splits the comma separated environment variable, using def when it is unset.
+// Setting the variable to "none" yields an empty list.
+func getEnvList(key, def string) []string {
+	v := strings.ToLower(getEnv(key, def))
+	if v == "none" {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
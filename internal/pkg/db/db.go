@@ -1,13 +1,14 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"os"
 
 	_ "github.com/lib/pq"
 
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
 	"github.com/pkg/errors"
 )
 
@@ -30,7 +31,7 @@ func ConnectDB(dbURL string) (*sql.DB, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	log.Println("Initializing DB connection")
+	logger.FromContext(context.Background()).Info().Msg("Initializing DB connection")
 
 	return conn, nil
 }
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/boldnguyen/friend-management/data/migrations"
+	"github.com/pkg/errors"
+)
+
+// Migrate applies or reverts migrations until the schema is at the target version, 0 meaning
+// every migration is reverted. Each migration runs in its own transaction along with the
+// update of the version recorded in schema_migrations. It returns the versions applied, or
+// reverted, in the order they ran.
+func Migrate(ctx context.Context, conn *sql.DB, all []migrations.Migration, target int) ([]int, error) {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create schema_migrations table")
+	}
+
+	current, dirty, err := SchemaVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, errors.Errorf("schema version %d is dirty, it has to be fixed by hand", current)
+	}
+
+	var ran []int
+	if target >= current {
+		for _, m := range all {
+			if m.Version <= current || m.Version > target {
+				continue
+			}
+			if err := runMigration(ctx, conn, m.Up, m.Version); err != nil {
+				return ran, errors.Wrapf(err, "failed to apply migration %s", m.Name)
+			}
+			ran = append(ran, m.Version)
+		}
+		return ran, nil
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		m := all[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		previous := 0
+		if i > 0 {
+			previous = all[i-1].Version
+		}
+		if err := runMigration(ctx, conn, m.Down, previous); err != nil {
+			return ran, errors.Wrapf(err, "failed to revert migration %s", m.Name)
+		}
+		ran = append(ran, m.Version)
+	}
+	return ran, nil
+}
+
+// runMigration runs the SQL of a migration and records the version it leaves the schema at.
+func runMigration(ctx context.Context, conn *sql.DB, query string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `TRUNCATE schema_migrations`); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
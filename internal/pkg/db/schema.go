@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaVersion returns the migration version recorded in the schema_migrations table
+// and whether the last migration was left half applied. A database without the table
+// is reported as version 0.
+func SchemaVersion(ctx context.Context, conn *sql.DB) (int, bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to check schema_migrations table")
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var (
+		version int
+		dirty   bool
+	)
+	err = conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to read schema version")
+	}
+
+	return version, dirty, nil
+}
@@ -0,0 +1,27 @@
+// Package emailaddr defines the canonical form of the email addresses identifying users.
+package emailaddr
+
+import "strings"
+
+// Normalize returns the canonical form of an email address: trimmed and lowercased.
+// Emails are normalized before they are stored or looked up so that addresses differing
+// only in case identify the same user.
+func Normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeAll returns the canonical forms of the email addresses, dropping duplicates
+// while keeping the order of first occurrence.
+func NormalizeAll(emails []string) []string {
+	normalized := make([]string, 0, len(emails))
+	seen := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		email = Normalize(email)
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		normalized = append(normalized, email)
+	}
+	return normalized
+}
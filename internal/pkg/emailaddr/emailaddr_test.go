@@ -0,0 +1,43 @@
+package emailaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNormalize tests that emails differing only in case or surrounding spaces normalize alike.
+func TestNormalize(t *testing.T) {
+	tcs := map[string]struct {
+		input string
+		exp   string
+	}{
+		"canonical":   {input: "andy@example.com", exp: "andy@example.com"},
+		"mixed_case":  {input: "Andy@Example.COM", exp: "andy@example.com"},
+		"padded":      {input: "  andy@example.com\t", exp: "andy@example.com"},
+		"empty":       {input: "", exp: ""},
+		"padded_case": {input: " ANDY@example.com ", exp: "andy@example.com"},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// When
+			got := Normalize(tc.input)
+
+			// Then
+			require.Equal(t, tc.exp, got)
+		})
+	}
+}
+
+// TestNormalizeAll tests that NormalizeAll drops the emails identifying the same user.
+func TestNormalizeAll(t *testing.T) {
+	// Given
+	emails := []string{"Andy@Example.com", "kate@example.com", "andy@example.com ", "KATE@example.com"}
+
+	// When
+	got := NormalizeAll(emails)
+
+	// Then
+	require.Equal(t, []string{"andy@example.com", "kate@example.com"}, got)
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/boldnguyen/friend-management/data/migrations"
+	"github.com/boldnguyen/friend-management/internal/pkg/db"
+)
+
+// DBPing checks that the database answers a ping.
+func DBPing(conn *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		return conn.PingContext(ctx)
+	}
+}
+
+// SchemaVersion checks that the database schema is at the version of the newest
+// embedded migration and that no migration was left half applied.
+func SchemaVersion(conn *sql.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		latest, err := migrations.Latest()
+		if err != nil {
+			return err
+		}
+
+		version, dirty, err := db.SchemaVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty", version)
+		}
+		if version != latest {
+			return fmt.Errorf("schema version %d, expected %d", version, latest)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Build information, set at link time with
+// -ldflags "-X github.com/boldnguyen/friend-management/internal/pkg/health.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// Status values reported by the checks.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// CheckFunc reports an error when the dependency it checks is not usable.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of a single readiness check.
+type CheckResult struct {
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of all readiness checks.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Checker runs the registered readiness checks.
+type Checker struct {
+	timeout time.Duration
+	started time.Time
+
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]CheckFunc
+}
+
+// NewChecker creates a Checker whose checks each run with the given timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		started: time.Now(),
+		checks:  map[string]CheckFunc{},
+	}
+}
+
+// Register adds a named readiness check. Registering the same name twice replaces the check.
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = fn
+}
+
+// Check runs all the registered checks concurrently and aggregates the results.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	names := append([]string(nil), c.names...)
+	checks := make(map[string]CheckFunc, len(c.checks))
+	for name, fn := range c.checks {
+		checks[name] = fn
+	}
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(names))}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string, fn CheckFunc) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := fn(ctx)
+			res := CheckResult{Status: StatusOK, Duration: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status = StatusUnavailable
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}(name, checks[name])
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Uptime returns how long the checker, and therefore the server, has been running.
+func (c *Checker) Uptime() time.Duration {
+	return time.Since(c.started)
+}
+
+// Build returns the build information of the running binary.
+func Build() BuildInfo {
+	return BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}
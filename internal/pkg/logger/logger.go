@@ -0,0 +1,136 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/httplog"
+	"github.com/rs/zerolog"
+)
+
+// ServiceName is attached to every log line written by the application.
+const ServiceName = "friend-management"
+
+var (
+	// base is used when the context does not carry a request scoped logger,
+	// e.g. during startup or in background workers.
+	base         = zerolog.Nop()
+	redactEmails bool
+
+	// emailInURL matches email addresses in a request path or query, either
+	// plain or with the @ percent-encoded.
+	emailInURL = regexp.MustCompile(`[^/?&=#]+(?:@|%40)[^/?&=#]+`)
+)
+
+type originalRequestKey struct{}
+
+// New configures the application logger from the given config and returns it.
+// It must be called once during startup, before the router is built.
+func New(cfg config.Log) zerolog.Logger {
+	l := httplog.NewLogger(ServiceName, httplog.Options{
+		LogLevel: cfg.Level,
+		JSON:     cfg.JSON,
+		Concise:  true,
+	})
+
+	base = l
+	redactEmails = cfg.RedactEmails
+
+	return l
+}
+
+// FromContext returns the request scoped logger carried by ctx. The logger is
+// tagged with the request ID so service and repository logs can be correlated
+// with the HTTP request that produced them. It falls back to the base logger.
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if entry, ok := ctx.Value(middleware.LogEntryCtxKey).(*httplog.RequestLoggerEntry); ok && entry != nil {
+		return &entry.Logger
+	}
+	return &base
+}
+
+// Email returns the email address as it should appear in logs. When redaction
+// is enabled only the first character of the local part is kept.
+func Email(email string) string {
+	if !redactEmails {
+		return email
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// URL returns the request path or query as it should appear in logs and traces.
+// When redaction is enabled the email addresses it contains are redacted.
+func URL(s string) string {
+	if !redactEmails {
+		return s
+	}
+	return redactURL(s)
+}
+
+// RequestLogger is an http middleware logging requests and responses like
+// httplog.RequestLogger. When redaction is enabled, email addresses in the
+// request URL are redacted in the logged fields, while the handlers still see
+// the original request.
+func RequestLogger(l zerolog.Logger, skipPaths []string) func(next http.Handler) http.Handler {
+	return chi.Chain(
+		middleware.RequestID,
+		redactRequest,
+		httplog.Handler(l, skipPaths),
+		restoreRequest,
+		middleware.Recoverer,
+	).Handler
+}
+
+// redactRequest passes a copy of the request with redacted emails in its URL to
+// the request logger. The original request is kept in the context so it can be
+// restored once the log entry is created.
+func redactRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !redactEmails {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		redacted := r.WithContext(context.WithValue(r.Context(), originalRequestKey{}, r))
+		u := *r.URL
+		u.Path = URL(u.Path)
+		u.RawPath = ""
+		u.RawQuery = URL(u.RawQuery)
+		redacted.URL = &u
+		redacted.RequestURI = URL(r.RequestURI)
+
+		next.ServeHTTP(w, redacted)
+	})
+}
+
+// restoreRequest hands the original request, with the context built by the
+// request logger, back to the handlers.
+func restoreRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if orig, ok := r.Context().Value(originalRequestKey{}).(*http.Request); ok {
+			r = orig.WithContext(r.Context())
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redactURL redacts the email addresses found in a request path or query.
+func redactURL(s string) string {
+	return emailInURL.ReplaceAllStringFunc(s, func(m string) string {
+		if email, err := url.QueryUnescape(m); err == nil {
+			m = email
+		}
+		return Email(m)
+	})
+}
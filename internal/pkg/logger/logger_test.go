@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmail tests the Email redaction helper.
+func TestEmail(t *testing.T) {
+	tcs := map[string]struct {
+		redact bool   // Whether redaction is enabled
+		email  string // Input email
+		exp    string // Expected output
+	}{
+		"redaction_disabled": {
+			redact: false,
+			email:  "andy@example.com",
+			exp:    "andy@example.com",
+		},
+		"redaction_enabled": {
+			redact: true,
+			email:  "andy@example.com",
+			exp:    "a***@example.com",
+		},
+		"redaction_enabled_invalid_email": {
+			redact: true,
+			email:  "andy",
+			exp:    "***",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			redactEmails = tc.redact
+			defer func() { redactEmails = false }()
+
+			// When
+			got := Email(tc.email)
+
+			// Then
+			require.Equal(t, tc.exp, got)
+		})
+	}
+}
+
+// TestURL tests the URL function.
+func TestURL(t *testing.T) {
+	tcs := map[string]struct {
+		redact bool   // Whether redaction is enabled
+		url    string // Input path or query
+		exp    string // Expected output
+	}{
+		"redaction_disabled": {
+			redact: false,
+			url:    "/users/andy@example.com/inbox",
+			exp:    "/users/andy@example.com/inbox",
+		},
+		"redaction_enabled": {
+			redact: true,
+			url:    "/users/andy@example.com/inbox",
+			exp:    "/users/a***@example.com/inbox",
+		},
+		"redaction_enabled_no_email": {
+			redact: true,
+			url:    "/friend/list",
+			exp:    "/friend/list",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			redactEmails = tc.redact
+			defer func() { redactEmails = false }()
+
+			// When
+			got := URL(tc.url)
+
+			// Then
+			require.Equal(t, tc.exp, got)
+		})
+	}
+}
+
+// TestRequestLogger tests that emails in the request URL are redacted in the
+// request log while the handler still sees the original request.
+func TestRequestLogger(t *testing.T) {
+	tcs := map[string]struct {
+		redact     bool   // Whether redaction is enabled
+		target     string // Request target
+		expLogged  string // Expected substring of the log line
+		expMissing string // Substring that must not be logged
+	}{
+		"redaction_disabled": {
+			redact:    false,
+			target:    "/users/andy@example.com/inbox",
+			expLogged: "/users/andy@example.com/inbox",
+		},
+		"redaction_enabled_path": {
+			redact:     true,
+			target:     "/users/andy@example.com/inbox",
+			expLogged:  "/users/a***@example.com/inbox",
+			expMissing: "andy",
+		},
+		"redaction_enabled_escaped_path": {
+			redact:     true,
+			target:     "/users/andy%40example.com/inbox",
+			expLogged:  "/users/a***@example.com/inbox",
+			expMissing: "andy",
+		},
+		"redaction_enabled_query": {
+			redact:     true,
+			target:     "/stream?email=andy@example.com",
+			expLogged:  "/stream?email=a***@example.com",
+			expMissing: "andy",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			redactEmails = tc.redact
+			defer func() { redactEmails = false }()
+
+			var buf bytes.Buffer
+			var gotURI string
+			h := RequestLogger(zerolog.New(&buf), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotURI = r.RequestURI
+			}))
+
+			// When
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			// Then
+			require.Equal(t, tc.target, gotURI)
+			require.Contains(t, buf.String(), tc.expLogged)
+			if tc.expMissing != "" {
+				require.NotContains(t, buf.String(), tc.expMissing)
+			}
+		})
+	}
+}
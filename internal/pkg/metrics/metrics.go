@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric exposed by the application.
+const namespace = "friend_management"
+
+// unmatchedRoute is used as the route label for requests that did not match any route,
+// so unknown paths do not blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+// Registry holds all the application metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	repositoryQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Latency of repository methods.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method"})
+
+	// FriendshipsCreated counts the friend connections created.
+	FriendshipsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "friendships_created_total",
+		Help:      "Total number of friend connections created.",
+	})
+
+	// SubscriptionsCreated counts the subscriptions created.
+	SubscriptionsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subscriptions_created_total",
+		Help:      "Total number of subscriptions created.",
+	})
+
+	// BlocksCreated counts the blocks created.
+	BlocksCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocks_created_total",
+		Help:      "Total number of blocks created.",
+	})
+
+	// BlocksExpired counts the blocks deleted once their expiry passed.
+	BlocksExpired = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocks_expired_total",
+		Help:      "Total number of blocks expired.",
+	})
+
+	// RecipientsFanout observes the number of eligible recipients computed for an update.
+	RecipientsFanout = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "recipients_fanout_size",
+		Help:      "Number of eligible recipients computed for an update.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+	// StreamConnections tracks the open real-time streams by transport.
+	StreamConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_connections",
+		Help:      "Number of open real-time update streams by transport.",
+	}, []string{"transport"})
+
+	// StreamDropped counts the streams disconnected for falling behind.
+	StreamDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_dropped_total",
+		Help:      "Total number of streams disconnected because their buffer was full.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		repositoryQueryDuration,
+		FriendshipsCreated,
+		SubscriptionsCreated,
+		BlocksCreated,
+		BlocksExpired,
+		RecipientsFanout,
+		StreamConnections,
+		StreamDropped,
+	)
+}
+
+// RegisterDB exposes the connection pool statistics of db.
+func RegisterDB(db *sql.DB, dbName string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// Handler returns the HTTP handler serving the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware records the count and latency of HTTP requests, labelled by chi route pattern.
+func Middleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		labels := prometheus.Labels{"route": route, "method": r.Method, "status": strconv.Itoa(status)}
+
+		httpRequestsTotal.With(labels).Inc()
+		httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ObserveQuery records the latency of the repository method started at start.
+// It is meant to be deferred at the top of the method:
+//
+//	defer metrics.ObserveQuery("GetUserByEmail", time.Now())
+func ObserveQuery(method string, start time.Time) {
+	repositoryQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMiddleware tests that Middleware labels requests with the chi route pattern.
+func TestMiddleware(t *testing.T) {
+	tcs := map[string]struct {
+		path     string // Requested path
+		expRoute string // Expected route label
+		expCode  string // Expected status label
+	}{
+		"matched_route": {
+			path:     "/users/andy@example.com",
+			expRoute: "/users/{email}",
+			expCode:  "200",
+		},
+		"unmatched_route": {
+			path:     "/unknown",
+			expRoute: unmatchedRoute,
+			expCode:  "404",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			r := chi.NewRouter()
+			r.Use(Middleware)
+			r.Get("/users/{email}", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("OK"))
+			})
+			counter := httpRequestsTotal.WithLabelValues(tc.expRoute, http.MethodGet, tc.expCode)
+			before := testutil.ToFloat64(counter)
+
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+
+			// When
+			r.ServeHTTP(rr, req)
+
+			// Then
+			require.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package netguard keeps the requests made to user supplied URLs, e.g. webhooks, away from
+// the loopback, private and reserved networks.
+package netguard
+
+import (
+	"context"
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// ErrForbiddenAddress is returned for a host resolving to an address that is not publicly routable.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// Resolver looks up the addresses of a host. *net.Resolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// reserved lists the special purpose networks not covered by the net.IP predicates.
+var reserved = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved, and the broadcast address
+	"64:ff9b::/96",    // NAT64, may translate to private IPv4 addresses
+	"64:ff9b:1::/48",  // local-use NAT64
+	"100::/64",        // discard-only
+	"2001:db8::/32",   // documentation
+)
+
+// IsPublic reports whether ip is a publicly routable unicast address.
+func IsPublic(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host and returns ErrForbiddenAddress when any of its addresses is not
+// publicly routable.
+func CheckHost(ctx context.Context, r Resolver, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublic(ip) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function refusing to connect to addresses that are not
+// publicly routable. Checking the address actually dialled also covers redirects and hosts
+// whose DNS records changed since they were checked.
+func Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublic(ip) {
+		return errors.Wrap(ErrForbiddenAddress, address)
+	}
+	return nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
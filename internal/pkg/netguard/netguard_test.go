@@ -0,0 +1,87 @@
+package netguard
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver resolves every host to the same addresses.
+type fakeResolver []string
+
+func (r fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, ip := range r {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+// TestCheckHost tests the rejection of the hosts that are not publicly routable.
+func TestCheckHost(t *testing.T) {
+	tcs := map[string]struct {
+		host     string       // Host to check
+		resolver fakeResolver // Addresses the host resolves to
+		expErr   error        // Expected error
+	}{
+		"public_name": {
+			host:     "example.com",
+			resolver: fakeResolver{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+		},
+		"public_ip": {
+			host: "93.184.216.34",
+		},
+		"loopback_ip": {
+			host:   "127.0.0.1",
+			expErr: ErrForbiddenAddress,
+		},
+		"loopback_ipv6": {
+			host:   "::1",
+			expErr: ErrForbiddenAddress,
+		},
+		"ipv4_mapped_loopback": {
+			host:   "::ffff:127.0.0.1",
+			expErr: ErrForbiddenAddress,
+		},
+		"private_ip": {
+			host:   "10.1.2.3",
+			expErr: ErrForbiddenAddress,
+		},
+		"metadata_ip": {
+			host:   "169.254.169.254",
+			expErr: ErrForbiddenAddress,
+		},
+		"carrier_grade_nat_ip": {
+			host:   "100.64.0.1",
+			expErr: ErrForbiddenAddress,
+		},
+		"unspecified_ip": {
+			host:   "0.0.0.0",
+			expErr: ErrForbiddenAddress,
+		},
+		"name_resolving_to_private_ip": {
+			host:     "internal.example.com",
+			resolver: fakeResolver{"93.184.216.34", "192.168.1.10"},
+			expErr:   ErrForbiddenAddress,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// When
+			err := CheckHost(context.Background(), tc.resolver, tc.host)
+
+			// Then
+			require.Equal(t, tc.expErr, err)
+		})
+	}
+}
+
+// TestControl tests that dialling an address that is not publicly routable is refused.
+func TestControl(t *testing.T) {
+	require.NoError(t, Control("tcp4", "93.184.216.34:443", nil))
+	require.ErrorIs(t, Control("tcp4", "127.0.0.1:80", nil), ErrForbiddenAddress)
+	require.ErrorIs(t, Control("tcp6", "[fd00::1]:80", nil), ErrForbiddenAddress)
+}
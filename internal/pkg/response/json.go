@@ -15,6 +15,7 @@ const (
 	ErrMsgCreateFriend              = "failed to create friend connection"
 	ErrMsgGetUserByEmail            = "failed to get user by email"
 	ErrMsgUserNotFound              = "user not found"
+	ErrMsgInactiveUser              = "user account is not active"
 	ErrMsgCheckFriend               = "failed to check if users are already friends"
 	ErrMsgAlreadyFriends            = "They are already friends"
 	ErrMsgGetFriendsList            = "failed to get friends list"
@@ -29,6 +30,83 @@ const (
 	ErrMsgRemoveSubscription        = "failed to remove subscription"
 	ErrMsgBlockUser                 = "failed to block user"
 	ErrMsgGetSubscribers            = "failed to get subscribers"
+	ErrMsgBeginTx                   = "failed to begin transaction"
+	ErrMsgCommitTx                  = "failed to commit transaction"
+	ErrMsgInsertOutboxEvent         = "failed to record event"
+	ErrMsgClaimOutboxEvents         = "failed to claim pending events"
+	ErrMsgUpdateOutboxEvent         = "failed to update event delivery state"
+	ErrMsgCreateWebhook             = "failed to create webhook"
+	ErrMsgGetWebhook                = "failed to get webhook"
+	ErrMsgDeleteWebhook             = "failed to delete webhook"
+	ErrMsgWebhookNotFound           = "webhook not found"
+	ErrMsgInvalidWebhookURL         = "webhook URL must be an absolute http or https URL"
+	ErrMsgForbiddenWebhookURL       = "webhook URL must resolve to public addresses"
+	ErrMsgInvalidEventType          = "unknown event type"
+	ErrMsgEnqueueWebhookDelivery    = "failed to enqueue webhook delivery"
+	ErrMsgClaimWebhookDeliveries    = "failed to claim webhook deliveries"
+	ErrMsgUpdateWebhookDelivery     = "failed to update webhook delivery state"
+	ErrMsgGetWebhookDeliveries      = "failed to get webhook deliveries"
+	ErrMsgCreateUpdate              = "failed to create update"
+	ErrMsgGetUpdate                 = "failed to get update"
+	ErrMsgUpdateNotFound            = "update not found"
+	ErrMsgDeliverUpdate             = "failed to deliver update"
+	ErrMsgGetInbox                  = "failed to get inbox"
+	ErrMsgInvalidUpdateText         = "update text must not be empty"
+	ErrMsgInvalidStreamUser         = "the email query parameter must name the authenticated user"
+	ErrMsgGetDuplicateEmails        = "failed to get duplicate emails"
+	ErrMsgImportRelations           = "failed to import relations"
+	ErrMsgExportRelations           = "failed to export relations"
+	ErrMsgInvalidBulkFormat         = "format must be csv or jsonl"
+	ErrMsgTooManyRows               = "too many rows"
+	ErrMsgMalformedRow              = "malformed row"
+	ErrMsgInvalidRelationType       = "type must be friendship, subscription or block"
+	ErrMsgInvalidEmail              = "invalid email"
+	ErrMsgSelfRelation              = "requestor and target must be different users"
+	ErrMsgDuplicateRow              = "duplicate row"
+	ErrMsgUnexpectedBlockDetails    = "only blocks have a category, reason and expiry"
+	ErrMsgCreateUser                = "failed to create user"
+	ErrMsgDeleteUser                = "failed to delete user"
+	ErrMsgUserAlreadyExists         = "user already exists"
+	ErrMsgRemoveFriend              = "failed to remove friend connection"
+	ErrMsgNotFriends                = "They are not friends"
+	ErrMsgUnblockUser               = "failed to unblock user"
+	ErrMsgNotBlocked                = "The user is not blocked"
+	ErrMsgExportUserData            = "failed to export user data"
+	ErrMsgEraseUser                 = "failed to erase user"
+	ErrMsgInvalidErasureMode        = "mode must be delete or anonymize"
+	ErrMsgSetUserStatus             = "failed to set user status"
+	ErrMsgInvalidUserStatus         = "status must be active, deactivated, suspended or deleted"
+	ErrMsgPurgeUsers                = "failed to purge deleted users"
+	ErrMsgInsertAuditEntry          = "failed to record audit entry"
+	ErrMsgGetAuditEntries           = "failed to get audit entries"
+	ErrMsgInvalidAuditAction        = "unknown audit action"
+	ErrMsgInvalidTimeRange          = "from must be before to"
+	ErrMsgGetSuggestions            = "failed to get friend suggestions"
+	ErrMsgGetFriendPath             = "failed to find friendship path"
+	ErrMsgInvalidPathDepth          = "invalid max depth"
+	ErrMsgGetAnalytics              = "failed to get analytics"
+	ErrMsgStoreAnalytics            = "failed to store analytics snapshot"
+	ErrMsgInvalidAnalyticsFormat    = "format must be json or csv"
+	ErrMsgGetRelationship           = "failed to get relationship"
+	ErrMsgTooManyTargets            = "too many target emails"
+	ErrMsgGetUserStats              = "failed to get user stats"
+	ErrMsgReconcileUserStats        = "failed to reconcile user stats"
+	ErrMsgSetPrivacy                = "failed to set privacy"
+	ErrMsgInvalidFollowerPolicy     = "policy must be keep, review or remove"
+	ErrMsgGetSubscriptionRequests   = "failed to get subscription requests"
+	ErrMsgApproveSubscription       = "failed to approve subscription"
+	ErrMsgDenySubscription          = "failed to deny subscription"
+	ErrMsgSubscriptionNotPending    = "There is no pending subscription request"
+	ErrMsgMuteUser                  = "failed to mute user"
+	ErrMsgUnmuteUser                = "failed to unmute user"
+	ErrMsgGetMutes                  = "failed to get mutes"
+	ErrMsgNotMuted                  = "The user is not muted"
+	ErrMsgInvalidExpiry             = "expires_at must be in the future"
+	ErrMsgInvalidBlockCategory      = "category must be spam, harassment, abuse or other"
+	ErrMsgExpireBlocks              = "failed to expire blocks"
+	ErrMsgUnauthenticated           = "authentication required"
+	ErrMsgInvalidToken              = "invalid or expired token"
+	ErrMsgForbidden                 = "not allowed to access this resource"
 )
 
 // RespondSuccess responds basic success response
@@ -82,3 +160,21 @@ func RespondErr(
 	w.Write(respByte)
 	return nil
 }
+
+// RespondJSON responds with data encoded as JSON as is, using the given status code
+func RespondJSON(ctx context.Context, w http.ResponseWriter, code int, data interface{}) error {
+	log := httplog.LogEntry(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	respByte, err := json.Marshal(data)
+	if err != nil {
+		log.Error().Msgf("Failed to marshal response, err: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+
+	w.WriteHeader(code)
+	w.Write(respByte)
+	return nil
+}
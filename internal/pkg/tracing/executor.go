@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// executor wraps a boil.ContextExecutor and records a client span for every SQL statement.
+type executor struct {
+	boil.ContextExecutor
+}
+
+// WrapExecutor returns a boil.ContextExecutor that traces the statements run through exec.
+func WrapExecutor(exec boil.ContextExecutor) boil.ContextExecutor {
+	return executor{ContextExecutor: exec}
+}
+
+// ExecContext traces and runs the statement.
+func (e executor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	res, err := e.ContextExecutor.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+// QueryContext traces and runs the query.
+func (e executor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	rows, err := e.ContextExecutor.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext traces and runs the query. Scan errors are not recorded on the span.
+func (e executor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, query)
+	defer span.End()
+
+	return e.ContextExecutor.QueryRowContext(ctx, query, args...)
+}
+
+// startStatement starts a client span for the SQL statement.
+func startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	return Start(ctx, "SQL",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBStatement(query)),
+	)
+}
+
+// recordError marks the span as failed when err is not nil.
+func recordError(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,62 @@
+// Package purge permanently deletes the accounts deleted beyond the retention period.
+package purge
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/service"
+)
+
+// Purger periodically purges the users deleted for longer than the retention period,
+// along with their relations and updates. Until then deleted users can be restored.
+type Purger struct {
+	svc       service.UserService
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewPurger creates a new Purger.
+func NewPurger(svc service.UserService, cfg config.Accounts) *Purger {
+	return &Purger{
+		svc:       svc,
+		retention: cfg.RetentionPeriod,
+		interval:  cfg.PurgeInterval,
+		now:       time.Now,
+	}
+}
+
+// Run purges deleted users until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Dur("retention", p.retention).Msg("Starting deleted account purge")
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PurgeOnce(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to purge deleted accounts")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping deleted account purge")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce purges the users deleted for longer than the retention period, returning how
+// many were purged.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	n, err := p.svc.PurgeDeletedUsers(ctx, p.now().Add(-p.retention))
+	if n > 0 {
+		logger.FromContext(ctx).Info().Int("purged", n).Msg("Purged deleted accounts")
+	}
+	return n, err
+}
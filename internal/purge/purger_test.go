@@ -0,0 +1,86 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPurger_PurgeOnce tests the PurgeOnce method of the Purger.
+func TestPurger_PurgeOnce(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := map[string]struct {
+		purged int   // Users purged by the service
+		err    error // Error returned by the service
+	}{
+		"success": {
+			purged: 3,
+		},
+		"nothing_to_purge": {},
+		"service_error": {
+			purged: 1,
+			err:    errors.New(response.ErrMsgPurgeUsers),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockUserService)
+			purger := NewPurger(mockService, config.Accounts{RetentionPeriod: 24 * time.Hour, PurgeInterval: time.Hour})
+			purger.now = func() time.Time { return now }
+
+			mockService.On("PurgeDeletedUsers", mock.Anything, now.Add(-24*time.Hour)).Return(tc.purged, tc.err).Once()
+
+			// When
+			n, err := purger.PurgeOnce(context.Background())
+
+			// Then
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.purged, n)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestPurger_Run tests that the Purger purges on start and stops with its context.
+func TestPurger_Run(t *testing.T) {
+	// Given
+	mockService := new(service.MockUserService)
+	purger := NewPurger(mockService, config.Accounts{RetentionPeriod: time.Hour, PurgeInterval: time.Hour})
+
+	done := make(chan struct{})
+	mockService.On("PurgeDeletedUsers", mock.Anything, mock.AnythingOfType("time.Time")).
+		Run(func(mock.Arguments) { close(done) }).Return(0, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		purger.Run(ctx)
+		close(stopped)
+	}()
+
+	// When
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deleted accounts were not purged")
+	}
+	cancel()
+
+	// Then
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("purger did not stop")
+	}
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// GraphTotals counts the active users and the relations between them.
+type GraphTotals struct {
+	Users         int `json:"users"`
+	Friendships   int `json:"friendships"`
+	Subscriptions int `json:"subscriptions"`
+	Blocks        int `json:"blocks"`
+}
+
+// DegreeCount is the number of active users having a given number of active friends.
+type DegreeCount struct {
+	Degree int `json:"degree"`
+	Users  int `json:"users"`
+}
+
+// UserCount is a user along with the number of relations counted for them.
+type UserCount struct {
+	Email string `json:"email"`
+	Count int    `json:"count"`
+}
+
+// ComponentStats summarizes the connected components of the friendship graph.
+type ComponentStats struct {
+	Count   int `json:"count"`
+	Largest int `json:"largest"`
+	// Isolated is the number of users without any friend, each one a component of their own.
+	Isolated int `json:"isolated"`
+}
+
+// AnalyticsReport is a snapshot of aggregate statistics on the social graph.
+type AnalyticsReport struct {
+	ID                 int64          `json:"id"`
+	Totals             GraphTotals    `json:"totals"`
+	DegreeDistribution []DegreeCount  `json:"degree_distribution"`
+	MostFollowed       []UserCount    `json:"most_followed"`
+	MostBlocked        []UserCount    `json:"most_blocked"`
+	Components         ComponentStats `json:"components"`
+	// ClusteringCoefficient is the average local clustering coefficient of the active users.
+	ClusteringCoefficient float64   `json:"clustering_coefficient"`
+	ComputedAt            time.Time `json:"computed_at"`
+}
+
+// activeFriendEdges selects the friendships between active users.
+const activeFriendEdges = `
+        SELECT f.user_id1, f.user_id2
+        FROM friend_connections f
+        JOIN users u1 ON u1.id = f.user_id1 AND u1.status = $1
+        JOIN users u2 ON u2.id = f.user_id2 AND u2.status = $1`
+
+// GetGraphTotals counts the active users, the friendships and approved subscriptions between
+// them and all the blocks.
+func (repo *analyticsRepository) GetGraphTotals(ctx context.Context) (GraphTotals, error) {
+	ctx, done := startOp(ctx, "GetGraphTotals")
+	defer done()
+
+	var totals GraphTotals
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM users WHERE status = $1),
+            (SELECT COUNT(*) FROM (`+activeFriendEdges+`) f),
+            (SELECT COUNT(*) FROM subscriptions s
+             JOIN users r ON r.email = s.requestor AND r.status = $1
+             JOIN users t ON t.email = s.target AND t.status = $1
+             WHERE s.status = $2),
+            (SELECT COUNT(*) FROM blocks);
+    `, UserStatusActive, SubscriptionStatusApproved).Scan(&totals.Users, &totals.Friendships, &totals.Subscriptions, &totals.Blocks)
+	if err != nil {
+		return GraphTotals{}, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	return totals, nil
+}
+
+// GetDegreeDistribution counts the active users by their number of active friends.
+func (repo *analyticsRepository) GetDegreeDistribution(ctx context.Context) ([]DegreeCount, error) {
+	ctx, done := startOp(ctx, "GetDegreeDistribution")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        WITH edges AS (`+activeFriendEdges+`
+        ), degrees AS (
+            SELECT u.id, COUNT(e.user_id1) AS degree
+            FROM users u
+            LEFT JOIN edges e ON u.id IN (e.user_id1, e.user_id2)
+            WHERE u.status = $1
+            GROUP BY u.id
+        )
+        SELECT degree, COUNT(*) FROM degrees GROUP BY degree ORDER BY degree;
+    `, UserStatusActive)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	defer rows.Close()
+
+	distribution := []DegreeCount{}
+	for rows.Next() {
+		var d DegreeCount
+		if err := rows.Scan(&d.Degree, &d.Users); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+		}
+		distribution = append(distribution, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	return distribution, nil
+}
+
+// GetMostFollowed retrieves the active users with the most active approved subscribers.
+func (repo *analyticsRepository) GetMostFollowed(ctx context.Context, limit int) ([]UserCount, error) {
+	ctx, done := startOp(ctx, "GetMostFollowed")
+	defer done()
+
+	return repo.userCounts(ctx, `
+        SELECT t.email, COUNT(*)
+        FROM subscriptions s
+        JOIN users r ON r.email = s.requestor AND r.status = $1
+        JOIN users t ON t.email = s.target AND t.status = $1
+        WHERE s.status = $3
+        GROUP BY t.email
+        ORDER BY COUNT(*) DESC, t.email
+        LIMIT $2;
+    `, UserStatusActive, limit, SubscriptionStatusApproved)
+}
+
+// GetMostBlocked retrieves the users blocked by the most users. Users of any status are
+// counted, as the ones suspended for being blocked are of interest too.
+func (repo *analyticsRepository) GetMostBlocked(ctx context.Context, limit int) ([]UserCount, error) {
+	ctx, done := startOp(ctx, "GetMostBlocked")
+	defer done()
+
+	return repo.userCounts(ctx, `
+        SELECT u.email, COUNT(*)
+        FROM blocks b
+        JOIN users u ON u.id = b.target
+        GROUP BY u.email
+        ORDER BY COUNT(*) DESC, u.email
+        LIMIT $1;
+    `, limit)
+}
+
+// userCounts runs a query selecting emails along with a count.
+func (repo *analyticsRepository) userCounts(ctx context.Context, query string, args ...interface{}) ([]UserCount, error) {
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	defer rows.Close()
+
+	counts := []UserCount{}
+	for rows.Next() {
+		var c UserCount
+		if err := rows.Scan(&c.Email, &c.Count); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	return counts, nil
+}
+
+// ListFriendEdges streams the friendships between active users to fn, stopping at the first
+// error fn returns.
+func (repo *analyticsRepository) ListFriendEdges(ctx context.Context, fn func(userID1, userID2 int) error) error {
+	ctx, done := startOp(ctx, "ListFriendEdges")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, activeFriendEdges+";", UserStatusActive)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id1, id2 int
+		if err := rows.Scan(&id1, &id2); err != nil {
+			return errors.Wrap(err, response.ErrMsgGetAnalytics)
+		}
+		if err := fn(id1, id2); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(rows.Err(), response.ErrMsgGetAnalytics)
+}
+
+// InsertAnalyticsSnapshot stores the report, setting its ID and computation time.
+func (repo *analyticsRepository) InsertAnalyticsSnapshot(ctx context.Context, report *AnalyticsReport) error {
+	ctx, done := startOp(ctx, "InsertAnalyticsSnapshot")
+	defer done()
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgStoreAnalytics)
+	}
+
+	err = repo.DB.QueryRowContext(ctx, `
+        INSERT INTO analytics_snapshots (report) VALUES ($1) RETURNING id, computed_at;
+    `, body).Scan(&report.ID, &report.ComputedAt)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgStoreAnalytics)
+	}
+	return nil
+}
+
+// GetLatestAnalyticsSnapshot retrieves the latest report stored, or nil when there is none yet.
+func (repo *analyticsRepository) GetLatestAnalyticsSnapshot(ctx context.Context) (*AnalyticsReport, error) {
+	ctx, done := startOp(ctx, "GetLatestAnalyticsSnapshot")
+	defer done()
+
+	var (
+		report AnalyticsReport
+		body   []byte
+	)
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT id, report, computed_at FROM analytics_snapshots ORDER BY computed_at DESC, id DESC LIMIT 1;
+    `).Scan(&report.ID, &body, &report.ComputedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+
+	// The ID and time of the report are only known once stored, they are read from the columns
+	id, computedAt := report.ID, report.ComputedAt
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAnalytics)
+	}
+	report.ID, report.ComputedAt = id, computedAt
+	return &report, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnalyticsRepo is a mock implementation of AnalyticsRepository for testing purposes.
+type MockAnalyticsRepo struct {
+	mock.Mock
+}
+
+// GetGraphTotals mocks the GetGraphTotals method.
+func (m *MockAnalyticsRepo) GetGraphTotals(ctx context.Context) (GraphTotals, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(GraphTotals), args.Error(1)
+}
+
+// GetDegreeDistribution mocks the GetDegreeDistribution method.
+func (m *MockAnalyticsRepo) GetDegreeDistribution(ctx context.Context) ([]DegreeCount, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DegreeCount), args.Error(1)
+}
+
+// GetMostFollowed mocks the GetMostFollowed method.
+func (m *MockAnalyticsRepo) GetMostFollowed(ctx context.Context, limit int) ([]UserCount, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]UserCount), args.Error(1)
+}
+
+// GetMostBlocked mocks the GetMostBlocked method.
+func (m *MockAnalyticsRepo) GetMostBlocked(ctx context.Context, limit int) ([]UserCount, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]UserCount), args.Error(1)
+}
+
+// ListFriendEdges mocks the ListFriendEdges method, calling fn with the pairs of user IDs
+// given to Return.
+func (m *MockAnalyticsRepo) ListFriendEdges(ctx context.Context, fn func(userID1, userID2 int) error) error {
+	args := m.Called(ctx, fn)
+	if edges, ok := args.Get(0).([][2]int); ok {
+		for _, e := range edges {
+			if err := fn(e[0], e[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// InsertAnalyticsSnapshot mocks the InsertAnalyticsSnapshot method.
+func (m *MockAnalyticsRepo) InsertAnalyticsSnapshot(ctx context.Context, report *AnalyticsReport) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+// GetLatestAnalyticsSnapshot mocks the GetLatestAnalyticsSnapshot method.
+func (m *MockAnalyticsRepo) GetLatestAnalyticsSnapshot(ctx context.Context) (*AnalyticsReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*AnalyticsReport), args.Error(1)
+}
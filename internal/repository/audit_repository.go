@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// AuditEntry is the record of a mutation of the social graph.
+type AuditEntry struct {
+	ID int64 `json:"id"`
+	// Actor is who made the change, empty when unknown.
+	Actor string `json:"actor"`
+	// Action is the type of the domain event recorded with the change.
+	Action    string `json:"action"`
+	Requestor string `json:"requestor"`
+	Target    string `json:"target"`
+	RequestID string `json:"request_id"`
+	ClientIP  string `json:"client_ip"`
+	// Before and After are the state of the relation between the requestor and the target.
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit entries. Zero fields do not filter.
+type AuditFilter struct {
+	// User matches the entries where the user is either the requestor or the target.
+	User   string
+	Action string
+	// From and To bound the time of the entries, From inclusive and To exclusive.
+	From time.Time
+	To   time.Time
+}
+
+// ListAuditEntries lists the audit entries matching the filter, newest first.
+func (repo *auditRepository) ListAuditEntries(ctx context.Context, filter AuditFilter, limit, offset int) ([]AuditEntry, error) {
+	ctx, done := startOp(ctx, "ListAuditEntries")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT id, actor, action, requestor, target, request_id, client_ip, before, after, created_at
+        FROM audit_log
+        WHERE ($1 = '' OR requestor = $1 OR target = $1)
+          AND ($2 = '' OR action = $2)
+          AND ($3::timestamp IS NULL OR created_at >= $3)
+          AND ($4::timestamp IS NULL OR created_at < $4)
+        ORDER BY id DESC
+        LIMIT $5 OFFSET $6;
+    `, emailaddr.Normalize(filter.User), filter.Action, nullTime(filter.From), nullTime(filter.To), limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAuditEntries)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Requestor, &e.Target, &e.RequestID, &e.ClientIP,
+			&before, &after, &e.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetAuditEntries)
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetAuditEntries)
+	}
+
+	return entries, nil
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditRepo is a mock implementation of AuditRepository for testing purposes.
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+// ListAuditEntries mocks the ListAuditEntries method.
+func (m *MockAuditRepo) ListAuditEntries(ctx context.Context, filter AuditFilter, limit, offset int) ([]AuditEntry, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]AuditEntry), args.Error(1)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// Block categories, telling what a block was made for.
+const (
+	BlockCategorySpam       = "spam"
+	BlockCategoryHarassment = "harassment"
+	BlockCategoryAbuse      = "abuse"
+	BlockCategoryOther      = "other"
+)
+
+// BlockCategories lists every block category.
+var BlockCategories = []string{BlockCategorySpam, BlockCategoryHarassment, BlockCategoryAbuse, BlockCategoryOther}
+
+// BlockDetails describes why a block was made and how long it lasts.
+type BlockDetails struct {
+	Category string `json:"category"`
+	Reason   string `json:"reason"`
+	// ExpiresAt is nil for a block lasting until the user is unblocked.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ExpiredBlock is a block deleted once its expiry passed.
+type ExpiredBlock struct {
+	Requestor string    `json:"requestor"`
+	Target    string    `json:"target"`
+	Category  string    `json:"category"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ExpireBlocks deletes up to limit blocks whose expiry passed, oldest expiry first, and returns
+// them. Blocks being expired by a concurrent sweep are skipped.
+func (repo *friendRepository) ExpireBlocks(ctx context.Context, limit int) ([]ExpiredBlock, error) {
+	ctx, done := startOp(ctx, "ExpireBlocks")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        WITH expired AS (
+            SELECT id FROM blocks
+            WHERE expires_at <= NOW()
+            ORDER BY expires_at, id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        ), deleted AS (
+            DELETE FROM blocks b
+            USING expired e
+            WHERE b.id = e.id
+            RETURNING b.id, b.requestor, b.target, b.category, b.expires_at
+        )
+        SELECT r.email, t.email, d.category, d.expires_at
+        FROM deleted d
+        JOIN users r ON r.id = d.requestor
+        JOIN users t ON t.id = d.target
+        ORDER BY d.expires_at, d.id;
+    `, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgExpireBlocks)
+	}
+	defer rows.Close()
+
+	var expired []ExpiredBlock
+	for rows.Next() {
+		var block ExpiredBlock
+		if err := rows.Scan(&block.Requestor, &block.Target, &block.Category, &block.ExpiresAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgExpireBlocks)
+		}
+		expired = append(expired, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgExpireBlocks)
+	}
+	return expired, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Types of the relations between users.
+const (
+	RelationFriendship   = "friendship"
+	RelationSubscription = "subscription"
+	RelationBlock        = "block"
+)
+
+// Relation is a friendship, subscription or block between two users. Friendships are
+// symmetric, for them requestor and target are simply the two friends.
+type Relation struct {
+	Type      string `json:"type"`
+	Requestor string `json:"requestor"`
+	Target    string `json:"target"`
+	// Category, Reason and ExpiresAt are the details of a block, empty for the other
+	// relations. ExpiresAt is nil for a block lasting until the user is unblocked.
+	Category  string     `json:"category,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ImportRelation is a relation to import along with the users it is between.
+type ImportRelation struct {
+	// Line is the line of the relation in the imported file, it identifies the relation.
+	Line int
+	Relation
+	RequestorID int
+	TargetID    int
+}
+
+// UserRef identifies a user.
+type UserRef struct {
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+// ResolveUsers looks up the users with the given emails, returning them by canonical email.
+// Emails of unknown users are missing from the result.
+func (repo *bulkRepository) ResolveUsers(ctx context.Context, emails []string) (map[string]UserRef, error) {
+	ctx, done := startOp(ctx, "ResolveUsers")
+	defer done()
+
+	users := make(map[string]UserRef, len(emails))
+	if len(emails) == 0 {
+		return users, nil
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT DISTINCT ON (LOWER(email)) id, email
+        FROM users
+        WHERE LOWER(email) = ANY($1)
+        ORDER BY LOWER(email), id;
+    `, pq.Array(emailaddr.NormalizeAll(emails)))
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user UserRef
+		if err := rows.Scan(&user.ID, &user.Email); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+		}
+		users[emailaddr.Normalize(user.Email)] = user
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	return users, nil
+}
+
+// importStatements insert the staged relations that do not exist yet, returning the lines
+// of the relations created. Subscriptions to a private user are pending until they approve
+// them, as when subscribing through the API.
+var importStatements = []string{
+	`WITH created AS (
+        INSERT INTO friend_connections (user_id1, user_id2)
+        SELECT s.requestor_id, s.target_id
+        FROM bulk_import s
+        WHERE s.kind = 'friendship' AND NOT EXISTS (
+            SELECT 1 FROM friend_connections f
+            WHERE f.user_id1 = s.target_id AND f.user_id2 = s.requestor_id
+        )
+        ON CONFLICT (user_id1, user_id2) DO NOTHING
+        RETURNING user_id1, user_id2
+    )
+    SELECT s.line FROM bulk_import s
+    JOIN created c ON c.user_id1 = s.requestor_id AND c.user_id2 = s.target_id
+    WHERE s.kind = 'friendship';`,
+	`WITH created AS (
+        INSERT INTO subscriptions (requestor, target, status)
+        SELECT s.requestor, s.target, CASE WHEN u.private THEN 'pending' ELSE 'approved' END
+        FROM bulk_import s
+        JOIN users u ON u.id = s.target_id
+        WHERE s.kind = 'subscription'
+        ON CONFLICT (requestor, target) DO NOTHING
+        RETURNING requestor, target
+    )
+    SELECT s.line FROM bulk_import s
+    JOIN created c ON c.requestor = s.requestor AND c.target = s.target
+    WHERE s.kind = 'subscription';`,
+	`WITH created AS (
+        INSERT INTO blocks (requestor, target, category, reason, expires_at)
+        SELECT s.requestor_id, s.target_id, s.category, s.reason, s.expires_at
+        FROM bulk_import s WHERE s.kind = 'block'
+        ON CONFLICT (requestor, target) DO NOTHING
+        RETURNING requestor, target
+    )
+    SELECT s.line FROM bulk_import s
+    JOIN created c ON c.requestor = s.requestor_id AND c.target = s.target_id
+    WHERE s.kind = 'block';`,
+}
+
+// ImportRelations creates the relations in a single transaction, returning the lines of
+// the relations created. Relations that already exist are left untouched. The relations
+// are staged with COPY, then inserted with one statement per type.
+func (repo *bulkRepository) ImportRelations(ctx context.Context, relations []ImportRelation) ([]int, error) {
+	ctx, done := startOp(ctx, "ImportRelations")
+	defer done()
+
+	if len(relations) == 0 {
+		return nil, nil
+	}
+
+	tx, err := repo.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgBeginTx)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        CREATE TEMP TABLE bulk_import (
+            line INT NOT NULL,
+            kind TEXT NOT NULL,
+            requestor TEXT NOT NULL,
+            target TEXT NOT NULL,
+            requestor_id INT NOT NULL,
+            target_id INT NOT NULL,
+            category TEXT NOT NULL,
+            reason TEXT NOT NULL,
+            expires_at TIMESTAMPTZ
+        ) ON COMMIT DROP;
+    `)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("bulk_import", "line", "kind", "requestor", "target", "requestor_id", "target_id",
+		"category", "reason", "expires_at"))
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+	}
+	for _, rel := range relations {
+		if _, err := stmt.ExecContext(ctx, rel.Line, rel.Type, rel.Requestor, rel.Target, rel.RequestorID, rel.TargetID,
+			rel.Category, rel.Reason, rel.ExpiresAt); err != nil {
+			stmt.Close()
+			return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+		}
+	}
+	// Flush the staged rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+	}
+
+	exec := tracing.WrapExecutor(tx)
+	var created []int
+	for _, query := range importStatements {
+		rows, err := exec.QueryContext(ctx, query)
+		if err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+		}
+		for rows.Next() {
+			var line int
+			if err := rows.Scan(&line); err != nil {
+				rows.Close()
+				return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+			}
+			created = append(created, line)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgImportRelations)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgCommitTx)
+	}
+	return created, nil
+}
+
+// ExportRelations calls fn with every relation involving the user, or with every relation
+// of the graph when user is nil. Pending subscriptions and expired blocks are left out, the
+// other blocks come with their details. It stops at the first error returned by fn.
+func (repo *bulkRepository) ExportRelations(ctx context.Context, user *UserRef, fn func(Relation) error) error {
+	ctx, done := startOp(ctx, "ExportRelations")
+	defer done()
+
+	var id, email interface{}
+	if user != nil {
+		id, email = user.ID, user.Email
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT 'friendship', u1.email, u2.email, '', '', NULL::TIMESTAMPTZ
+        FROM friend_connections f
+        JOIN users u1 ON u1.id = f.user_id1
+        JOIN users u2 ON u2.id = f.user_id2
+        WHERE $1::INT IS NULL OR $1 IN (f.user_id1, f.user_id2)
+        UNION ALL
+        SELECT 'subscription', s.requestor, s.target, '', '', NULL::TIMESTAMPTZ
+        FROM subscriptions s
+        WHERE s.status = $3 AND ($2::TEXT IS NULL OR $2 IN (s.requestor, s.target))
+        UNION ALL
+        SELECT 'block', u1.email, u2.email, b.category, b.reason, b.expires_at
+        FROM blocks b
+        JOIN users u1 ON u1.id = b.requestor
+        JOIN users u2 ON u2.id = b.target
+        WHERE ($1::INT IS NULL OR $1 IN (b.requestor, b.target))
+          AND (b.expires_at IS NULL OR b.expires_at > NOW());
+    `, id, email, SubscriptionStatusApproved)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgExportRelations)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rel Relation
+		if err := rows.Scan(&rel.Type, &rel.Requestor, &rel.Target, &rel.Category, &rel.Reason, &rel.ExpiresAt); err != nil {
+			return errors.Wrap(err, response.ErrMsgExportRelations)
+		}
+		if err := fn(rel); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, response.ErrMsgExportRelations)
+	}
+	return nil
+}
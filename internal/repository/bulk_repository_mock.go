@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBulkRepo is a mock implementation of BulkRepository for testing purposes.
+type MockBulkRepo struct {
+	mock.Mock
+}
+
+// ResolveUsers mocks the ResolveUsers method.
+func (m *MockBulkRepo) ResolveUsers(ctx context.Context, emails []string) (map[string]UserRef, error) {
+	args := m.Called(ctx, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]UserRef), args.Error(1)
+}
+
+// ImportRelations mocks the ImportRelations method.
+func (m *MockBulkRepo) ImportRelations(ctx context.Context, relations []ImportRelation) ([]int, error) {
+	args := m.Called(ctx, relations)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int), args.Error(1)
+}
+
+// ExportRelations mocks the ExportRelations method, calling fn with the relations given
+// to Return.
+func (m *MockBulkRepo) ExportRelations(ctx context.Context, user *UserRef, fn func(Relation) error) error {
+	args := m.Called(ctx, user, fn)
+	if relations, ok := args.Get(0).([]Relation); ok {
+		for _, rel := range relations {
+			if err := fn(rel); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
@@ -2,19 +2,31 @@ package repository
 
 import (
 	"context"
-	"strconv"
 
+	"github.com/boldnguyen/friend-management/internal/events"
 	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
 	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
-// GetUserByEmail retrieves the user by email from the database.
+// GetUserByEmail retrieves the user by email from the database. Emails are matched by their
+// canonical form, the oldest user wins when several share it.
 func (repo friendRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	user, err := models.Users(qm.Where("email = ?", email)).One(ctx, repo.DB)
+	ctx, done := startOp(ctx, "GetUserByEmail")
+	defer done()
+
+	user, err := models.Users(
+		qm.Where("LOWER(email) = ?", emailaddr.Normalize(email)),
+		qm.OrderBy("id"),
+	).One(ctx, repo.DB)
 	if err != nil {
+		logger.FromContext(ctx).Debug().Err(err).Str("email", logger.Email(email)).Msg("User lookup failed")
 		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 	return user, nil
@@ -22,6 +34,9 @@ func (repo friendRepository) GetUserByEmail(ctx context.Context, email string) (
 
 // AddFriend creates a friend connection between two users using their user IDs.
 func (repo friendRepository) AddFriend(ctx context.Context, userID1, userID2 int) error {
+	ctx, done := startOp(ctx, "AddFriend")
+	defer done()
+
 	friendship := models.FriendConnection{
 		UserID1: userID1,
 		UserID2: userID2,
@@ -29,6 +44,7 @@ func (repo friendRepository) AddFriend(ctx context.Context, userID1, userID2 int
 
 	err := friendship.Insert(ctx, repo.DB, boil.Infer())
 	if err != nil {
+		logger.FromContext(ctx).Debug().Err(err).Int("user_id1", userID1).Int("user_id2", userID2).Msg("Insert friend connection failed")
 		return errors.Wrap(err, response.ErrMsgCreateFriend)
 	}
 	return nil
@@ -36,6 +52,9 @@ func (repo friendRepository) AddFriend(ctx context.Context, userID1, userID2 int
 
 // CheckFriends checks if two users are already friends.
 func (repo friendRepository) CheckFriends(ctx context.Context, userID1, userID2 int) (bool, error) {
+	ctx, done := startOp(ctx, "CheckFriends")
+	defer done()
+
 	// Check if a friend connection exists between the two users
 	exists, err := models.FriendConnections(
 		qm.Where("user_id1 = ? AND user_id2 = ?", userID1, userID2),
@@ -57,8 +76,11 @@ func (repo friendRepository) CheckFriends(ctx context.Context, userID1, userID2
 	return exists, nil
 }
 
-// GetFriendsList retrieves the list of friends for a given user ID.
+// GetFriendsList retrieves the list of active friends for a given user ID.
 func (repo friendRepository) GetFriendsList(ctx context.Context, userID int) ([]string, error) {
+	ctx, done := startOp(ctx, "GetFriendsList")
+	defer done()
+
 	friendConnections, err := models.FriendConnections(
 		qm.Select("user_id1", "user_id2"),
 		qm.Where("user_id1 = ? OR user_id2 = ?", userID, userID),
@@ -80,14 +102,21 @@ func (repo friendRepository) GetFriendsList(ctx context.Context, userID int) ([]
 		if err != nil {
 			return nil, errors.Wrap(err, response.ErrMsgGetFriendsList)
 		}
+		// Deactivated, suspended and deleted users are hidden but stay friends
+		if user.Status != UserStatusActive {
+			continue
+		}
 		friends = append(friends, user.Email)
 	}
 
 	return friends, nil
 }
 
-// GetCommonFriends retrieves the list of common friends between two user IDs.
+// GetCommonFriends retrieves the list of active common friends between two user IDs.
 func (repo friendRepository) GetCommonFriends(ctx context.Context, userID1, userID2 int) ([]string, error) {
+	ctx, done := startOp(ctx, "GetCommonFriends")
+	defer done()
+
 	// Get friends list for both users
 	friends, err := models.FriendConnections(
 		qm.Select("user_id2"),
@@ -111,6 +140,9 @@ func (repo friendRepository) GetCommonFriends(ctx context.Context, userID1, user
 			if err != nil {
 				return nil, errors.Wrap(err, response.ErrMsgGetCommonFriends)
 			}
+			if user.Status != UserStatusActive {
+				continue
+			}
 			common = append(common, user.Email)
 		}
 	}
@@ -120,8 +152,11 @@ func (repo friendRepository) GetCommonFriends(ctx context.Context, userID1, user
 
 // CheckSubscription checks if a subscription already exists between requestor and target.
 func (repo *friendRepository) CheckSubscription(ctx context.Context, requestor, target string) (bool, error) {
+	ctx, done := startOp(ctx, "CheckSubscription")
+	defer done()
+
 	exists, err := models.Subscriptions(
-		qm.Where("requestor = ? AND target = ?", requestor, target),
+		qm.Where("requestor = ? AND target = ?", emailaddr.Normalize(requestor), emailaddr.Normalize(target)),
 	).Exists(ctx, repo.DB)
 	if err != nil {
 		return false, errors.Wrap(err, response.ErrMsgCheckSubscription)
@@ -129,30 +164,43 @@ func (repo *friendRepository) CheckSubscription(ctx context.Context, requestor,
 	return exists, nil
 }
 
-// SubscribeUpdates subscribes the requestor to updates from the target.
-func (repo *friendRepository) SubscribeUpdates(ctx context.Context, requestor, target string) error {
+// SubscribeUpdates subscribes the requestor to updates from the target and returns the status of
+// the subscription, which is pending when the target is private.
+func (repo *friendRepository) SubscribeUpdates(ctx context.Context, requestor, target string) (string, error) {
+	ctx, done := startOp(ctx, "SubscribeUpdates")
+	defer done()
+
 	// Check if the subscription already exists
 	exists, err := repo.CheckSubscription(ctx, requestor, target)
 	if err != nil {
-		return errors.Wrap(err, response.ErrMsgSubscribeUpdates)
+		return "", errors.Wrap(err, response.ErrMsgSubscribeUpdates)
 	}
 	if exists {
-		return errors.New(response.ErrMsgSubscriptionAlreadyExists)
+		return "", errors.New(response.ErrMsgSubscriptionAlreadyExists)
 	}
 
-	subscription := models.Subscription{
-		Requestor: requestor,
-		Target:    target,
-	}
-	err = subscription.Insert(ctx, repo.DB, boil.Infer())
+	// The status is decided along with the insert so a privacy change cannot slip in between
+	var status string
+	err = repo.DB.QueryRowContext(ctx, `
+        INSERT INTO subscriptions (requestor, target, status)
+        SELECT $1, $2, CASE WHEN u.private THEN $3 ELSE $4 END
+        FROM users u WHERE LOWER(u.email) = LOWER($2)
+        ORDER BY u.id
+        LIMIT 1
+        RETURNING status;
+    `, emailaddr.Normalize(requestor), emailaddr.Normalize(target), SubscriptionStatusPending, SubscriptionStatusApproved).Scan(&status)
 	if err != nil {
-		return errors.Wrap(err, response.ErrMsgSubscribeUpdates)
+		logger.FromContext(ctx).Debug().Err(err).Str("requestor", logger.Email(requestor)).Str("target", logger.Email(target)).Msg("Insert subscription failed")
+		return "", errors.Wrap(err, response.ErrMsgSubscribeUpdates)
 	}
-	return nil
+	return status, nil
 }
 
 // DeleteSubscription deletes a subscription from the database.
 func (repo *friendRepository) DeleteSubscription(ctx context.Context, requestorID, targetID int) error {
+	ctx, done := startOp(ctx, "DeleteSubscription")
+	defer done()
+
 	_, err := models.Subscriptions(
 		qm.Where("requestor = ? AND target = ?", requestorID, targetID),
 	).DeleteAll(ctx, repo.DB)
@@ -162,31 +210,74 @@ func (repo *friendRepository) DeleteSubscription(ctx context.Context, requestorI
 	return nil
 }
 
-// BlockUser inserts a block entry into the database.
-func (repo *friendRepository) BlockUser(ctx context.Context, requestorID, targetID int) error {
-	block := models.Block{
-		Requestor: requestorID,
-		Target:    targetID,
-	}
-	err := block.Insert(ctx, repo.DB, boil.Infer())
+// BlockUser inserts a block entry into the database. Blocking a user again replaces the details
+// of the previous block, which starts over when it had expired.
+func (repo *friendRepository) BlockUser(ctx context.Context, requestorID, targetID int, details BlockDetails) error {
+	ctx, done := startOp(ctx, "BlockUser")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        INSERT INTO blocks (requestor, target, category, reason, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (requestor, target) DO UPDATE SET
+            category = EXCLUDED.category,
+            reason = EXCLUDED.reason,
+            expires_at = EXCLUDED.expires_at,
+            created_at = CASE WHEN blocks.expires_at <= NOW() THEN NOW() ELSE blocks.created_at END;
+    `, requestorID, targetID, details.Category, details.Reason, details.ExpiresAt)
 	if err != nil {
+		logger.FromContext(ctx).Debug().Err(err).Int("requestor", requestorID).Int("target", targetID).Msg("Insert block failed")
 		return errors.Wrap(err, response.ErrMsgBlockUpdates)
 	}
 	return nil
 }
 
-// GetSubscribers retrieves the list of subscribers for a given user ID.
+// RemoveFriend deletes the friend connection between two users, in either direction.
+// It reports whether the users were friends.
+func (repo *friendRepository) RemoveFriend(ctx context.Context, userID1, userID2 int) (bool, error) {
+	ctx, done := startOp(ctx, "RemoveFriend")
+	defer done()
+
+	n, err := models.FriendConnections(
+		qm.Where("(user_id1 = ? AND user_id2 = ?) OR (user_id1 = ? AND user_id2 = ?)", userID1, userID2, userID2, userID1),
+	).DeleteAll(ctx, repo.DB)
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgRemoveFriend)
+	}
+	return n > 0, nil
+}
+
+// UnblockUser deletes a block entry from the database. It reports whether the entry existed.
+// Expired blocks are left to ExpireBlocks.
+func (repo *friendRepository) UnblockUser(ctx context.Context, requestorID, targetID int) (bool, error) {
+	ctx, done := startOp(ctx, "UnblockUser")
+	defer done()
+
+	n, err := models.Blocks(
+		qm.Where("requestor = ? AND target = ?", requestorID, targetID),
+		qm.Where("expires_at IS NULL OR expires_at > NOW()"),
+	).DeleteAll(ctx, repo.DB)
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgUnblockUser)
+	}
+	return n > 0, nil
+}
+
+// GetSubscribers retrieves the list of active subscribers for a given user ID. Pending
+// subscriptions to a private user are left out until approved.
 func (repo *friendRepository) GetSubscribers(ctx context.Context, userID int) ([]string, error) {
-	userIDStr := strconv.Itoa(userID) // Chuyển đổi userID từ int sang string
+	ctx, done := startOp(ctx, "GetSubscribers")
+	defer done()
 
 	query := `
         SELECT u.email
         FROM users u
-        JOIN subscriptions s ON u.id = CAST(s.requestor AS INTEGER)
-        WHERE s.target = $1;
+        JOIN subscriptions s ON s.requestor = u.email
+        JOIN users t ON t.email = s.target
+        WHERE t.id = $1 AND u.status = $2 AND s.status = $3;
     `
 
-	rows, err := repo.DB.QueryContext(ctx, query, userIDStr)
+	rows, err := repo.DB.QueryContext(ctx, query, userID, UserStatusActive, SubscriptionStatusApproved)
 	if err != nil {
 		return nil, errors.Wrap(err, response.ErrMsgCheckSubscription)
 	}
@@ -207,8 +298,12 @@ func (repo *friendRepository) GetSubscribers(ctx context.Context, userID int) ([
 	return subscribers, nil
 }
 
-// HasBlockedUpdates checks if the target user has blocked updates from the sender.
+// HasBlockedUpdates checks if the target user has blocked updates from the sender. Expired
+// blocks are ignored, even before ExpireBlocks deletes them.
 func (repo *friendRepository) HasBlockedUpdates(ctx context.Context, targetEmail, senderEmail string) (bool, error) {
+	ctx, done := startOp(ctx, "HasBlockedUpdates")
+	defer done()
+
 	targetUser, err := repo.GetUserByEmail(ctx, targetEmail)
 	if err != nil {
 		return false, errors.Wrap(err, response.ErrMsgGetUserByEmail)
@@ -221,6 +316,7 @@ func (repo *friendRepository) HasBlockedUpdates(ctx context.Context, targetEmail
 
 	exists, err := models.Blocks(
 		qm.Where("requestor = ? AND target = ?", targetUser.ID, senderUser.ID),
+		qm.Where("expires_at IS NULL OR expires_at > NOW()"),
 	).Exists(ctx, repo.DB)
 	if err != nil {
 		return false, errors.Wrap(err, response.ErrMsgBlockUpdates)
@@ -228,3 +324,144 @@ func (repo *friendRepository) HasBlockedUpdates(ctx context.Context, targetEmail
 
 	return exists, nil
 }
+
+// Relationship is the status of the relations between a user and a target user.
+type Relationship struct {
+	Email  string `json:"email"`
+	Target string `json:"target"`
+	// Friends reports whether the users are friends.
+	Friends bool `json:"friends"`
+	// Following reports whether the user subscribed to the updates of the target, and
+	// FollowedBy whether the target subscribed to the updates of the user.
+	Following  bool `json:"following"`
+	FollowedBy bool `json:"followed_by"`
+	// Requested reports whether the user asked to subscribe to the updates of the private
+	// target, who has yet to approve it, and RequestedBy whether the target asked the user.
+	Requested   bool `json:"requested"`
+	RequestedBy bool `json:"requested_by"`
+	// Blocking reports whether the user blocked the target, and BlockedBy whether the target
+	// blocked the user.
+	Blocking  bool `json:"blocking"`
+	BlockedBy bool `json:"blocked_by"`
+	// MutualFriends is the number of active friends the users have in common.
+	MutualFriends int `json:"mutual_friends"`
+}
+
+// GetRelationships retrieves the relationship between the viewer and each of the active targets,
+// looked up by their canonical email, with a single set-based query. Unknown and inactive targets
+// are missing from the result, which is ordered by target email.
+func (repo *friendRepository) GetRelationships(ctx context.Context, viewerID int, targets []string) ([]Relationship, error) {
+	ctx, done := startOp(ctx, "GetRelationships")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        WITH v AS (
+            SELECT id, email FROM users WHERE id = $1
+        ), t AS (
+            SELECT DISTINCT ON (LOWER(email)) id, email
+            FROM users
+            WHERE LOWER(email) = ANY($2) AND status = $3 AND id <> $1
+            ORDER BY LOWER(email), id
+        ), v_friends AS (
+            SELECT DISTINCT CASE WHEN user_id1 = $1 THEN user_id2 ELSE user_id1 END AS id
+            FROM friend_connections
+            WHERE $1 IN (user_id1, user_id2)
+        ), mutual AS (
+            SELECT t.id, COUNT(DISTINCT vf.id) AS n
+            FROM t
+            JOIN friend_connections f ON t.id IN (f.user_id1, f.user_id2)
+            JOIN v_friends vf ON vf.id = CASE WHEN f.user_id1 = t.id THEN f.user_id2 ELSE f.user_id1 END
+            JOIN users u ON u.id = vf.id AND u.status = $3
+            GROUP BY t.id
+        )
+        SELECT v.email, t.email,
+            vf.id IS NOT NULL, so.status IS NOT DISTINCT FROM $4, si.status IS NOT DISTINCT FROM $4,
+            so.status IS NOT DISTINCT FROM $5, si.status IS NOT DISTINCT FROM $5,
+            bo.id IS NOT NULL, bi.id IS NOT NULL, COALESCE(m.n, 0)
+        FROM v
+        CROSS JOIN t
+        LEFT JOIN v_friends vf ON vf.id = t.id
+        LEFT JOIN subscriptions so ON so.requestor = v.email AND so.target = t.email
+        LEFT JOIN subscriptions si ON si.requestor = t.email AND si.target = v.email
+        LEFT JOIN blocks bo ON bo.requestor = v.id AND bo.target = t.id AND (bo.expires_at IS NULL OR bo.expires_at > NOW())
+        LEFT JOIN blocks bi ON bi.requestor = t.id AND bi.target = v.id AND (bi.expires_at IS NULL OR bi.expires_at > NOW())
+        LEFT JOIN mutual m ON m.id = t.id
+        ORDER BY t.email;
+    `, viewerID, pq.Array(emailaddr.NormalizeAll(targets)), UserStatusActive,
+		SubscriptionStatusApproved, SubscriptionStatusPending)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetRelationship)
+	}
+	defer rows.Close()
+
+	relationships := []Relationship{}
+	for rows.Next() {
+		var rel Relationship
+		if err := rows.Scan(&rel.Email, &rel.Target, &rel.Friends, &rel.Following, &rel.FollowedBy,
+			&rel.Requested, &rel.RequestedBy, &rel.Blocking, &rel.BlockedBy, &rel.MutualFriends); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetRelationship)
+		}
+		relationships = append(relationships, rel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetRelationship)
+	}
+	return relationships, nil
+}
+
+// InsertOutboxEvent appends a domain event to the outbox. Call it within WithTx so the
+// event is only recorded when the change it describes is committed.
+func (repo *friendRepository) InsertOutboxEvent(ctx context.Context, event events.Event) error {
+	ctx, done := startOp(ctx, "InsertOutboxEvent")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, payload, created_at) VALUES ($1, $2, $3)`,
+		event.Type, []byte(event.Payload), event.OccurredAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgInsertOutboxEvent)
+	}
+	return nil
+}
+
+// InsertAuditEntry appends an entry to the audit log. Call it within WithTx so the entry is
+// only recorded when the change it describes is committed.
+func (repo *friendRepository) InsertAuditEntry(ctx context.Context, entry AuditEntry) error {
+	ctx, done := startOp(ctx, "InsertAuditEntry")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        INSERT INTO audit_log (actor, action, requestor, target, request_id, client_ip, before, after)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+    `, entry.Actor, entry.Action, entry.Requestor, entry.Target, entry.RequestID, entry.ClientIP,
+		[]byte(entry.Before), []byte(entry.After))
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgInsertAuditEntry)
+	}
+	return nil
+}
+
+// WithTx runs fn with a FriendRepository bound to a new database transaction, which is
+// committed when fn returns nil and rolled back otherwise. When the repository is already
+// bound to a transaction fn simply runs within it.
+func (repo *friendRepository) WithTx(ctx context.Context, fn func(repo FriendRepository) error) error {
+	if repo.conn == nil {
+		return fn(repo)
+	}
+
+	tx, err := repo.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgBeginTx)
+	}
+
+	if err := fn(&friendRepository{DB: tracing.WrapExecutor(tx)}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, response.ErrMsgCommitTx)
+	}
+	return nil
+}
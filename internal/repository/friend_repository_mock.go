@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/events"
 	"github.com/boldnguyen/friend-management/internal/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -43,9 +45,15 @@ func (m *MockRepo) GetCommonFriends(ctx context.Context, userID1, userID2 int) (
 }
 
 // SubscribeUpdates mocks the SubscribeUpdates method.
-func (m *MockRepo) SubscribeUpdates(ctx context.Context, requestor, target string) error {
+func (m *MockRepo) SubscribeUpdates(ctx context.Context, requestor, target string) (string, error) {
 	args := m.Called(ctx, requestor, target)
-	return args.Error(0)
+	return args.String(0), args.Error(1)
+}
+
+// GetFriendSuggestions mocks the GetFriendSuggestions method.
+func (m *MockRepo) GetFriendSuggestions(ctx context.Context, userID int, limit, offset int) ([]Suggestion, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]Suggestion), args.Error(1)
 }
 
 // CheckSubscription mocks the CheckSubscription method.
@@ -54,6 +62,18 @@ func (m *MockRepo) CheckSubscription(ctx context.Context, requestor, target stri
 	return args.Bool(0), args.Error(1)
 }
 
+// RemoveFriend mocks the RemoveFriend method.
+func (m *MockRepo) RemoveFriend(ctx context.Context, userID1, userID2 int) (bool, error) {
+	args := m.Called(ctx, userID1, userID2)
+	return args.Bool(0), args.Error(1)
+}
+
+// UnblockUser mocks the UnblockUser method.
+func (m *MockRepo) UnblockUser(ctx context.Context, requestorID, targetID int) (bool, error) {
+	args := m.Called(ctx, requestorID, targetID)
+	return args.Bool(0), args.Error(1)
+}
+
 // DeleteSubscription mocks the DeleteSubscription method.
 func (m *MockRepo) DeleteSubscription(ctx context.Context, requestorID, targetID int) error {
 	args := m.Called(ctx, requestorID, targetID)
@@ -61,8 +81,8 @@ func (m *MockRepo) DeleteSubscription(ctx context.Context, requestorID, targetID
 }
 
 // BlockUser mocks the BlockUser method.
-func (m *MockRepo) BlockUser(ctx context.Context, requestorID, targetID int) error {
-	args := m.Called(ctx, requestorID, targetID)
+func (m *MockRepo) BlockUser(ctx context.Context, requestorID, targetID int, details BlockDetails) error {
+	args := m.Called(ctx, requestorID, targetID, details)
 	return args.Error(0)
 }
 
@@ -77,3 +97,92 @@ func (m *MockRepo) HasBlockedUpdates(ctx context.Context, targetEmail, senderEma
 	args := m.Called(ctx, targetEmail, senderEmail)
 	return args.Bool(0), args.Error(1)
 }
+
+// ExpireBlocks mocks the ExpireBlocks method.
+func (m *MockRepo) ExpireBlocks(ctx context.Context, limit int) ([]ExpiredBlock, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ExpiredBlock), args.Error(1)
+}
+
+// GetRelationships mocks the GetRelationships method.
+func (m *MockRepo) GetRelationships(ctx context.Context, viewerID int, targets []string) ([]Relationship, error) {
+	args := m.Called(ctx, viewerID, targets)
+	return args.Get(0).([]Relationship), args.Error(1)
+}
+
+// SetUserPrivacy mocks the SetUserPrivacy method.
+func (m *MockRepo) SetUserPrivacy(ctx context.Context, userID int, private bool, policy string) (int, error) {
+	args := m.Called(ctx, userID, private, policy)
+	return args.Int(0), args.Error(1)
+}
+
+// ListSubscriptionRequests mocks the ListSubscriptionRequests method.
+func (m *MockRepo) ListSubscriptionRequests(ctx context.Context, userID int, limit, offset int) ([]SubscriptionRequest, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SubscriptionRequest), args.Error(1)
+}
+
+// ApproveSubscription mocks the ApproveSubscription method.
+func (m *MockRepo) ApproveSubscription(ctx context.Context, requestor, target string) (bool, error) {
+	args := m.Called(ctx, requestor, target)
+	return args.Bool(0), args.Error(1)
+}
+
+// DenySubscription mocks the DenySubscription method.
+func (m *MockRepo) DenySubscription(ctx context.Context, requestor, target string) (bool, error) {
+	args := m.Called(ctx, requestor, target)
+	return args.Bool(0), args.Error(1)
+}
+
+// MuteUser mocks the MuteUser method.
+func (m *MockRepo) MuteUser(ctx context.Context, requestorID, targetID int, expiresAt *time.Time) error {
+	args := m.Called(ctx, requestorID, targetID, expiresAt)
+	return args.Error(0)
+}
+
+// UnmuteUser mocks the UnmuteUser method.
+func (m *MockRepo) UnmuteUser(ctx context.Context, requestorID, targetID int) (bool, error) {
+	args := m.Called(ctx, requestorID, targetID)
+	return args.Bool(0), args.Error(1)
+}
+
+// GetMutedBy mocks the GetMutedBy method.
+func (m *MockRepo) GetMutedBy(ctx context.Context, senderID int) ([]string, error) {
+	args := m.Called(ctx, senderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// ListMutes mocks the ListMutes method.
+func (m *MockRepo) ListMutes(ctx context.Context, userID int) ([]Mute, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Mute), args.Error(1)
+}
+
+// InsertOutboxEvent mocks the InsertOutboxEvent method.
+func (m *MockRepo) InsertOutboxEvent(ctx context.Context, event events.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// InsertAuditEntry mocks the InsertAuditEntry method.
+func (m *MockRepo) InsertAuditEntry(ctx context.Context, entry AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+// WithTx mocks the WithTx method by running fn against the mock itself.
+func (m *MockRepo) WithTx(ctx context.Context, fn func(repo FriendRepository) error) error {
+	return fn(m)
+}
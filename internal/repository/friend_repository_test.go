@@ -413,7 +413,7 @@ func TestFriendRepository_SubscribeUpdates(t *testing.T) {
 			repo := friendRepository{DB: tx}
 
 			// When
-			err = repo.SubscribeUpdates(ctx, tc.requestor, tc.target)
+			_, err = repo.SubscribeUpdates(ctx, tc.requestor, tc.target)
 
 			// Rollback transaction
 			require.NoError(t, tx.Rollback())
@@ -540,13 +540,13 @@ func TestFriendRepository_BlockUpdates(t *testing.T) {
 			mockRepo.On("GetUserByEmail", ctx, tc.target).Return(&models.User{ID: 2}, nil)
 
 			// Mock BlockUser method behavior
-			mockRepo.On("BlockUser", ctx, 1, 2).Return(tc.expectedErr)
+			mockRepo.On("BlockUser", ctx, 1, 2, BlockDetails{}).Return(tc.expectedErr)
 
 			// Initialize repository with the mock and transaction
 			repo := friendRepository{DB: tx}
 
 			// When
-			err = repo.BlockUser(ctx, 1, 2)
+			err = repo.BlockUser(ctx, 1, 2, BlockDetails{Category: BlockCategoryOther})
 
 			// Rollback transaction
 			require.NoError(t, tx.Rollback())
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// GetFriendRefs retrieves the active friends of each of the given users in a single query,
+// ordered by ID. Friendships between users where either one blocked the other are left out,
+// so walking the result never crosses a block.
+func (repo *graphRepository) GetFriendRefs(ctx context.Context, userIDs []int) (map[int][]UserRef, error) {
+	ctx, done := startOp(ctx, "GetFriendRefs")
+	defer done()
+
+	ids := make([]int64, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        WITH edges AS (
+            SELECT user_id1 AS id, user_id2 AS friend_id FROM friend_connections WHERE user_id1 = ANY($1)
+            UNION ALL
+            SELECT user_id2, user_id1 FROM friend_connections WHERE user_id2 = ANY($1)
+        )
+        SELECT e.id, u.id, u.email
+        FROM edges e
+        JOIN users u ON u.id = e.friend_id AND u.status = $2
+        WHERE NOT EXISTS (
+            SELECT 1 FROM blocks b
+            WHERE ((b.requestor = e.id AND b.target = e.friend_id) OR (b.requestor = e.friend_id AND b.target = e.id))
+              AND (b.expires_at IS NULL OR b.expires_at > NOW())
+        )
+        ORDER BY e.id, u.id;
+    `, pq.Array(ids), UserStatusActive)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetFriendsList)
+	}
+	defer rows.Close()
+
+	friends := make(map[int][]UserRef, len(userIDs))
+	for rows.Next() {
+		var (
+			userID int
+			friend UserRef
+		)
+		if err := rows.Scan(&userID, &friend.ID, &friend.Email); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetFriendsList)
+		}
+		friends[userID] = append(friends[userID], friend)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetFriendsList)
+	}
+	return friends, nil
+}
+
+// HasBlock reports whether either of the two users blocked the other. Expired blocks are ignored.
+func (repo *graphRepository) HasBlock(ctx context.Context, userID1, userID2 int) (bool, error) {
+	ctx, done := startOp(ctx, "HasBlock")
+	defer done()
+
+	var blocked bool
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE ((requestor = $1 AND target = $2) OR (requestor = $2 AND target = $1))
+              AND (expires_at IS NULL OR expires_at > NOW())
+        );
+    `, userID1, userID2).Scan(&blocked)
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgBlockUpdates)
+	}
+	return blocked, nil
+}
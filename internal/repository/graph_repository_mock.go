@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGraphRepo is a mock implementation of GraphRepository for testing purposes.
+type MockGraphRepo struct {
+	mock.Mock
+}
+
+// GetFriendRefs mocks the GetFriendRefs method.
+func (m *MockGraphRepo) GetFriendRefs(ctx context.Context, userIDs []int) (map[int][]UserRef, error) {
+	args := m.Called(ctx, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int][]UserRef), args.Error(1)
+}
+
+// HasBlock mocks the HasBlock method.
+func (m *MockGraphRepo) HasBlock(ctx context.Context, userID1, userID2 int) (bool, error) {
+	args := m.Called(ctx, userID1, userID2)
+	return args.Bool(0), args.Error(1)
+}
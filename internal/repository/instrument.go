@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/metrics"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+)
+
+// startOp starts a span for the repository method and returns a function that ends it
+// and records the method latency. It is meant to be called at the top of every method:
+//
+//	ctx, done := startOp(ctx, "GetUserByEmail")
+//	defer done()
+func startOp(ctx context.Context, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "FriendRepository."+method)
+	return ctx, func() {
+		span.End()
+		metrics.ObserveQuery(method, start)
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// Mute is a user muted by another user. The muting user stops receiving the updates of the
+// muted one, nothing else changes between them.
+type Mute struct {
+	Target string `json:"target"`
+	// ExpiresAt is nil for a mute lasting until the user is unmuted.
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MuteUser mutes the target for the requestor until expiresAt, or until unmuted when expiresAt
+// is nil. Muting a user again replaces the expiry of the previous mute.
+func (repo *friendRepository) MuteUser(ctx context.Context, requestorID, targetID int, expiresAt *time.Time) error {
+	ctx, done := startOp(ctx, "MuteUser")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        INSERT INTO mutes (requestor, target, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (requestor, target) DO UPDATE SET expires_at = EXCLUDED.expires_at, created_at = NOW();
+    `, requestorID, targetID, expiresAt)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgMuteUser)
+	}
+	return nil
+}
+
+// UnmuteUser deletes the mute of the target by the requestor. It reports whether the mute was
+// still active, expired mutes are deleted as well.
+func (repo *friendRepository) UnmuteUser(ctx context.Context, requestorID, targetID int) (bool, error) {
+	ctx, done := startOp(ctx, "UnmuteUser")
+	defer done()
+
+	var active bool
+	err := repo.DB.QueryRowContext(ctx, `
+        WITH deleted AS (
+            DELETE FROM mutes WHERE requestor = $1 AND target = $2
+            RETURNING expires_at
+        )
+        SELECT EXISTS (SELECT 1 FROM deleted WHERE expires_at IS NULL OR expires_at > NOW());
+    `, requestorID, targetID).Scan(&active)
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgUnmuteUser)
+	}
+	return active, nil
+}
+
+// GetMutedBy retrieves the emails of the users currently muting a given sender ID.
+func (repo *friendRepository) GetMutedBy(ctx context.Context, senderID int) ([]string, error) {
+	ctx, done := startOp(ctx, "GetMutedBy")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT u.email
+        FROM mutes m
+        JOIN users u ON u.id = m.requestor
+        WHERE m.target = $1 AND (m.expires_at IS NULL OR m.expires_at > NOW());
+    `, senderID)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+		}
+		emails = append(emails, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+	}
+	return emails, nil
+}
+
+// ListMutes retrieves the users currently muted by a given user ID, most recent first.
+func (repo *friendRepository) ListMutes(ctx context.Context, userID int) ([]Mute, error) {
+	ctx, done := startOp(ctx, "ListMutes")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT u.email, m.expires_at, m.created_at
+        FROM mutes m
+        JOIN users u ON u.id = m.target
+        WHERE m.requestor = $1 AND (m.expires_at IS NULL OR m.expires_at > NOW())
+        ORDER BY m.created_at DESC, m.id DESC;
+    `, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+	}
+	defer rows.Close()
+
+	mutes := []Mute{}
+	for rows.Next() {
+		var mute Mute
+		if err := rows.Scan(&mute.Target, &mute.ExpiresAt, &mute.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+		}
+		mutes = append(mutes, mute)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+	}
+	return mutes, nil
+}
@@ -3,8 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/events"
 	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
@@ -15,20 +18,212 @@ type FriendRepository interface {
 	CheckFriends(ctx context.Context, userID1, userID2 int) (bool, error)
 	GetFriendsList(ctx context.Context, userID int) ([]string, error)
 	GetCommonFriends(ctx context.Context, userID1, userID2 int) ([]string, error)
+	GetFriendSuggestions(ctx context.Context, userID int, limit, offset int) ([]Suggestion, error)
 	CheckSubscription(ctx context.Context, requestor, target string) (bool, error)
-	SubscribeUpdates(ctx context.Context, requestor, target string) error
+	SubscribeUpdates(ctx context.Context, requestor, target string) (string, error)
 	DeleteSubscription(ctx context.Context, requestorID, targetID int) error
-	BlockUser(ctx context.Context, requestorID, targetID int) error
+	BlockUser(ctx context.Context, requestorID, targetID int, details BlockDetails) error
+	RemoveFriend(ctx context.Context, userID1, userID2 int) (bool, error)
+	UnblockUser(ctx context.Context, requestorID, targetID int) (bool, error)
 	GetSubscribers(ctx context.Context, userID int) ([]string, error)
 	HasBlockedUpdates(ctx context.Context, targetEmail, senderEmail string) (bool, error)
+	ExpireBlocks(ctx context.Context, limit int) ([]ExpiredBlock, error)
+	GetRelationships(ctx context.Context, viewerID int, targets []string) ([]Relationship, error)
+	SetUserPrivacy(ctx context.Context, userID int, private bool, policy string) (int, error)
+	ListSubscriptionRequests(ctx context.Context, userID int, limit, offset int) ([]SubscriptionRequest, error)
+	ApproveSubscription(ctx context.Context, requestor, target string) (bool, error)
+	DenySubscription(ctx context.Context, requestor, target string) (bool, error)
+	MuteUser(ctx context.Context, requestorID, targetID int, expiresAt *time.Time) error
+	UnmuteUser(ctx context.Context, requestorID, targetID int) (bool, error)
+	GetMutedBy(ctx context.Context, senderID int) ([]string, error)
+	ListMutes(ctx context.Context, userID int) ([]Mute, error)
+	InsertOutboxEvent(ctx context.Context, event events.Event) error
+	InsertAuditEntry(ctx context.Context, entry AuditEntry) error
+	WithTx(ctx context.Context, fn func(repo FriendRepository) error) error
 }
 
 // friendRepository implements the FriendRepository interface.
 type friendRepository struct {
 	DB boil.ContextExecutor
+	// conn is used to begin transactions. It is nil when DB is already a transaction.
+	conn *sql.DB
 }
 
 // NewFriendRepository creates a new instance of FriendRepository.
+// Every SQL statement run by the repository is traced.
 func NewFriendRepository(db *sql.DB) FriendRepository {
-	return &friendRepository{DB: db}
+	return &friendRepository{DB: tracing.WrapExecutor(db), conn: db}
+}
+
+// OutboxRepository provides methods for relaying the domain events stored in the outbox.
+type OutboxRepository interface {
+	ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]PendingEvent, error)
+	MarkEventPublished(ctx context.Context, id int64) error
+	MarkEventFailed(ctx context.Context, id int64, cause string, nextAttempt time.Time) error
+}
+
+// outboxRepository implements the OutboxRepository interface.
+type outboxRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewOutboxRepository creates a new instance of OutboxRepository.
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{DB: tracing.WrapExecutor(db)}
+}
+
+// WebhookRepository provides methods for managing webhooks and their deliveries.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *Webhook) error
+	GetWebhook(ctx context.Context, id int) (*Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	EnqueueWebhookDelivery(ctx context.Context, webhookID int, event events.Event) error
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueWebhookDelivery, error)
+	MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error
+	MarkWebhookDeliveryFailed(ctx context.Context, id int64, statusCode int, cause string, nextAttempt time.Time) error
+	DeadLetterWebhookDelivery(ctx context.Context, id int64, statusCode int, cause string) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]WebhookDelivery, error)
+	ListWebhookDeadLetters(ctx context.Context, webhookID int, limit, offset int) ([]WebhookDeadLetter, error)
+}
+
+// webhookRepository implements the WebhookRepository interface.
+type webhookRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository.
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &webhookRepository{DB: tracing.WrapExecutor(db)}
+}
+
+// UpdateRepository provides methods for storing updates and delivering them to inboxes.
+type UpdateRepository interface {
+	CreateUpdate(ctx context.Context, senderID int, text string) (*Update, error)
+	GetUpdate(ctx context.Context, id int64) (*Update, error)
+	CountAudience(ctx context.Context, userID int) (int, error)
+	DeliverToInboxes(ctx context.Context, updateID int64, recipients []string) (int, error)
+	MarkUpdateFannedOut(ctx context.Context, id int64, recipientCount int) error
+	MarkUpdateFanoutFailed(ctx context.Context, id int64) error
+	ListPendingUpdates(ctx context.Context, before time.Time, limit int) ([]int64, error)
+	GetInbox(ctx context.Context, userID int, limit, offset int) ([]InboxItem, error)
+}
+
+// updateRepository implements the UpdateRepository interface.
+type updateRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewUpdateRepository creates a new instance of UpdateRepository.
+func NewUpdateRepository(db *sql.DB) UpdateRepository {
+	return &updateRepository{DB: tracing.WrapExecutor(db)}
+}
+
+// UserRepository provides methods for administering the users.
+type UserRepository interface {
+	CreateUser(ctx context.Context, name, email string) (*models.User, error)
+	SetUserStatus(ctx context.Context, email, status string) (*models.User, error)
+	PurgeDeletedUsers(ctx context.Context, before time.Time, limit int) (int, error)
+	GetUserArchive(ctx context.Context, email string) (*UserArchive, error)
+	EraseUser(ctx context.Context, email string, erasure *Erasure) error
+	ListDuplicateEmails(ctx context.Context) ([]DuplicateEmail, error)
+}
+
+// userRepository implements the UserRepository interface.
+type userRepository struct {
+	DB boil.ContextExecutor
+	// conn is used to begin the erasure transactions.
+	conn *sql.DB
+}
+
+// NewUserRepository creates a new instance of UserRepository.
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{DB: tracing.WrapExecutor(db), conn: db}
+}
+
+// BulkRepository provides methods for importing and exporting the relations between users.
+type BulkRepository interface {
+	ResolveUsers(ctx context.Context, emails []string) (map[string]UserRef, error)
+	ImportRelations(ctx context.Context, relations []ImportRelation) ([]int, error)
+	ExportRelations(ctx context.Context, user *UserRef, fn func(Relation) error) error
+}
+
+// bulkRepository implements the BulkRepository interface.
+type bulkRepository struct {
+	DB boil.ContextExecutor
+	// conn is used to begin the import transactions.
+	conn *sql.DB
+}
+
+// NewBulkRepository creates a new instance of BulkRepository.
+func NewBulkRepository(db *sql.DB) BulkRepository {
+	return &bulkRepository{DB: tracing.WrapExecutor(db), conn: db}
+}
+
+// AuditRepository provides methods for querying the audit log.
+type AuditRepository interface {
+	ListAuditEntries(ctx context.Context, filter AuditFilter, limit, offset int) ([]AuditEntry, error)
+}
+
+// auditRepository implements the AuditRepository interface.
+type auditRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewAuditRepository creates a new instance of AuditRepository.
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{DB: tracing.WrapExecutor(db)}
+}
+
+// GraphRepository provides methods for querying the friendship graph.
+type GraphRepository interface {
+	GetFriendRefs(ctx context.Context, userIDs []int) (map[int][]UserRef, error)
+	HasBlock(ctx context.Context, userID1, userID2 int) (bool, error)
+}
+
+// graphRepository implements the GraphRepository interface.
+type graphRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewGraphRepository creates a new instance of GraphRepository.
+func NewGraphRepository(db *sql.DB) GraphRepository {
+	return &graphRepository{DB: tracing.WrapExecutor(db)}
+}
+
+// AnalyticsRepository provides methods for computing and storing aggregate statistics on the social graph.
+type AnalyticsRepository interface {
+	GetGraphTotals(ctx context.Context) (GraphTotals, error)
+	GetDegreeDistribution(ctx context.Context) ([]DegreeCount, error)
+	GetMostFollowed(ctx context.Context, limit int) ([]UserCount, error)
+	GetMostBlocked(ctx context.Context, limit int) ([]UserCount, error)
+	ListFriendEdges(ctx context.Context, fn func(userID1, userID2 int) error) error
+	InsertAnalyticsSnapshot(ctx context.Context, report *AnalyticsReport) error
+	GetLatestAnalyticsSnapshot(ctx context.Context) (*AnalyticsReport, error)
+}
+
+// analyticsRepository implements the AnalyticsRepository interface.
+type analyticsRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewAnalyticsRepository creates a new instance of AnalyticsRepository.
+func NewAnalyticsRepository(db *sql.DB) AnalyticsRepository {
+	return &analyticsRepository{DB: tracing.WrapExecutor(db)}
+}
+
+// StatsRepository provides methods for reading and repairing the counters of the relations of the users.
+type StatsRepository interface {
+	GetUserStats(ctx context.Context, email string) (*UserStats, error)
+	ReconcileUserStats(ctx context.Context) (int, error)
+}
+
+// statsRepository implements the StatsRepository interface.
+type statsRepository struct {
+	DB boil.ContextExecutor
+}
+
+// NewStatsRepository creates a new instance of StatsRepository.
+func NewStatsRepository(db *sql.DB) StatsRepository {
+	return &statsRepository{DB: tracing.WrapExecutor(db)}
 }
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// PendingEvent is an outbox event waiting to be published.
+type PendingEvent struct {
+	events.Event
+	// Attempts is the number of failed publish attempts so far.
+	Attempts int
+}
+
+// ClaimPendingEvents claims up to limit unpublished events that are due, oldest first.
+// Claimed events are hidden from other relays for the lease duration, so an event whose
+// relay crashed before marking it is published again once the lease expires.
+func (repo *outboxRepository) ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]PendingEvent, error) {
+	ctx, done := startOp(ctx, "ClaimPendingEvents")
+	defer done()
+
+	query := `
+        UPDATE outbox
+        SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+        WHERE id IN (
+            SELECT id
+            FROM outbox
+            WHERE published_at IS NULL AND next_attempt_at <= NOW()
+            ORDER BY id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, event_type, payload, created_at, attempts;
+    `
+
+	rows, err := repo.DB.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgClaimOutboxEvents)
+	}
+	defer rows.Close()
+
+	var pending []PendingEvent
+	for rows.Next() {
+		var (
+			evt     PendingEvent
+			payload []byte
+		)
+		if err := rows.Scan(&evt.ID, &evt.Type, &payload, &evt.OccurredAt, &evt.Attempts); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgClaimOutboxEvents)
+		}
+		evt.Payload = payload
+		pending = append(pending, evt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgClaimOutboxEvents)
+	}
+
+	return pending, nil
+}
+
+// MarkEventPublished records that the event was delivered to every sink.
+func (repo *outboxRepository) MarkEventPublished(ctx context.Context, id int64) error {
+	ctx, done := startOp(ctx, "MarkEventPublished")
+	defer done()
+
+	res, err := repo.DB.ExecContext(ctx,
+		`UPDATE outbox SET published_at = NOW(), last_error = NULL WHERE id = $1`, id)
+	return checkOutboxUpdate(res, err, id)
+}
+
+// MarkEventFailed records a failed publish attempt and schedules the next one.
+func (repo *outboxRepository) MarkEventFailed(ctx context.Context, id int64, cause string, nextAttempt time.Time) error {
+	ctx, done := startOp(ctx, "MarkEventFailed")
+	defer done()
+
+	res, err := repo.DB.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		id, cause, nextAttempt)
+	return checkOutboxUpdate(res, err, id)
+}
+
+// checkOutboxUpdate wraps the error of an outbox update and checks the event existed.
+func checkOutboxUpdate(res sql.Result, err error, id int64) error {
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgUpdateOutboxEvent)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Wrap(fmt.Errorf("event %d not found", id), response.ErrMsgUpdateOutboxEvent)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOutboxRepo is a mock implementation of OutboxRepository for testing purposes.
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+// ClaimPendingEvents mocks the ClaimPendingEvents method.
+func (m *MockOutboxRepo) ClaimPendingEvents(ctx context.Context, limit int, lease time.Duration) ([]PendingEvent, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]PendingEvent), args.Error(1)
+}
+
+// MarkEventPublished mocks the MarkEventPublished method.
+func (m *MockOutboxRepo) MarkEventPublished(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MarkEventFailed mocks the MarkEventFailed method.
+func (m *MockOutboxRepo) MarkEventFailed(ctx context.Context, id int64, cause string, nextAttempt time.Time) error {
+	args := m.Called(ctx, id, cause, nextAttempt)
+	return args.Error(0)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// UserStats holds the counters of the relations of a user. Relations with inactive users are counted.
+type UserStats struct {
+	Email   string `json:"email"`
+	Friends int    `json:"friends"`
+	// Followers is the number of users subscribed to the updates of the user, and Following
+	// the number of users whose updates the user subscribed to. Pending subscriptions are
+	// not counted.
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+	// Blocked is the number of users the user blocked.
+	Blocked   int       `json:"blocked"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetUserStats retrieves the stored counters of an active user, looked up by their canonical
+// email. It returns nil when the user is not found.
+func (repo *statsRepository) GetUserStats(ctx context.Context, email string) (*UserStats, error) {
+	ctx, done := startOp(ctx, "GetUserStats")
+	defer done()
+
+	var stats UserStats
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT u.email, COALESCE(s.friends, 0), COALESCE(s.followers, 0), COALESCE(s.following, 0),
+            COALESCE(s.blocked, 0), COALESCE(s.updated_at, u.created_at)
+        FROM users u
+        LEFT JOIN user_stats s ON s.user_id = u.id
+        WHERE LOWER(u.email) = $1 AND u.status = $2
+        ORDER BY u.id
+        LIMIT 1;
+    `, emailaddr.Normalize(email), UserStatusActive).Scan(
+		&stats.Email, &stats.Friends, &stats.Followers, &stats.Following, &stats.Blocked, &stats.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetUserStats)
+	}
+	return &stats, nil
+}
+
+// ReconcileUserStats recounts the relations of every user and repairs the counters that drifted,
+// or are missing, returning how many were repaired. Counters of relations changed while the
+// reconciliation runs may be left off until the next one.
+func (repo *statsRepository) ReconcileUserStats(ctx context.Context) (int, error) {
+	ctx, done := startOp(ctx, "ReconcileUserStats")
+	defer done()
+
+	var repaired int
+	err := repo.DB.QueryRowContext(ctx, `
+        WITH friends AS (
+            SELECT id, COUNT(*) AS n
+            FROM (SELECT user_id1 AS id FROM friend_connections UNION ALL SELECT user_id2 FROM friend_connections) f
+            GROUP BY id
+        ), followers AS (
+            SELECT u.id, COUNT(*) AS n FROM subscriptions s JOIN users u ON u.email = s.target WHERE s.status = $1 GROUP BY u.id
+        ), following AS (
+            SELECT u.id, COUNT(*) AS n FROM subscriptions s JOIN users u ON u.email = s.requestor WHERE s.status = $1 GROUP BY u.id
+        ), blocked AS (
+            SELECT requestor AS id, COUNT(*) AS n FROM blocks GROUP BY requestor
+        ), actual AS (
+            SELECT u.id AS user_id, COALESCE(fr.n, 0) AS friends, COALESCE(fo.n, 0) AS followers,
+                COALESCE(fg.n, 0) AS following, COALESCE(b.n, 0) AS blocked
+            FROM users u
+            LEFT JOIN friends fr ON fr.id = u.id
+            LEFT JOIN followers fo ON fo.id = u.id
+            LEFT JOIN following fg ON fg.id = u.id
+            LEFT JOIN blocked b ON b.id = u.id
+        ), repaired AS (
+            INSERT INTO user_stats (user_id, friends, followers, following, blocked)
+            SELECT a.user_id, a.friends, a.followers, a.following, a.blocked
+            FROM actual a
+            LEFT JOIN user_stats s ON s.user_id = a.user_id
+            WHERE s.user_id IS NULL
+               OR (s.friends, s.followers, s.following, s.blocked) IS DISTINCT FROM (a.friends, a.followers, a.following, a.blocked)
+            ON CONFLICT (user_id) DO UPDATE SET
+                friends = EXCLUDED.friends,
+                followers = EXCLUDED.followers,
+                following = EXCLUDED.following,
+                blocked = EXCLUDED.blocked,
+                updated_at = NOW()
+            RETURNING user_id
+        )
+        SELECT COUNT(*) FROM repaired;
+    `, SubscriptionStatusApproved).Scan(&repaired)
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgReconcileUserStats)
+	}
+	return repaired, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStatsRepo is a mock implementation of StatsRepository for testing purposes.
+type MockStatsRepo struct {
+	mock.Mock
+}
+
+// GetUserStats mocks the GetUserStats method.
+func (m *MockStatsRepo) GetUserStats(ctx context.Context, email string) (*UserStats, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserStats), args.Error(1)
+}
+
+// ReconcileUserStats mocks the ReconcileUserStats method.
+func (m *MockStatsRepo) ReconcileUserStats(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
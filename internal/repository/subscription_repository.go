@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// Subscription states. Subscriptions to a private user are pending until the user approves
+// them, only approved subscribers receive the updates.
+const (
+	SubscriptionStatusApproved = "approved"
+	SubscriptionStatusPending  = "pending"
+)
+
+// Policies applied to the existing subscribers of a user whose account becomes private.
+const (
+	// FollowerPolicyKeep keeps the existing subscriptions approved.
+	FollowerPolicyKeep = "keep"
+	// FollowerPolicyReview turns the existing subscriptions into requests to approve again.
+	FollowerPolicyReview = "review"
+	// FollowerPolicyRemove deletes the existing subscriptions.
+	FollowerPolicyRemove = "remove"
+)
+
+// SubscriptionRequest is a pending subscription to the updates of a private user.
+type SubscriptionRequest struct {
+	Requestor string    `json:"requestor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetUserPrivacy makes the user private or public and returns how many of the subscriptions to
+// the user changed as a result. Making the user public approves the pending subscriptions, making
+// them private applies the policy to the approved ones. Nothing changes when the user already has
+// the requested privacy.
+func (repo *friendRepository) SetUserPrivacy(ctx context.Context, userID int, private bool, policy string) (int, error) {
+	ctx, done := startOp(ctx, "SetUserPrivacy")
+	defer done()
+
+	var changed int
+	err := repo.DB.QueryRowContext(ctx, `
+        WITH u AS (
+            UPDATE users SET private = $2, updated_at = NOW()
+            WHERE id = $1 AND private <> $2
+            RETURNING email
+        ), approved AS (
+            UPDATE subscriptions s SET status = $4
+            FROM u
+            WHERE NOT $2 AND s.target = u.email AND s.status = $5
+            RETURNING s.id
+        ), reviewed AS (
+            UPDATE subscriptions s SET status = $5
+            FROM u
+            WHERE $2 AND $3::TEXT = $6 AND s.target = u.email AND s.status = $4
+            RETURNING s.id
+        ), removed AS (
+            DELETE FROM subscriptions s
+            USING u
+            WHERE $2 AND $3::TEXT = $7 AND s.target = u.email
+            RETURNING s.id
+        )
+        SELECT (SELECT COUNT(*) FROM approved) + (SELECT COUNT(*) FROM reviewed) + (SELECT COUNT(*) FROM removed);
+    `, userID, private, policy, SubscriptionStatusApproved, SubscriptionStatusPending,
+		FollowerPolicyReview, FollowerPolicyRemove).Scan(&changed)
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgSetPrivacy)
+	}
+	return changed, nil
+}
+
+// ListSubscriptionRequests retrieves the pending subscriptions of active users to the updates of
+// a given user ID, oldest first.
+func (repo *friendRepository) ListSubscriptionRequests(ctx context.Context, userID int, limit, offset int) ([]SubscriptionRequest, error) {
+	ctx, done := startOp(ctx, "ListSubscriptionRequests")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT s.requestor, s.created_at
+        FROM subscriptions s
+        JOIN users t ON t.email = s.target
+        JOIN users r ON r.email = s.requestor AND r.status = $2
+        WHERE t.id = $1 AND s.status = $3
+        ORDER BY s.created_at, s.id
+        LIMIT $4 OFFSET $5;
+    `, userID, UserStatusActive, SubscriptionStatusPending, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetSubscriptionRequests)
+	}
+	defer rows.Close()
+
+	requests := []SubscriptionRequest{}
+	for rows.Next() {
+		var req SubscriptionRequest
+		if err := rows.Scan(&req.Requestor, &req.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetSubscriptionRequests)
+		}
+		requests = append(requests, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetSubscriptionRequests)
+	}
+	return requests, nil
+}
+
+// ApproveSubscription approves the pending subscription of the requestor to the target. It reports
+// whether the subscription was pending.
+func (repo *friendRepository) ApproveSubscription(ctx context.Context, requestor, target string) (bool, error) {
+	ctx, done := startOp(ctx, "ApproveSubscription")
+	defer done()
+
+	res, err := repo.DB.ExecContext(ctx, `
+        UPDATE subscriptions SET status = $3
+        WHERE requestor = $1 AND target = $2 AND status = $4;
+    `, emailaddr.Normalize(requestor), emailaddr.Normalize(target), SubscriptionStatusApproved, SubscriptionStatusPending)
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgApproveSubscription)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgApproveSubscription)
+	}
+	return n > 0, nil
+}
+
+// DenySubscription deletes the pending subscription of the requestor to the target. It reports
+// whether the subscription was pending.
+func (repo *friendRepository) DenySubscription(ctx context.Context, requestor, target string) (bool, error) {
+	ctx, done := startOp(ctx, "DenySubscription")
+	defer done()
+
+	res, err := repo.DB.ExecContext(ctx, `
+        DELETE FROM subscriptions
+        WHERE requestor = $1 AND target = $2 AND status = $3;
+    `, emailaddr.Normalize(requestor), emailaddr.Normalize(target), SubscriptionStatusPending)
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgDenySubscription)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, response.ErrMsgDenySubscription)
+	}
+	return n > 0, nil
+}
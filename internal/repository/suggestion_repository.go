@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// maxExplainedMutualFriends is the number of mutual friends listed with a suggestion.
+const maxExplainedMutualFriends = 10
+
+// Suggestion is a user who is not a friend of the user yet but shares friends with them.
+type Suggestion struct {
+	Email string `json:"email"`
+	// MutualCount is the number of friends the user and the suggested user have in common.
+	MutualCount int `json:"mutual_count"`
+	// MutualFriends lists the first mutual friends in alphabetical order, explaining the suggestion.
+	MutualFriends []string `json:"mutual_friends"`
+}
+
+// GetFriendSuggestions ranks the friends of the friends of a user who are not their friends yet by
+// their number of mutual friends. Users involved in a block with the user, either way, are never
+// suggested, and inactive users are neither suggested nor counted as mutual friends.
+func (repo *friendRepository) GetFriendSuggestions(ctx context.Context, userID int, limit, offset int) ([]Suggestion, error) {
+	ctx, done := startOp(ctx, "GetFriendSuggestions")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        WITH friends AS (
+            SELECT CASE WHEN user_id1 = $1 THEN user_id2 ELSE user_id1 END AS id
+            FROM friend_connections
+            WHERE user_id1 = $1 OR user_id2 = $1
+        ), candidates AS (
+            SELECT CASE WHEN fc.user_id1 = f.id THEN fc.user_id2 ELSE fc.user_id1 END AS id, f.id AS via
+            FROM friends f
+            JOIN friend_connections fc ON fc.user_id1 = f.id OR fc.user_id2 = f.id
+        )
+        SELECT u.email, COUNT(DISTINCT m.id), (ARRAY_AGG(DISTINCT m.email ORDER BY m.email))[1:$5]
+        FROM candidates c
+        JOIN users u ON u.id = c.id AND u.status = $4
+        JOIN users m ON m.id = c.via AND m.status = $4
+        WHERE c.id <> $1
+          AND c.id NOT IN (SELECT id FROM friends)
+          AND NOT EXISTS (
+              SELECT 1 FROM blocks b
+              WHERE ((b.requestor = $1 AND b.target = c.id) OR (b.requestor = c.id AND b.target = $1))
+                AND (b.expires_at IS NULL OR b.expires_at > NOW())
+          )
+        GROUP BY u.id, u.email
+        ORDER BY COUNT(DISTINCT m.id) DESC, u.email
+        LIMIT $2 OFFSET $3
+    `, userID, limit, offset, UserStatusActive, maxExplainedMutualFriends)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetSuggestions)
+	}
+	defer rows.Close()
+
+	suggestions := []Suggestion{}
+	for rows.Next() {
+		var s Suggestion
+		if err := rows.Scan(&s.Email, &s.MutualCount, pq.Array(&s.MutualFriends)); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetSuggestions)
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetSuggestions)
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Update fan-out statuses.
+const (
+	FanoutStatusPending = "pending"
+	FanoutStatusDone    = "done"
+	FanoutStatusFailed  = "failed"
+)
+
+// Update is a post sent by a user to the users eligible to receive it.
+type Update struct {
+	ID             int64     `json:"id"`
+	SenderID       int       `json:"-"`
+	Sender         string    `json:"sender"`
+	Text           string    `json:"text"`
+	FanoutStatus   string    `json:"fanout_status"`
+	RecipientCount int       `json:"recipient_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// InboxItem is an update delivered to a user's inbox.
+type InboxItem struct {
+	UpdateID    int64     `json:"update_id"`
+	Sender      string    `json:"sender"`
+	Text        string    `json:"text"`
+	PostedAt    time.Time `json:"posted_at"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+// CreateUpdate stores a new update pending fan-out.
+func (repo *updateRepository) CreateUpdate(ctx context.Context, senderID int, text string) (*Update, error) {
+	ctx, done := startOp(ctx, "CreateUpdate")
+	defer done()
+
+	u := Update{SenderID: senderID, Text: text, FanoutStatus: FanoutStatusPending}
+	err := repo.DB.QueryRowContext(ctx, `
+        WITH inserted AS (
+            INSERT INTO updates (sender_id, text)
+            VALUES ($1, $2)
+            RETURNING id, sender_id, created_at
+        )
+        SELECT i.id, i.created_at, u.email
+        FROM inserted i
+        JOIN users u ON u.id = i.sender_id;
+    `, senderID, text).Scan(&u.ID, &u.CreatedAt, &u.Sender)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgCreateUpdate)
+	}
+	return &u, nil
+}
+
+// GetUpdate retrieves the update by ID.
+func (repo *updateRepository) GetUpdate(ctx context.Context, id int64) (*Update, error) {
+	ctx, done := startOp(ctx, "GetUpdate")
+	defer done()
+
+	var u Update
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT up.id, up.sender_id, u.email, up.text, up.fanout_status, up.recipient_count, up.created_at
+        FROM updates up
+        JOIN users u ON u.id = up.sender_id
+        WHERE up.id = $1;
+    `, id).Scan(&u.ID, &u.SenderID, &u.Sender, &u.Text, &u.FanoutStatus, &u.RecipientCount, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New(response.ErrMsgUpdateNotFound)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetUpdate)
+	}
+	return &u, nil
+}
+
+// CountAudience counts the friends and approved subscribers of the user, an upper bound of the
+// number of recipients of an update the user sends.
+func (repo *updateRepository) CountAudience(ctx context.Context, userID int) (int, error) {
+	ctx, done := startOp(ctx, "CountAudience")
+	defer done()
+
+	var count int
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM friend_connections WHERE user_id1 = $1 OR user_id2 = $1) +
+            (SELECT COUNT(*) FROM subscriptions s JOIN users u ON u.email = s.target WHERE u.id = $1 AND s.status = $2);
+    `, userID, SubscriptionStatusApproved).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgGetUpdate)
+	}
+	return count, nil
+}
+
+// DeliverToInboxes adds the update to the inboxes of the recipients, returning how many
+// inboxes it was added to. Delivering an update to an inbox twice is a no-op.
+func (repo *updateRepository) DeliverToInboxes(ctx context.Context, updateID int64, recipients []string) (int, error) {
+	ctx, done := startOp(ctx, "DeliverToInboxes")
+	defer done()
+
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	res, err := repo.DB.ExecContext(ctx, `
+        INSERT INTO inbox_items (user_id, update_id)
+        SELECT id, $1 FROM users WHERE LOWER(email) = ANY($2)
+        ON CONFLICT (user_id, update_id) DO NOTHING;
+    `, updateID, pq.Array(emailaddr.NormalizeAll(recipients)))
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgDeliverUpdate)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgDeliverUpdate)
+	}
+	return int(n), nil
+}
+
+// MarkUpdateFannedOut records that the update reached all of its recipients.
+func (repo *updateRepository) MarkUpdateFannedOut(ctx context.Context, id int64, recipientCount int) error {
+	ctx, done := startOp(ctx, "MarkUpdateFannedOut")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        UPDATE updates
+        SET fanout_status = 'done', recipient_count = $2, fanned_out_at = NOW()
+        WHERE id = $1;
+    `, id, recipientCount)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgDeliverUpdate)
+	}
+	return nil
+}
+
+// MarkUpdateFanoutFailed records that the update will never reach its recipients, so the
+// sweeper stops retrying it.
+func (repo *updateRepository) MarkUpdateFanoutFailed(ctx context.Context, id int64) error {
+	ctx, done := startOp(ctx, "MarkUpdateFanoutFailed")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        UPDATE updates
+        SET fanout_status = 'failed'
+        WHERE id = $1 AND fanout_status = 'pending';
+    `, id)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgDeliverUpdate)
+	}
+	return nil
+}
+
+// ListPendingUpdates retrieves up to limit updates created before the given time whose
+// fan-out has not completed, oldest first.
+func (repo *updateRepository) ListPendingUpdates(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	ctx, done := startOp(ctx, "ListPendingUpdates")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT id
+        FROM updates
+        WHERE fanout_status = 'pending' AND created_at < $1
+        ORDER BY created_at
+        LIMIT $2;
+    `, before, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetUpdate)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetUpdate)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetUpdate)
+	}
+
+	return ids, nil
+}
+
+// GetInbox retrieves a page of the updates delivered to the user, newest first.
+func (repo *updateRepository) GetInbox(ctx context.Context, userID int, limit, offset int) ([]InboxItem, error) {
+	ctx, done := startOp(ctx, "GetInbox")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT up.id, u.email, up.text, up.created_at, i.created_at
+        FROM inbox_items i
+        JOIN updates up ON up.id = i.update_id
+        JOIN users u ON u.id = up.sender_id
+        WHERE i.user_id = $1
+        ORDER BY i.update_id DESC
+        LIMIT $2 OFFSET $3;
+    `, userID, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetInbox)
+	}
+	defer rows.Close()
+
+	items := []InboxItem{}
+	for rows.Next() {
+		var item InboxItem
+		if err := rows.Scan(&item.UpdateID, &item.Sender, &item.Text, &item.PostedAt, &item.DeliveredAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetInbox)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetInbox)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUpdateRepo is a mock implementation of UpdateRepository for testing purposes.
+type MockUpdateRepo struct {
+	mock.Mock
+}
+
+// CreateUpdate mocks the CreateUpdate method.
+func (m *MockUpdateRepo) CreateUpdate(ctx context.Context, senderID int, text string) (*Update, error) {
+	args := m.Called(ctx, senderID, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Update), args.Error(1)
+}
+
+// GetUpdate mocks the GetUpdate method.
+func (m *MockUpdateRepo) GetUpdate(ctx context.Context, id int64) (*Update, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Update), args.Error(1)
+}
+
+// CountAudience mocks the CountAudience method.
+func (m *MockUpdateRepo) CountAudience(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+// DeliverToInboxes mocks the DeliverToInboxes method.
+func (m *MockUpdateRepo) DeliverToInboxes(ctx context.Context, updateID int64, recipients []string) (int, error) {
+	args := m.Called(ctx, updateID, recipients)
+	return args.Int(0), args.Error(1)
+}
+
+// MarkUpdateFannedOut mocks the MarkUpdateFannedOut method.
+func (m *MockUpdateRepo) MarkUpdateFannedOut(ctx context.Context, id int64, recipientCount int) error {
+	args := m.Called(ctx, id, recipientCount)
+	return args.Error(0)
+}
+
+// MarkUpdateFanoutFailed mocks the MarkUpdateFanoutFailed method.
+func (m *MockUpdateRepo) MarkUpdateFanoutFailed(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// ListPendingUpdates mocks the ListPendingUpdates method.
+func (m *MockUpdateRepo) ListPendingUpdates(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+// GetInbox mocks the GetInbox method.
+func (m *MockUpdateRepo) GetInbox(ctx context.Context, userID int, limit, offset int) ([]InboxItem, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]InboxItem), args.Error(1)
+}
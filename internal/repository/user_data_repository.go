@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/pkg/errors"
+)
+
+// Erasure modes.
+const (
+	ErasureModeDelete    = "delete"
+	ErasureModeAnonymize = "anonymize"
+)
+
+// UserArchive holds all the data held about a user.
+type UserArchive struct {
+	Profile       models.User     `json:"profile"`
+	Friends       []ArchiveLink   `json:"friends"`
+	Subscriptions []ArchiveLink   `json:"subscriptions"`
+	Subscribers   []ArchiveLink   `json:"subscribers"`
+	Blocking      []ArchiveLink   `json:"blocking"`
+	BlockedBy     []ArchiveLink   `json:"blocked_by"`
+	Updates       []ArchiveUpdate `json:"updates"`
+	ExportedAt    time.Time       `json:"exported_at"`
+}
+
+// ArchiveLink is a relation of the archived user with another user.
+type ArchiveLink struct {
+	Email string    `json:"email"`
+	Since time.Time `json:"since"`
+}
+
+// ArchiveUpdate is an update posted by the archived user.
+type ArchiveUpdate struct {
+	ID             int64     `json:"id"`
+	Text           string    `json:"text"`
+	RecipientCount int       `json:"recipient_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Erasure is the audit record of the erasure of a user.
+type Erasure struct {
+	ID        int64  `json:"id"`
+	UserID    int    `json:"user_id"`
+	EmailHash string `json:"email_hash"`
+	Mode      string `json:"mode"`
+	Reason    string `json:"reason"`
+	RequestID string `json:"request_id"`
+	// The number of relations and updates removed, or anonymized along with the user.
+	Friendships   int       `json:"friendships"`
+	Subscriptions int       `json:"subscriptions"`
+	Blocks        int       `json:"blocks"`
+	Updates       int       `json:"updates"`
+	ErasedAt      time.Time `json:"erased_at"`
+}
+
+// scrubQueries replace the email of an erased user, matched case-insensitively by the
+// pattern $1, with the pseudonym $2 wherever it is copied.
+var scrubQueries = []string{
+	`UPDATE outbox SET payload = regexp_replace(payload::text, $1, $2, 'gi')::jsonb WHERE payload::text ~* $1`,
+	`UPDATE webhook_deliveries SET payload = regexp_replace(payload::text, $1, $2, 'gi')::jsonb WHERE payload::text ~* $1`,
+	`UPDATE webhook_dead_letters SET payload = regexp_replace(payload::text, $1, $2, 'gi')::jsonb WHERE payload::text ~* $1`,
+	`UPDATE analytics_snapshots SET report = regexp_replace(report::text, $1, $2, 'gi')::jsonb WHERE report::text ~* $1`,
+	`UPDATE updates SET text = regexp_replace(text, $1, $2, 'gi') WHERE text ~* $1`,
+	`UPDATE blocks SET reason = regexp_replace(reason, $1, $2, 'gi') WHERE reason ~* $1`,
+}
+
+// ErasedEmail returns the pseudonym replacing the email of the erased user with the ID, the
+// email an anonymized user is given.
+func ErasedEmail(userID int) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", userID)
+}
+
+// emailPattern returns the regular expression matching the email as a whole address, not as
+// part of a longer one.
+func emailPattern(email string) string {
+	return `(?<![A-Za-z0-9._%+-])` + regexp.QuoteMeta(email) + `(?![A-Za-z0-9-]|\.[A-Za-z0-9])`
+}
+
+// archiveQueries select the links of the archived user, given their id or, for the
+// subscriptions, their email.
+var archiveQueries = []struct {
+	query   string
+	byEmail bool
+	dest    func(a *UserArchive) *[]ArchiveLink
+}{
+	{`SELECT u.email, f.created_at FROM friend_connections f
+      JOIN users u ON u.id = CASE WHEN f.user_id1 = $1 THEN f.user_id2 ELSE f.user_id1 END
+      WHERE f.user_id1 = $1 OR f.user_id2 = $1 ORDER BY f.created_at`,
+		false, func(a *UserArchive) *[]ArchiveLink { return &a.Friends }},
+	{`SELECT s.target, s.created_at FROM subscriptions s WHERE s.requestor = $1 ORDER BY s.created_at`,
+		true, func(a *UserArchive) *[]ArchiveLink { return &a.Subscriptions }},
+	{`SELECT s.requestor, s.created_at FROM subscriptions s WHERE s.target = $1 ORDER BY s.created_at`,
+		true, func(a *UserArchive) *[]ArchiveLink { return &a.Subscribers }},
+	{`SELECT u.email, b.created_at FROM blocks b JOIN users u ON u.id = b.target
+      WHERE b.requestor = $1 ORDER BY b.created_at`,
+		false, func(a *UserArchive) *[]ArchiveLink { return &a.Blocking }},
+	{`SELECT u.email, b.created_at FROM blocks b JOIN users u ON u.id = b.requestor
+      WHERE b.target = $1 ORDER BY b.created_at`,
+		false, func(a *UserArchive) *[]ArchiveLink { return &a.BlockedBy }},
+}
+
+// GetUserArchive gathers all the data held about the user with the email.
+func (repo *userRepository) GetUserArchive(ctx context.Context, email string) (*UserArchive, error) {
+	ctx, done := startOp(ctx, "GetUserArchive")
+	defer done()
+
+	archive := &UserArchive{ExportedAt: time.Now().UTC()}
+	p := &archive.Profile
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT id, name, email, created_at, updated_at, status, status_changed_at, private
+        FROM users WHERE LOWER(email) = $1 ORDER BY id LIMIT 1;
+    `, emailaddr.Normalize(email)).Scan(userFields(p)...)
+	if err == sql.ErrNoRows {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+	}
+
+	for _, q := range archiveQueries {
+		var arg interface{} = p.ID
+		if q.byEmail {
+			arg = p.Email
+		}
+		rows, err := repo.DB.QueryContext(ctx, q.query, arg)
+		if err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+		}
+		links := []ArchiveLink{}
+		for rows.Next() {
+			var link ArchiveLink
+			if err := rows.Scan(&link.Email, &link.Since); err != nil {
+				rows.Close()
+				return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+			}
+			links = append(links, link)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+		}
+		*q.dest(archive) = links
+	}
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT id, text, recipient_count, created_at
+        FROM updates WHERE sender_id = $1 ORDER BY id;
+    `, p.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+	}
+	defer rows.Close()
+
+	archive.Updates = []ArchiveUpdate{}
+	for rows.Next() {
+		var u ArchiveUpdate
+		if err := rows.Scan(&u.ID, &u.Text, &u.RecipientCount, &u.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+		}
+		archive.Updates = append(archive.Updates, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgExportUserData)
+	}
+	return archive, nil
+}
+
+// EraseUser erases the user with the email and records the erasure, in a single transaction.
+// The delete mode deletes the user along with their relations and updates. The anonymize
+// mode replaces the name and email of the user, keeping their relations but deleting their
+// updates, whose text may hold personal data. In both modes, the copies of the email kept
+// elsewhere, the audit log included, are replaced with a pseudonym. The erasure is filled in with the user and the
+// counts, then stored.
+func (repo *userRepository) EraseUser(ctx context.Context, email string, erasure *Erasure) error {
+	ctx, done := startOp(ctx, "EraseUser")
+	defer done()
+
+	tx, err := repo.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgBeginTx)
+	}
+	defer tx.Rollback()
+	exec := tracing.WrapExecutor(tx)
+
+	var userEmail string
+	err = exec.QueryRowContext(ctx, `
+        SELECT id, email FROM users WHERE LOWER(email) = $1 ORDER BY id LIMIT 1 FOR UPDATE;
+    `, emailaddr.Normalize(email)).Scan(&erasure.UserID, &userEmail)
+	if err == sql.ErrNoRows {
+		return errors.New(response.ErrMsgUserNotFound)
+	}
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEraseUser)
+	}
+
+	err = exec.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM friend_connections WHERE user_id1 = $1 OR user_id2 = $1),
+            (SELECT COUNT(*) FROM subscriptions WHERE requestor = $2 OR target = $2),
+            (SELECT COUNT(*) FROM blocks WHERE requestor = $1 OR target = $1),
+            (SELECT COUNT(*) FROM updates WHERE sender_id = $1);
+    `, erasure.UserID, userEmail).Scan(&erasure.Friendships, &erasure.Subscriptions, &erasure.Blocks, &erasure.Updates)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEraseUser)
+	}
+
+	type statement struct {
+		query string
+		arg   interface{}
+	}
+	var statements []statement
+	switch erasure.Mode {
+	case ErasureModeDelete:
+		// The other relations and the updates are deleted in cascade
+		statements = []statement{
+			{`DELETE FROM subscriptions WHERE requestor = $1 OR target = $1`, userEmail},
+			{`DELETE FROM users WHERE id = $1`, erasure.UserID},
+		}
+	case ErasureModeAnonymize:
+		// Subscriptions follow the new email in cascade
+		statements = []statement{
+			{`DELETE FROM updates WHERE sender_id = $1`, erasure.UserID},
+			{`UPDATE users SET name = 'Erased user', email = 'erased-' || id || '@erased.invalid', updated_at = NOW() WHERE id = $1`, erasure.UserID},
+		}
+	default:
+		return errors.New(response.ErrMsgInvalidErasureMode)
+	}
+	for _, statement := range statements {
+		if _, err := exec.ExecContext(ctx, statement.query, statement.arg); err != nil {
+			return errors.Wrap(err, response.ErrMsgEraseUser)
+		}
+	}
+
+	// The email is also copied in the events, the webhook deliveries and the analytics
+	// reports, and written in the texts of other users. It is replaced with the pseudonym
+	// an anonymized user gets.
+	pseudonym := ErasedEmail(erasure.UserID)
+	pattern := emailPattern(userEmail)
+	for _, query := range scrubQueries {
+		if _, err := exec.ExecContext(ctx, query, pattern, pseudonym); err != nil {
+			return errors.Wrap(err, response.ErrMsgEraseUser)
+		}
+	}
+	_, err = exec.ExecContext(ctx, `
+        UPDATE webhooks SET emails = array_replace(emails, $1, $2), updated_at = NOW() WHERE $1 = ANY(emails);
+    `, emailaddr.Normalize(userEmail), pseudonym)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEraseUser)
+	}
+
+	// The audit log is append-only but for this redaction, allowed by the setting until the
+	// end of the transaction
+	if _, err := exec.ExecContext(ctx, `SELECT set_config('audit_log.redact', 'on', true);`); err != nil {
+		return errors.Wrap(err, response.ErrMsgEraseUser)
+	}
+	_, err = exec.ExecContext(ctx, `
+        UPDATE audit_log SET
+            actor = CASE WHEN LOWER(actor) = $1 THEN $2 ELSE actor END,
+            requestor = CASE WHEN LOWER(requestor) = $1 THEN $2 ELSE requestor END,
+            target = CASE WHEN LOWER(target) = $1 THEN $2 ELSE target END
+        WHERE $1 IN (LOWER(actor), LOWER(requestor), LOWER(target));
+    `, emailaddr.Normalize(userEmail), pseudonym)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEraseUser)
+	}
+
+	err = exec.QueryRowContext(ctx, `
+        INSERT INTO user_erasures (user_id, email_hash, mode, reason, request_id, friendships, subscriptions, blocks, updates)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, erased_at;
+    `, erasure.UserID, erasure.EmailHash, erasure.Mode, erasure.Reason, erasure.RequestID,
+		erasure.Friendships, erasure.Subscriptions, erasure.Blocks, erasure.Updates,
+	).Scan(&erasure.ID, &erasure.ErasedAt)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEraseUser)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, response.ErrMsgCommitTx)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/pkg/errors"
+)
+
+// Account states of a user. Only active users appear in friend lists, common friends and
+// the recipients of updates, the others keep their relations so they can be restored.
+// Deleted users are purged once the retention period is over.
+const (
+	UserStatusActive      = "active"
+	UserStatusDeactivated = "deactivated"
+	UserStatusSuspended   = "suspended"
+	UserStatusDeleted     = "deleted"
+)
+
+// CreateUser inserts a user. It returns nil when a user with the same canonical email exists.
+func (repo *userRepository) CreateUser(ctx context.Context, name, email string) (*models.User, error) {
+	ctx, done := startOp(ctx, "CreateUser")
+	defer done()
+
+	email = emailaddr.Normalize(email)
+	var user models.User
+	err := repo.DB.QueryRowContext(ctx, `
+        INSERT INTO users (name, email)
+        SELECT $1, $2
+        WHERE NOT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = $2)
+        RETURNING id, name, email, created_at, updated_at, status, status_changed_at, private;
+    `, name, email).Scan(userFields(&user)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.FromContext(ctx).Debug().Err(err).Str("email", logger.Email(email)).Msg("Insert user failed")
+		return nil, errors.Wrap(err, response.ErrMsgCreateUser)
+	}
+	return &user, nil
+}
+
+// SetUserStatus changes the account state of the user with the email. It returns nil when
+// no user has the email.
+func (repo *userRepository) SetUserStatus(ctx context.Context, email, status string) (*models.User, error) {
+	ctx, done := startOp(ctx, "SetUserStatus")
+	defer done()
+
+	var user models.User
+	err := repo.DB.QueryRowContext(ctx, `
+        UPDATE users SET status = $2, status_changed_at = NOW(), updated_at = NOW()
+        WHERE id = (SELECT id FROM users WHERE LOWER(email) = $1 ORDER BY id LIMIT 1)
+        RETURNING id, name, email, created_at, updated_at, status, status_changed_at, private;
+    `, emailaddr.Normalize(email), status).Scan(userFields(&user)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.FromContext(ctx).Debug().Err(err).Str("email", logger.Email(email)).Str("status", status).Msg("Update user status failed")
+		return nil, errors.Wrap(err, response.ErrMsgSetUserStatus)
+	}
+	return &user, nil
+}
+
+// PurgeDeletedUsers permanently deletes up to limit users deleted before the given time,
+// along with their subscriptions; the other relations and the updates are deleted in
+// cascade. It returns the number of users purged.
+func (repo *userRepository) PurgeDeletedUsers(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, done := startOp(ctx, "PurgeDeletedUsers")
+	defer done()
+
+	// Subscriptions refer to the users by email and do not cascade. The foreign keys are
+	// checked at the end of the statement, once both deletes ran.
+	res, err := repo.DB.ExecContext(ctx, `
+        WITH purged AS (
+            SELECT id, email FROM users
+            WHERE status = $1 AND status_changed_at < $2
+            ORDER BY status_changed_at LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        ), subscriptions_deleted AS (
+            DELETE FROM subscriptions s USING purged p
+            WHERE s.requestor = p.email OR s.target = p.email
+        )
+        DELETE FROM users u USING purged p WHERE u.id = p.id;
+    `, UserStatusDeleted, before, limit)
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgPurgeUsers)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, response.ErrMsgPurgeUsers)
+	}
+	return int(n), nil
+}
+
+// userFields returns the destinations to scan the columns of a user into, in the order
+// id, name, email, created_at, updated_at, status, status_changed_at, private.
+func userFields(user *models.User) []interface{} {
+	return []interface{}{&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt, &user.Status, &user.StatusChangedAt, &user.Private}
+}
+
+// DuplicateUser is one of the users sharing a canonical email.
+type DuplicateUser struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DuplicateEmail is a canonical email shared by several users, which have to be merged
+// by hand. The oldest user comes first and is the one email lookups resolve to.
+type DuplicateEmail struct {
+	CanonicalEmail string          `json:"canonical_email"`
+	Users          []DuplicateUser `json:"users"`
+}
+
+// ListDuplicateEmails lists the canonical emails shared by several users.
+func (repo *userRepository) ListDuplicateEmails(ctx context.Context) ([]DuplicateEmail, error) {
+	ctx, done := startOp(ctx, "ListDuplicateEmails")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT canonical_email, id, email, created_at
+        FROM user_email_duplicates
+        ORDER BY canonical_email, id;
+    `)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetDuplicateEmails)
+	}
+	defer rows.Close()
+
+	duplicates := []DuplicateEmail{}
+	for rows.Next() {
+		var canonical string
+		var user DuplicateUser
+		if err := rows.Scan(&canonical, &user.ID, &user.Email, &user.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetDuplicateEmails)
+		}
+		if n := len(duplicates); n == 0 || duplicates[n-1].CanonicalEmail != canonical {
+			duplicates = append(duplicates, DuplicateEmail{CanonicalEmail: canonical})
+		}
+		last := &duplicates[len(duplicates)-1]
+		last.Users = append(last.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetDuplicateEmails)
+	}
+	return duplicates, nil
+}
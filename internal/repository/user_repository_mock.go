@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserRepo is a mock implementation of UserRepository for testing purposes.
+type MockUserRepo struct {
+	mock.Mock
+}
+
+// CreateUser mocks the CreateUser method.
+func (m *MockUserRepo) CreateUser(ctx context.Context, name, email string) (*models.User, error) {
+	args := m.Called(ctx, name, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// SetUserStatus mocks the SetUserStatus method.
+func (m *MockUserRepo) SetUserStatus(ctx context.Context, email, status string) (*models.User, error) {
+	args := m.Called(ctx, email, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// PurgeDeletedUsers mocks the PurgeDeletedUsers method.
+func (m *MockUserRepo) PurgeDeletedUsers(ctx context.Context, before time.Time, limit int) (int, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Int(0), args.Error(1)
+}
+
+// GetUserArchive mocks the GetUserArchive method.
+func (m *MockUserRepo) GetUserArchive(ctx context.Context, email string) (*UserArchive, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserArchive), args.Error(1)
+}
+
+// EraseUser mocks the EraseUser method.
+func (m *MockUserRepo) EraseUser(ctx context.Context, email string, erasure *Erasure) error {
+	args := m.Called(ctx, email, erasure)
+	return args.Error(0)
+}
+
+// ListDuplicateEmails mocks the ListDuplicateEmails method.
+func (m *MockUserRepo) ListDuplicateEmails(ctx context.Context) ([]DuplicateEmail, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DuplicateEmail), args.Error(1)
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
+
+// Webhook is an endpoint notified of the graph changes it subscribed to.
+type Webhook struct {
+	ID     int    `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"-"`
+	// EventTypes filters the events by type. Empty means every type.
+	EventTypes []string `json:"event_types"`
+	// Emails filters the events by the users they are about. Empty means any user.
+	Emails    []string  `json:"emails"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an event sent, or to be sent, to a webhook.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// DueWebhookDelivery is a claimed delivery along with the endpoint it goes to.
+type DueWebhookDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// WebhookDeadLetter is a delivery that exhausted its retries.
+type WebhookDeadLetter struct {
+	ID             int64           `json:"id"`
+	DeliveryID     int64           `json:"delivery_id"`
+	WebhookID      int             `json:"webhook_id"`
+	EventID        int64           `json:"event_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// CreateWebhook inserts the webhook and sets its ID and creation time.
+func (repo *webhookRepository) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	ctx, done := startOp(ctx, "CreateWebhook")
+	defer done()
+
+	err := repo.DB.QueryRowContext(ctx, `
+        INSERT INTO webhooks (url, secret, event_types, emails)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at;
+    `, webhook.URL, webhook.Secret, pq.Array(nonNil(webhook.EventTypes)), pq.Array(nonNil(webhook.Emails)),
+	).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgCreateWebhook)
+	}
+	return nil
+}
+
+// GetWebhook retrieves the webhook by ID.
+func (repo *webhookRepository) GetWebhook(ctx context.Context, id int) (*Webhook, error) {
+	ctx, done := startOp(ctx, "GetWebhook")
+	defer done()
+
+	var w Webhook
+	err := repo.DB.QueryRowContext(ctx, `
+        SELECT id, url, secret, event_types, emails, created_at
+        FROM webhooks
+        WHERE id = $1;
+    `, id).Scan(&w.ID, &w.URL, &w.Secret, pq.Array(&w.EventTypes), pq.Array(&w.Emails), &w.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New(response.ErrMsgWebhookNotFound)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhook)
+	}
+	return &w, nil
+}
+
+// ListWebhooks retrieves all the webhooks.
+func (repo *webhookRepository) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	ctx, done := startOp(ctx, "ListWebhooks")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT id, url, secret, event_types, emails, created_at
+        FROM webhooks
+        ORDER BY id;
+    `)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhook)
+	}
+	defer rows.Close()
+
+	var webhooks []Webhook
+	for rows.Next() {
+		var w Webhook
+		if err := rows.Scan(&w.ID, &w.URL, &w.Secret, pq.Array(&w.EventTypes), pq.Array(&w.Emails), &w.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetWebhook)
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhook)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes the webhook along with its deliveries.
+func (repo *webhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, done := startOp(ctx, "DeleteWebhook")
+	defer done()
+
+	res, err := repo.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgDeleteWebhook)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.New(response.ErrMsgWebhookNotFound)
+	}
+	return nil
+}
+
+// EnqueueWebhookDelivery schedules the event for delivery to the webhook.
+// Enqueuing the same event twice for a webhook is a no-op.
+func (repo *webhookRepository) EnqueueWebhookDelivery(ctx context.Context, webhookID int, event events.Event) error {
+	ctx, done := startOp(ctx, "EnqueueWebhookDelivery")
+	defer done()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEnqueueWebhookDelivery)
+	}
+
+	_, err = repo.DB.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (webhook_id, event_id) DO NOTHING;
+    `, webhookID, event.ID, event.Type, payload)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgEnqueueWebhookDelivery)
+	}
+	return nil
+}
+
+// ClaimDueWebhookDeliveries claims up to limit pending deliveries that are due, hiding
+// them from other workers for the lease duration.
+func (repo *webhookRepository) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueWebhookDelivery, error) {
+	ctx, done := startOp(ctx, "ClaimDueWebhookDeliveries")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        WITH claimed AS (
+            UPDATE webhook_deliveries
+            SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+            WHERE id IN (
+                SELECT id
+                FROM webhook_deliveries
+                WHERE status = 'pending' AND next_attempt_at <= NOW()
+                ORDER BY id
+                LIMIT $1
+                FOR UPDATE SKIP LOCKED
+            )
+            RETURNING *
+        )
+        SELECT c.id, c.webhook_id, c.event_id, c.event_type, c.payload, c.status, c.attempts,
+               c.last_status_code, c.last_error, c.next_attempt_at, c.delivered_at, c.created_at,
+               w.url, w.secret
+        FROM claimed c
+        JOIN webhooks w ON w.id = c.webhook_id
+        ORDER BY c.id;
+    `, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgClaimWebhookDeliveries)
+	}
+	defer rows.Close()
+
+	var due []DueWebhookDelivery
+	for rows.Next() {
+		var d DueWebhookDelivery
+		if err := rows.Scan(deliveryFields(&d.WebhookDelivery, &d.URL, &d.Secret)...); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgClaimWebhookDeliveries)
+		}
+		due = append(due, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgClaimWebhookDeliveries)
+	}
+
+	return due, nil
+}
+
+// MarkWebhookDelivered records the successful delivery.
+func (repo *webhookRepository) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error {
+	ctx, done := startOp(ctx, "MarkWebhookDelivered")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET status = 'delivered', attempts = attempts + 1, last_status_code = $2, last_error = NULL, delivered_at = NOW()
+        WHERE id = $1;
+    `, id, statusCode)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgUpdateWebhookDelivery)
+	}
+	return nil
+}
+
+// MarkWebhookDeliveryFailed records a failed attempt and schedules the next one.
+// A statusCode of 0 means no response was received.
+func (repo *webhookRepository) MarkWebhookDeliveryFailed(ctx context.Context, id int64, statusCode int, cause string, nextAttempt time.Time) error {
+	ctx, done := startOp(ctx, "MarkWebhookDeliveryFailed")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        UPDATE webhook_deliveries
+        SET attempts = attempts + 1, last_status_code = $2, last_error = $3, next_attempt_at = $4
+        WHERE id = $1;
+    `, id, nullInt(statusCode), cause, nextAttempt)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgUpdateWebhookDelivery)
+	}
+	return nil
+}
+
+// DeadLetterWebhookDelivery records the last failed attempt and moves the delivery to the dead-letter table.
+func (repo *webhookRepository) DeadLetterWebhookDelivery(ctx context.Context, id int64, statusCode int, cause string) error {
+	ctx, done := startOp(ctx, "DeadLetterWebhookDelivery")
+	defer done()
+
+	_, err := repo.DB.ExecContext(ctx, `
+        WITH dead AS (
+            UPDATE webhook_deliveries
+            SET status = 'dead', attempts = attempts + 1, last_status_code = $2, last_error = $3
+            WHERE id = $1
+            RETURNING id, webhook_id, event_id, payload, attempts, last_status_code, last_error
+        )
+        INSERT INTO webhook_dead_letters (delivery_id, webhook_id, event_id, payload, attempts, last_status_code, last_error)
+        SELECT id, webhook_id, event_id, payload, attempts, last_status_code, last_error FROM dead
+        ON CONFLICT (delivery_id) DO NOTHING;
+    `, id, nullInt(statusCode), cause)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgUpdateWebhookDelivery)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries retrieves the deliveries of the webhook, newest first,
+// optionally filtered by status.
+func (repo *webhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]WebhookDelivery, error) {
+	ctx, done := startOp(ctx, "ListWebhookDeliveries")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+               last_status_code, last_error, next_attempt_at, delivered_at, created_at
+        FROM webhook_deliveries
+        WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+        ORDER BY id DESC
+        LIMIT $3 OFFSET $4;
+    `, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhookDeliveries)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(deliveryFields(&d)...); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetWebhookDeliveries)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhookDeliveries)
+	}
+
+	return deliveries, nil
+}
+
+// ListWebhookDeadLetters retrieves the dead letters of the webhook, newest first.
+func (repo *webhookRepository) ListWebhookDeadLetters(ctx context.Context, webhookID int, limit, offset int) ([]WebhookDeadLetter, error) {
+	ctx, done := startOp(ctx, "ListWebhookDeadLetters")
+	defer done()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+        SELECT id, delivery_id, webhook_id, event_id, payload, attempts, last_status_code, last_error, created_at
+        FROM webhook_dead_letters
+        WHERE webhook_id = $1
+        ORDER BY id DESC
+        LIMIT $2 OFFSET $3;
+    `, webhookID, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhookDeliveries)
+	}
+	defer rows.Close()
+
+	deadLetters := []WebhookDeadLetter{}
+	for rows.Next() {
+		var (
+			dl      WebhookDeadLetter
+			payload []byte
+		)
+		if err := rows.Scan(&dl.ID, &dl.DeliveryID, &dl.WebhookID, &dl.EventID, &payload, &dl.Attempts, &dl.LastStatusCode, &dl.LastError, &dl.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgGetWebhookDeliveries)
+		}
+		dl.Payload = payload
+		deadLetters = append(deadLetters, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetWebhookDeliveries)
+	}
+
+	return deadLetters, nil
+}
+
+// deliveryFields returns the scan destinations of a webhook_deliveries row followed by extra.
+func deliveryFields(d *WebhookDelivery, extra ...interface{}) []interface{} {
+	return append([]interface{}{
+		&d.ID, &d.WebhookID, &d.EventID, &d.EventType, (*[]byte)(&d.Payload), &d.Status, &d.Attempts,
+		&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt,
+	}, extra...)
+}
+
+// nullInt maps 0 to NULL.
+func nullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+// nonNil returns an empty slice instead of nil so it is stored as an empty array rather than NULL.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookRepo is a mock implementation of WebhookRepository for testing purposes.
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+// CreateWebhook mocks the CreateWebhook method.
+func (m *MockWebhookRepo) CreateWebhook(ctx context.Context, webhook *Webhook) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+// GetWebhook mocks the GetWebhook method.
+func (m *MockWebhookRepo) GetWebhook(ctx context.Context, id int) (*Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Webhook), args.Error(1)
+}
+
+// ListWebhooks mocks the ListWebhooks method.
+func (m *MockWebhookRepo) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Webhook), args.Error(1)
+}
+
+// DeleteWebhook mocks the DeleteWebhook method.
+func (m *MockWebhookRepo) DeleteWebhook(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// EnqueueWebhookDelivery mocks the EnqueueWebhookDelivery method.
+func (m *MockWebhookRepo) EnqueueWebhookDelivery(ctx context.Context, webhookID int, event events.Event) error {
+	args := m.Called(ctx, webhookID, event)
+	return args.Error(0)
+}
+
+// ClaimDueWebhookDeliveries mocks the ClaimDueWebhookDeliveries method.
+func (m *MockWebhookRepo) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueWebhookDelivery, error) {
+	args := m.Called(ctx, limit, lease)
+	return args.Get(0).([]DueWebhookDelivery), args.Error(1)
+}
+
+// MarkWebhookDelivered mocks the MarkWebhookDelivered method.
+func (m *MockWebhookRepo) MarkWebhookDelivered(ctx context.Context, id int64, statusCode int) error {
+	args := m.Called(ctx, id, statusCode)
+	return args.Error(0)
+}
+
+// MarkWebhookDeliveryFailed mocks the MarkWebhookDeliveryFailed method.
+func (m *MockWebhookRepo) MarkWebhookDeliveryFailed(ctx context.Context, id int64, statusCode int, cause string, nextAttempt time.Time) error {
+	args := m.Called(ctx, id, statusCode, cause, nextAttempt)
+	return args.Error(0)
+}
+
+// DeadLetterWebhookDelivery mocks the DeadLetterWebhookDelivery method.
+func (m *MockWebhookRepo) DeadLetterWebhookDelivery(ctx context.Context, id int64, statusCode int, cause string) error {
+	args := m.Called(ctx, id, statusCode, cause)
+	return args.Error(0)
+}
+
+// ListWebhookDeliveries mocks the ListWebhookDeliveries method.
+func (m *MockWebhookRepo) ListWebhookDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, status, limit, offset)
+	return args.Get(0).([]WebhookDelivery), args.Error(1)
+}
+
+// ListWebhookDeadLetters mocks the ListWebhookDeadLetters method.
+func (m *MockWebhookRepo) ListWebhookDeadLetters(ctx context.Context, webhookID int, limit, offset int) ([]WebhookDeadLetter, error) {
+	args := m.Called(ctx, webhookID, limit, offset)
+	return args.Get(0).([]WebhookDeadLetter), args.Error(1)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+)
+
+// ComputeSnapshot computes the statistics of the social graph and stores them as the latest snapshot.
+// The connected components and the clustering coefficient are computed in memory from the
+// friendships between active users.
+func (serv *analyticsService) ComputeSnapshot(ctx context.Context) (*repository.AnalyticsReport, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.ComputeSnapshot")
+	defer span.End()
+
+	report, err := serv.computeReport(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to compute analytics")
+		return nil, err
+	}
+	if err := serv.repo.InsertAnalyticsSnapshot(ctx, report); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to store analytics snapshot")
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetLatestSnapshot retrieves the latest snapshot of the statistics of the social graph,
+// computing one when there is none yet.
+func (serv *analyticsService) GetLatestSnapshot(ctx context.Context) (*repository.AnalyticsReport, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.GetLatestSnapshot")
+	defer span.End()
+
+	report, err := serv.repo.GetLatestAnalyticsSnapshot(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to get analytics snapshot")
+		return nil, err
+	}
+	if report == nil {
+		return serv.ComputeSnapshot(ctx)
+	}
+	return report, nil
+}
+
+// computeReport runs the queries of a report and the computations over the friendship graph.
+func (serv *analyticsService) computeReport(ctx context.Context) (*repository.AnalyticsReport, error) {
+	var (
+		report = &repository.AnalyticsReport{}
+		err    error
+	)
+	if report.Totals, err = serv.repo.GetGraphTotals(ctx); err != nil {
+		return nil, err
+	}
+	if report.DegreeDistribution, err = serv.repo.GetDegreeDistribution(ctx); err != nil {
+		return nil, err
+	}
+	if report.MostFollowed, err = serv.repo.GetMostFollowed(ctx, serv.topUsers); err != nil {
+		return nil, err
+	}
+	if report.MostBlocked, err = serv.repo.GetMostBlocked(ctx, serv.topUsers); err != nil {
+		return nil, err
+	}
+
+	graph := friendGraph{}
+	if err := serv.repo.ListFriendEdges(ctx, func(userID1, userID2 int) error {
+		graph.add(userID1, userID2)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	report.Components = graph.components(report.Totals.Users)
+	report.ClusteringCoefficient = graph.clustering(report.Totals.Users)
+	return report, nil
+}
+
+// friendGraph is the adjacency of the users having at least one friend.
+type friendGraph map[int]map[int]struct{}
+
+// add adds the friendship between two users.
+func (g friendGraph) add(userID1, userID2 int) {
+	for _, e := range [][2]int{{userID1, userID2}, {userID2, userID1}} {
+		if g[e[0]] == nil {
+			g[e[0]] = map[int]struct{}{}
+		}
+		g[e[0]][e[1]] = struct{}{}
+	}
+}
+
+// components summarizes the connected components of a graph of users users, the users
+// missing from g being isolated.
+func (g friendGraph) components(users int) repository.ComponentStats {
+	stats := repository.ComponentStats{Isolated: users - len(g)}
+	if stats.Isolated < 0 {
+		stats.Isolated = 0
+	}
+	stats.Count = stats.Isolated
+	if stats.Isolated > 0 {
+		stats.Largest = 1
+	}
+
+	visited := make(map[int]bool, len(g))
+	for start := range g {
+		if visited[start] {
+			continue
+		}
+		visited[start] = true
+		size, stack := 0, []int{start}
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			size++
+			for friend := range g[id] {
+				if !visited[friend] {
+					visited[friend] = true
+					stack = append(stack, friend)
+				}
+			}
+		}
+		stats.Count++
+		if size > stats.Largest {
+			stats.Largest = size
+		}
+	}
+	return stats
+}
+
+// clustering computes the average local clustering coefficient of a graph of users users,
+// counting 0 for the users with less than two friends.
+func (g friendGraph) clustering(users int) float64 {
+	if users == 0 {
+		return 0
+	}
+
+	// links[u] is twice the number of friendships between the friends of u: every friendship
+	// u-v adds the friends u and v have in common to both
+	links := make(map[int]int, len(g))
+	for u, friends := range g {
+		for v := range friends {
+			if u >= v {
+				continue
+			}
+			small, large := g[u], g[v]
+			if len(large) < len(small) {
+				small, large = large, small
+			}
+			common := 0
+			for w := range small {
+				if _, ok := large[w]; ok {
+					common++
+				}
+			}
+			links[u] += common
+			links[v] += common
+		}
+	}
+
+	var sum float64
+	for u, friends := range g {
+		if d := len(friends); d >= 2 {
+			sum += float64(links[u]) / float64(d*(d-1))
+		}
+	}
+	return sum / float64(users)
+}
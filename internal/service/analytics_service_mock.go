@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnalyticsService is a mock implementation of AnalyticsService interface.
+type MockAnalyticsService struct {
+	mock.Mock
+}
+
+// ComputeSnapshot mocks the ComputeSnapshot method of the AnalyticsService interface.
+func (m *MockAnalyticsService) ComputeSnapshot(ctx context.Context) (*repository.AnalyticsReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AnalyticsReport), args.Error(1)
+}
+
+// GetLatestSnapshot mocks the GetLatestSnapshot method of the AnalyticsService interface.
+func (m *MockAnalyticsService) GetLatestSnapshot(ctx context.Context) (*repository.AnalyticsReport, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AnalyticsReport), args.Error(1)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestComputeSnapshot tests the ComputeSnapshot method of the AnalyticsService.
+func TestComputeSnapshot(t *testing.T) {
+	// The friendships form the triangle 1-2-3 with 4 a friend of 3, while the fifth user has no friend
+	edges := [][2]int{{1, 2}, {2, 3}, {1, 3}, {4, 3}}
+	totals := repository.GraphTotals{Users: 5, Friendships: 4, Subscriptions: 2, Blocks: 1}
+	followed := []repository.UserCount{{Email: "kate@example.com", Count: 2}}
+	blocked := []repository.UserCount{{Email: "john@example.com", Count: 1}}
+
+	tcs := map[string]struct {
+		edgesErr  error  // Error returned by ListFriendEdges
+		insertErr error  // Error returned by InsertAnalyticsSnapshot
+		expError  string // Expected error message
+	}{
+		"success": {},
+		"edges_error": {
+			edgesErr: errors.New(response.ErrMsgGetAnalytics),
+			expError: response.ErrMsgGetAnalytics,
+		},
+		"store_error": {
+			insertErr: errors.New(response.ErrMsgStoreAnalytics),
+			expError:  response.ErrMsgStoreAnalytics,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockAnalyticsRepo)
+			analyticsService := NewAnalyticsService(mockRepo, 10)
+			mockRepo.On("GetGraphTotals", mock.Anything).Return(totals, nil).Once()
+			mockRepo.On("GetDegreeDistribution", mock.Anything).Return([]repository.DegreeCount{{Degree: 0, Users: 1}}, nil).Once()
+			mockRepo.On("GetMostFollowed", mock.Anything, 10).Return(followed, nil).Once()
+			mockRepo.On("GetMostBlocked", mock.Anything, 10).Return(blocked, nil).Once()
+			mockRepo.On("ListFriendEdges", mock.Anything, mock.Anything).Return(edges, tc.edgesErr).Once()
+			if tc.edgesErr == nil {
+				mockRepo.On("InsertAnalyticsSnapshot", mock.Anything, mock.Anything).Return(tc.insertErr).Once()
+			}
+
+			// When
+			report, err := analyticsService.ComputeSnapshot(context.Background())
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				require.Nil(t, report)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, totals, report.Totals)
+				require.Equal(t, followed, report.MostFollowed)
+				require.Equal(t, blocked, report.MostBlocked)
+				require.Equal(t, repository.ComponentStats{Count: 2, Largest: 4, Isolated: 1}, report.Components)
+				// Users 1 and 2 have a coefficient of 1 and user 3 of 1/3
+				require.InDelta(t, 7.0/15, report.ClusteringCoefficient, 1e-9)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetLatestSnapshot tests the GetLatestSnapshot method of the AnalyticsService.
+func TestGetLatestSnapshot(t *testing.T) {
+	stored := &repository.AnalyticsReport{ID: 3, ComputedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tcs := map[string]struct {
+		latest     *repository.AnalyticsReport // Snapshot returned by GetLatestAnalyticsSnapshot
+		expCompute bool                        // Whether a snapshot is expected to be computed
+	}{
+		"stored_snapshot": {
+			latest: stored,
+		},
+		"no_snapshot_yet": {
+			expCompute: true,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockAnalyticsRepo)
+			analyticsService := NewAnalyticsService(mockRepo, 10)
+			mockRepo.On("GetLatestAnalyticsSnapshot", mock.Anything).Return(tc.latest, nil).Once()
+			if tc.expCompute {
+				mockRepo.On("GetGraphTotals", mock.Anything).Return(repository.GraphTotals{}, nil).Once()
+				mockRepo.On("GetDegreeDistribution", mock.Anything).Return([]repository.DegreeCount{}, nil).Once()
+				mockRepo.On("GetMostFollowed", mock.Anything, 10).Return([]repository.UserCount{}, nil).Once()
+				mockRepo.On("GetMostBlocked", mock.Anything, 10).Return([]repository.UserCount{}, nil).Once()
+				mockRepo.On("ListFriendEdges", mock.Anything, mock.Anything).Return(nil, nil).Once()
+				mockRepo.On("InsertAnalyticsSnapshot", mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			// When
+			report, err := analyticsService.GetLatestSnapshot(context.Background())
+
+			// Then
+			require.NoError(t, err)
+			require.NotNil(t, report)
+			if !tc.expCompute {
+				require.Equal(t, stored, report)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
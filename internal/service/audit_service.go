@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// ListAuditEntries lists the audit entries matching the filter, newest first. Actions are
+// the domain event types and the audit only actions.
+func (serv *auditService) ListAuditEntries(ctx context.Context, filter repository.AuditFilter, limit, offset int) ([]repository.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.ListAuditEntries")
+	defer span.End()
+
+	if filter.Action != "" && !audit.IsValidAction(filter.Action) {
+		return nil, errors.Errorf("%s: %q", response.ErrMsgInvalidAuditAction, filter.Action)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, errors.New(response.ErrMsgInvalidTimeRange)
+	}
+	filter.User = emailaddr.Normalize(filter.User)
+
+	entries, err := serv.repo.ListAuditEntries(ctx, filter, limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to list audit entries")
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditService is a mock implementation of AuditService interface.
+type MockAuditService struct {
+	mock.Mock
+}
+
+// ListAuditEntries mocks the ListAuditEntries method of the AuditService interface.
+func (m *MockAuditService) ListAuditEntries(ctx context.Context, filter repository.AuditFilter, limit, offset int) ([]repository.AuditEntry, error) {
+	args := m.Called(ctx, filter, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.AuditEntry), args.Error(1)
+}
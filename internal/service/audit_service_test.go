@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListAuditEntries tests the ListAuditEntries method of the AuditService.
+func TestListAuditEntries(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tcs := map[string]struct {
+		filter    repository.AuditFilter // Requested filter
+		expFilter repository.AuditFilter // Expected filter passed to the repository, zero if not called
+		expError  string                 // Expected error message
+	}{
+		"no_filter": {},
+		"all_filters": {
+			filter:    repository.AuditFilter{User: " Andy@Example.com", Action: events.Blocked, From: from, To: to},
+			expFilter: repository.AuditFilter{User: "andy@example.com", Action: events.Blocked, From: from, To: to},
+		},
+		"audit_only_action": {
+			filter:    repository.AuditFilter{Action: audit.ActionMuted},
+			expFilter: repository.AuditFilter{Action: audit.ActionMuted},
+		},
+		"unknown_action": {
+			filter:   repository.AuditFilter{Action: "Deleted"},
+			expError: response.ErrMsgInvalidAuditAction,
+		},
+		"invalid_time_range": {
+			filter:   repository.AuditFilter{From: to, To: from},
+			expError: response.ErrMsgInvalidTimeRange,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockAuditRepo)
+			auditService := NewAuditService(mockRepo)
+			entries := []repository.AuditEntry{{ID: 1, Action: events.Blocked}}
+			if tc.expError == "" {
+				mockRepo.On("ListAuditEntries", mock.Anything, tc.expFilter, 20, 0).Return(entries, nil).Once()
+			}
+
+			// When
+			got, err := auditService.ListAuditEntries(context.Background(), tc.filter, 20, 0)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, entries, got)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// Import validates the rows and creates the relations they describe, reporting the rows
+// that were not imported. Invalid rows, rows repeating an earlier one and rows naming an
+// unknown user are skipped, the others are imported together. In a dry run the rows are
+// only validated. Imported relations do not record domain events.
+func (serv *bulkService) Import(ctx context.Context, rows []bulk.Row, dryRun bool) (*bulk.Report, error) {
+	ctx, span := tracing.Start(ctx, "BulkService.Import")
+	defer span.End()
+
+	report := &bulk.Report{DryRun: dryRun, Total: len(rows), Errors: []bulk.RowError{}}
+	fail := func(row bulk.Row, reason string) {
+		report.Errors = append(report.Errors, bulk.RowError{Line: row.Line, Relation: row.Relation, Error: reason})
+	}
+
+	// Validate the rows and collect the users they name
+	valid := make([]bulk.Row, 0, len(rows))
+	seen := map[string]int{}
+	var emails []string
+	for _, row := range rows {
+		if row.Err != "" {
+			fail(row, row.Err)
+			continue
+		}
+		rel, err := canonicalRelation(row.Relation)
+		if err != nil {
+			fail(row, err.Error())
+			continue
+		}
+		key := relationKey(rel)
+		if line, ok := seen[key]; ok {
+			fail(row, errors.Errorf("%s of line %d", response.ErrMsgDuplicateRow, line).Error())
+			continue
+		}
+		seen[key] = row.Line
+		emails = append(emails, rel.Requestor, rel.Target)
+		valid = append(valid, bulk.Row{Line: row.Line, Relation: rel})
+	}
+
+	users, err := serv.repo.ResolveUsers(ctx, emails)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to resolve imported users")
+		return nil, err
+	}
+
+	relations := make([]repository.ImportRelation, 0, len(valid))
+	for _, row := range valid {
+		requestor, ok := users[row.Requestor]
+		if !ok {
+			fail(row, response.ErrMsgUserNotFound+": "+row.Requestor)
+			continue
+		}
+		target, ok := users[row.Target]
+		if !ok {
+			fail(row, response.ErrMsgUserNotFound+": "+row.Target)
+			continue
+		}
+		if requestor.ID == target.ID {
+			fail(row, response.ErrMsgSelfRelation)
+			continue
+		}
+		relations = append(relations, repository.ImportRelation{
+			Line: row.Line,
+			// Subscriptions refer to the users by their stored email
+			Relation: repository.Relation{Type: row.Type, Requestor: requestor.Email, Target: target.Email,
+				Category: row.Category, Reason: row.Reason, ExpiresAt: row.ExpiresAt},
+			RequestorID: requestor.ID,
+			TargetID:    target.ID,
+		})
+	}
+	report.Valid = len(relations)
+
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	report.Failed = len(report.Errors)
+
+	if dryRun {
+		return report, nil
+	}
+
+	created, err := serv.repo.ImportRelations(ctx, relations)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("relations", len(relations)).Msg("Failed to import relations")
+		return nil, err
+	}
+	report.Created = len(created)
+	report.Existing = report.Valid - report.Created
+
+	return report, nil
+}
+
+// Export calls fn with every relation involving the user with the email, or with every
+// relation of the graph when email is empty.
+func (serv *bulkService) Export(ctx context.Context, email string, fn func(repository.Relation) error) error {
+	ctx, span := tracing.Start(ctx, "BulkService.Export")
+	defer span.End()
+
+	var user *repository.UserRef
+	if email != "" {
+		email = emailaddr.Normalize(email)
+		users, err := serv.repo.ResolveUsers(ctx, []string{email})
+		if err != nil {
+			return err
+		}
+		found, ok := users[email]
+		if !ok {
+			return errors.New(response.ErrMsgUserNotFound)
+		}
+		user = &found
+	}
+
+	if err := serv.repo.ExportRelations(ctx, user, fn); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to export relations")
+		return err
+	}
+	return nil
+}
+
+// canonicalRelation validates the relation and returns it with its type and emails in
+// canonical form. The details of a block are validated as when blocking through the API, the
+// category defaulting to other.
+func canonicalRelation(rel repository.Relation) (repository.Relation, error) {
+	rel.Type = strings.ToLower(strings.TrimSpace(rel.Type))
+	rel.Category, rel.Reason = strings.TrimSpace(rel.Category), strings.TrimSpace(rel.Reason)
+	switch rel.Type {
+	case repository.RelationFriendship, repository.RelationSubscription:
+		if rel.Category != "" || rel.Reason != "" || rel.ExpiresAt != nil {
+			return rel, errors.New(response.ErrMsgUnexpectedBlockDetails)
+		}
+	case repository.RelationBlock:
+		if rel.Category == "" {
+			rel.Category = repository.BlockCategoryOther
+		}
+		if !isBlockCategory(rel.Category) {
+			return rel, errors.New(response.ErrMsgInvalidBlockCategory)
+		}
+		if rel.ExpiresAt != nil && !rel.ExpiresAt.After(time.Now()) {
+			return rel, errors.New(response.ErrMsgInvalidExpiry)
+		}
+	default:
+		return rel, errors.New(response.ErrMsgInvalidRelationType)
+	}
+
+	for _, email := range []*string{&rel.Requestor, &rel.Target} {
+		*email = emailaddr.Normalize(*email)
+		if addr, err := mail.ParseAddress(*email); err != nil || addr.Address != *email {
+			return rel, errors.Errorf("%s: %q", response.ErrMsgInvalidEmail, *email)
+		}
+	}
+
+	if rel.Requestor == rel.Target {
+		return rel, errors.New(response.ErrMsgSelfRelation)
+	}
+	return rel, nil
+}
+
+// relationKey identifies a relation, friendships being the same both ways.
+func relationKey(rel repository.Relation) string {
+	a, b := rel.Requestor, rel.Target
+	if rel.Type == repository.RelationFriendship && b < a {
+		a, b = b, a
+	}
+	return rel.Type + " " + a + " " + b
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBulkService is a mock implementation of BulkService interface.
+type MockBulkService struct {
+	mock.Mock
+}
+
+// Import mocks the Import method of the BulkService interface.
+func (m *MockBulkService) Import(ctx context.Context, rows []bulk.Row, dryRun bool) (*bulk.Report, error) {
+	args := m.Called(ctx, rows, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*bulk.Report), args.Error(1)
+}
+
+// Export mocks the Export method of the BulkService interface, calling fn with the
+// relations given to Return.
+func (m *MockBulkService) Export(ctx context.Context, email string, fn func(repository.Relation) error) error {
+	args := m.Called(ctx, email, fn)
+	if relations, ok := args.Get(0).([]repository.Relation); ok {
+		for _, rel := range relations {
+			if err := fn(rel); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
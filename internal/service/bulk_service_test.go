@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImport tests the Import method of the BulkService.
+func TestImport(t *testing.T) {
+	users := map[string]repository.UserRef{
+		"andy@example.com": {ID: 1, Email: "Andy@Example.com"},
+		"kate@example.com": {ID: 2, Email: "kate@example.com"},
+		"john@example.com": {ID: 3, Email: "john@example.com"},
+	}
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	rows := []bulk.Row{
+		{Line: 2, Relation: repository.Relation{Type: "friendship", Requestor: "andy@example.com", Target: "kate@example.com"}},
+		{Line: 3, Relation: repository.Relation{Type: "Subscription", Requestor: " ANDY@example.com", Target: "john@example.com"}},
+		{Line: 4, Relation: repository.Relation{Type: "friendship", Requestor: "kate@example.com", Target: "Andy@example.com"}},
+		{Line: 5, Relation: repository.Relation{Type: "follow", Requestor: "andy@example.com", Target: "john@example.com"}},
+		{Line: 6, Relation: repository.Relation{Type: "block", Requestor: "andy@example.com", Target: "not-an-email"}},
+		{Line: 7, Relation: repository.Relation{Type: "block", Requestor: "john@example.com", Target: "john@example.com"}},
+		{Line: 8, Relation: repository.Relation{Type: "block", Requestor: "john@example.com", Target: "nobody@example.com"}},
+		{Line: 9, Err: response.ErrMsgMalformedRow},
+		{Line: 10, Relation: repository.Relation{Type: "block", Requestor: "kate@example.com", Target: "john@example.com",
+			Category: " spam ", Reason: " Sends ads ", ExpiresAt: &future}},
+		{Line: 11, Relation: repository.Relation{Type: "block", Requestor: "andy@example.com", Target: "kate@example.com"}},
+		{Line: 12, Relation: repository.Relation{Type: "friendship", Requestor: "john@example.com", Target: "kate@example.com", Reason: "Old friends"}},
+		{Line: 13, Relation: repository.Relation{Type: "block", Requestor: "john@example.com", Target: "andy@example.com", Category: "rude"}},
+		{Line: 14, Relation: repository.Relation{Type: "block", Requestor: "john@example.com", Target: "kate@example.com", ExpiresAt: &past}},
+	}
+	expRelations := []repository.ImportRelation{
+		{Line: 2, Relation: repository.Relation{Type: "friendship", Requestor: "Andy@Example.com", Target: "kate@example.com"}, RequestorID: 1, TargetID: 2},
+		{Line: 3, Relation: repository.Relation{Type: "subscription", Requestor: "Andy@Example.com", Target: "john@example.com"}, RequestorID: 1, TargetID: 3},
+		{Line: 10, Relation: repository.Relation{Type: "block", Requestor: "kate@example.com", Target: "john@example.com",
+			Category: repository.BlockCategorySpam, Reason: "Sends ads", ExpiresAt: &future}, RequestorID: 2, TargetID: 3},
+		{Line: 11, Relation: repository.Relation{Type: "block", Requestor: "Andy@Example.com", Target: "kate@example.com",
+			Category: repository.BlockCategoryOther}, RequestorID: 1, TargetID: 2},
+	}
+	expErrors := []int{4, 5, 6, 7, 8, 9, 12, 13, 14}
+
+	tcs := map[string]struct {
+		dryRun     bool  // Whether only to validate the rows
+		created    []int // Lines created by the repository
+		importErr  error // Error returned by ImportRelations
+		expCreated int   // Expected number of relations created
+		expError   string
+	}{
+		"import": {
+			created:    []int{3},
+			expCreated: 1,
+		},
+		"dry_run": {
+			dryRun: true,
+		},
+		"import_error": {
+			importErr: errors.New(response.ErrMsgImportRelations),
+			expError:  response.ErrMsgImportRelations,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockBulkRepo)
+			bulkService := NewBulkService(mockRepo)
+			mockRepo.On("ResolveUsers", mock.Anything, mock.Anything).Return(users, nil).Once()
+			if !tc.dryRun {
+				mockRepo.On("ImportRelations", mock.Anything, expRelations).Return(tc.created, tc.importErr).Once()
+			}
+
+			// When
+			report, err := bulkService.Import(context.Background(), rows, tc.dryRun)
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+				mockRepo.AssertExpectations(t)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.dryRun, report.DryRun)
+			require.Equal(t, len(rows), report.Total)
+			require.Equal(t, len(expRelations), report.Valid)
+			require.Equal(t, tc.expCreated, report.Created)
+			require.Equal(t, len(expErrors), report.Failed)
+			lines := make([]int, 0, len(report.Errors))
+			for _, rowErr := range report.Errors {
+				lines = append(lines, rowErr.Line)
+			}
+			require.Equal(t, expErrors, lines)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestExport tests the Export method of the BulkService.
+func TestExport(t *testing.T) {
+	relations := []repository.Relation{
+		{Type: repository.RelationFriendship, Requestor: "andy@example.com", Target: "kate@example.com"},
+	}
+
+	tcs := map[string]struct {
+		email    string                        // Email of the exported user
+		users    map[string]repository.UserRef // Users returned by ResolveUsers
+		expUser  *repository.UserRef           // Expected user given to ExportRelations
+		expCall  bool                          // Whether ExportRelations is expected to be called
+		expError string
+	}{
+		"whole_graph": {
+			expCall: true,
+		},
+		"user": {
+			email:   "Andy@Example.com",
+			users:   map[string]repository.UserRef{"andy@example.com": {ID: 1, Email: "andy@example.com"}},
+			expUser: &repository.UserRef{ID: 1, Email: "andy@example.com"},
+			expCall: true,
+		},
+		"unknown_user": {
+			email:    "nobody@example.com",
+			users:    map[string]repository.UserRef{},
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockBulkRepo)
+			bulkService := NewBulkService(mockRepo)
+			if tc.email != "" {
+				mockRepo.On("ResolveUsers", mock.Anything, mock.Anything).Return(tc.users, nil).Once()
+			}
+			if tc.expCall {
+				mockRepo.On("ExportRelations", mock.Anything, tc.expUser, mock.Anything).Return(relations, nil).Once()
+			}
+
+			// When
+			var got []repository.Relation
+			err := bulkService.Export(context.Background(), tc.email, func(rel repository.Relation) error {
+				got = append(got, rel)
+				return nil
+			})
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, relations, got)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
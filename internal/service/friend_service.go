@@ -2,29 +2,51 @@ package service
 
 import (
 	"context"
-	"log"
-	"regexp"
-
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/mention"
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/metrics"
 	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
 	"github.com/pkg/errors"
 )
 
+// expireBlocksBatchSize is the maximum number of blocks expired per transaction.
+const expireBlocksBatchSize = 100
+
 // CreateFriend creates a friend connection between two users using their email addresses.
 func (serv friendService) CreateFriend(ctx context.Context, email1, email2 string) error {
+	ctx, span := tracing.Start(ctx, "FriendService.CreateFriend")
+	defer span.End()
+
+	email1, email2 = emailaddr.Normalize(email1), emailaddr.Normalize(email2)
+
 	// Get user IDs from emails
 	user1, err := serv.repo.GetUserByEmail(ctx, email1)
 	if err != nil {
-		log.Printf("Failed to get user for email %s: %v", email1, err)
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email1)).Msg("Failed to get user by email")
 		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 	if user1 == nil {
 		return errors.New(response.ErrMsgUserNotFound)
 	}
+	if err := checkActive(user1); err != nil {
+		return err
+	}
 	userID1 := user1.ID
 
 	user2, err := serv.repo.GetUserByEmail(ctx, email2)
 	if err != nil {
-		log.Printf("Failed to get user for email %s: %v", email2, err)
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email2)).Msg("Failed to get user by email")
 		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 	if user2 == nil {
@@ -42,22 +64,39 @@ func (serv friendService) CreateFriend(ctx context.Context, email1, email2 strin
 		return errors.New(response.ErrMsgAlreadyFriends)
 	}
 
-	// Add friend connection using user IDs
-	err = serv.repo.AddFriend(ctx, userID1, userID2)
+	// Add friend connection using user IDs, recording the event in the same transaction
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		if err := repo.AddFriend(ctx, userID1, userID2); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, repo, events.FriendshipCreated, events.FriendshipCreatedPayload{
+			Friends: []string{user1.Email, user2.Email},
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.FriendshipCreated, user1.Email, user2.Email,
+			relationState{"friends": false}, relationState{"friends": true})
+	})
 	if err != nil {
-		log.Printf("Failed to create friend connection: %v", err)
+		logger.FromContext(ctx).Error().Err(err).Int("user_id1", userID1).Int("user_id2", userID2).Msg("Failed to create friend connection")
 		return errors.Wrap(err, response.ErrMsgCreateFriend)
 	}
+	metrics.FriendshipsCreated.Inc()
 
 	return nil
 }
 
 // GetFriendsList retrieves the list of friends for a given email address.
 func (serv friendService) GetFriendsList(ctx context.Context, email string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.GetFriendsList")
+	defer span.End()
+
+	email = emailaddr.Normalize(email)
+
 	// Get user ID from email
 	user, err := serv.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		log.Printf("Failed to get user for email %s: %v", email, err)
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user by email")
 		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 	if user == nil {
@@ -68,7 +107,7 @@ func (serv friendService) GetFriendsList(ctx context.Context, email string) ([]s
 	// Get friends list
 	friends, err := serv.repo.GetFriendsList(ctx, userID)
 	if err != nil {
-		log.Printf("Failed to get friends list for user ID %d: %v", userID, err)
+		logger.FromContext(ctx).Error().Err(err).Int("user_id", userID).Msg("Failed to get friends list")
 		return nil, errors.Wrap(err, response.ErrMsgGetFriendsList)
 	}
 
@@ -77,10 +116,15 @@ func (serv friendService) GetFriendsList(ctx context.Context, email string) ([]s
 
 // GetCommonFriends retrieves the list of common friends between two email addresses.
 func (serv friendService) GetCommonFriends(ctx context.Context, email1, email2 string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.GetCommonFriends")
+	defer span.End()
+
+	email1, email2 = emailaddr.Normalize(email1), emailaddr.Normalize(email2)
+
 	// Get user IDs from emails
 	user1, err := serv.repo.GetUserByEmail(ctx, email1)
 	if err != nil {
-		log.Printf("Failed to get user for email %s: %v", email1, err)
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email1)).Msg("Failed to get user by email")
 		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 	if user1 == nil {
@@ -90,7 +134,7 @@ func (serv friendService) GetCommonFriends(ctx context.Context, email1, email2 s
 
 	user2, err := serv.repo.GetUserByEmail(ctx, email2)
 	if err != nil {
-		log.Printf("Failed to get user for email %s: %v", email2, err)
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email2)).Msg("Failed to get user by email")
 		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 	if user2 == nil {
@@ -101,40 +145,353 @@ func (serv friendService) GetCommonFriends(ctx context.Context, email1, email2 s
 	// Get common friends list
 	commonFriends, err := serv.repo.GetCommonFriends(ctx, userID1, userID2)
 	if err != nil {
-		log.Printf("Failed to get common friends list: %v", err)
+		logger.FromContext(ctx).Error().Err(err).Int("user_id1", userID1).Int("user_id2", userID2).Msg("Failed to get common friends list")
 		return nil, errors.Wrap(err, response.ErrMsgGetCommonFriends)
 	}
 
 	return commonFriends, nil
 }
 
-// SubscribeUpdates subscribes requestor to updates from target.
-func (serv *friendService) SubscribeUpdates(ctx context.Context, requestor, target string) error {
+// GetFriendSuggestions retrieves the users suggested as friends for a given email address,
+// ranked by their number of mutual friends.
+func (serv friendService) GetFriendSuggestions(ctx context.Context, email string, limit, offset int) ([]repository.Suggestion, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.GetFriendSuggestions")
+	defer span.End()
+
+	email = emailaddr.Normalize(email)
+
+	user, err := serv.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user by email")
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	if user == nil {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+
+	suggestions, err := serv.repo.GetFriendSuggestions(ctx, user.ID, limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id", user.ID).Msg("Failed to get friend suggestions")
+		return nil, errors.Wrap(err, response.ErrMsgGetSuggestions)
+	}
+
+	return suggestions, nil
+}
+
+// GetRelationship retrieves the relationship between the viewer and the target, looked up as a
+// batch of one target. An unknown or inactive target is reported as not found.
+func (serv friendService) GetRelationship(ctx context.Context, viewer, target string) (*repository.Relationship, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.GetRelationship")
+	defer span.End()
+
+	viewer, target = emailaddr.Normalize(viewer), emailaddr.Normalize(target)
+	if viewer == target {
+		return nil, errors.New(response.ErrMsgSelfRelation)
+	}
+
+	result, err := serv.GetRelationships(ctx, viewer, []string{target})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Relationships) == 0 {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+	return &result.Relationships[0], nil
+}
+
+// GetRelationships retrieves the relationship between the viewer and each of up to
+// MaxRelationshipTargets targets. Targets that are unknown or inactive are listed as not found.
+func (serv friendService) GetRelationships(ctx context.Context, viewer string, targets []string) (*Relationships, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.GetRelationships")
+	defer span.End()
+
+	viewer = emailaddr.Normalize(viewer)
+	targets = emailaddr.NormalizeAll(targets)
+	if len(targets) > MaxRelationshipTargets {
+		return nil, errors.Errorf("%s: at most %d", response.ErrMsgTooManyTargets, MaxRelationshipTargets)
+	}
+
+	user, err := serv.repo.GetUserByEmail(ctx, viewer)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(viewer)).Msg("Failed to get user by email")
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	if user == nil || user.Status != repository.UserStatusActive {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+
+	relationships, err := serv.repo.GetRelationships(ctx, user.ID, targets)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id", user.ID).Int("targets", len(targets)).Msg("Failed to get relationships")
+		return nil, errors.Wrap(err, response.ErrMsgGetRelationship)
+	}
+
+	found := make(map[string]bool, len(relationships))
+	for _, rel := range relationships {
+		found[emailaddr.Normalize(rel.Target)] = true
+	}
+	result := &Relationships{Relationships: relationships, NotFound: []string{}}
+	for _, target := range targets {
+		if !found[target] {
+			result.NotFound = append(result.NotFound, target)
+		}
+	}
+	return result, nil
+}
+
+// SubscribeUpdates subscribes requestor to updates from target and returns the status of the
+// subscription. Subscriptions to a private target are pending until the target approves them.
+func (serv *friendService) SubscribeUpdates(ctx context.Context, requestor, target string) (string, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.SubscribeUpdates")
+	defer span.End()
+
+	requestor, target = emailaddr.Normalize(requestor), emailaddr.Normalize(target)
+
+	// Resolve the users first so that a missing user is reported as such
+	requestorUser, err := serv.repo.GetUserByEmail(ctx, requestor)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return "", errors.Wrap(err, response.ErrMsgUserNotFound)
+		}
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestor)).Msg("Failed to get subscription requestor")
+		return "", err
+	}
+	if err := checkActive(requestorUser); err != nil {
+		return "", err
+	}
+	if _, err := serv.repo.GetUserByEmail(ctx, target); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return "", errors.Wrap(err, response.ErrMsgUserNotFound)
+		}
+		logger.FromContext(ctx).Error().Err(err).Str("target", logger.Email(target)).Msg("Failed to get subscription target")
+		return "", err
+	}
+
 	// Check if the subscription already exists
 	exists, err := serv.repo.CheckSubscription(ctx, requestor, target)
 	if err != nil {
-		return errors.Wrap(err, response.ErrMsgCheckSubscription)
+		return "", errors.Wrap(err, response.ErrMsgCheckSubscription)
 	}
 	if exists {
-		return errors.New(response.ErrMsgAlreadySubscribed)
+		return "", errors.New(response.ErrMsgAlreadySubscribed)
 	}
 
-	// Subscribe to updates
-	err = serv.repo.SubscribeUpdates(ctx, requestor, target)
+	// Subscribe to updates, recording the event in the same transaction
+	var status string
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		var err error
+		if status, err = repo.SubscribeUpdates(ctx, requestor, target); err != nil {
+			return err
+		}
+		pending := status == repository.SubscriptionStatusPending
+		if pending {
+			if err := recordEvent(ctx, repo, events.SubscriptionRequested, events.SubscriptionRequestPayload{
+				Requestor: requestor,
+				Target:    target,
+			}); err != nil {
+				return err
+			}
+			return recordAudit(ctx, repo, events.SubscriptionRequested, requestor, target,
+				relationState{"subscribed": false, "pending": false}, relationState{"subscribed": false, "pending": true})
+		}
+		if err := recordEvent(ctx, repo, events.Subscribed, events.SubscribedPayload{
+			Requestor: requestor,
+			Target:    target,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.Subscribed, requestor, target,
+			relationState{"subscribed": false}, relationState{"subscribed": true})
+	})
 	if err != nil {
-		log.Printf("Failed to subscribe updates: %v", err)
-		return errors.Wrap(err, response.ErrMsgSubscribeUpdates)
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestor)).Str("target", logger.Email(target)).Msg("Failed to subscribe updates")
+		return "", errors.Wrap(err, response.ErrMsgSubscribeUpdates)
+	}
+	if status == repository.SubscriptionStatusApproved {
+		metrics.SubscriptionsCreated.Inc()
+	}
+	return status, nil
+}
+
+// SetPrivacy makes the user with the email private or public. The pending subscriptions to a
+// user made public are approved. The existing subscribers of a user made private are kept,
+// asked to be approved again or removed depending on the policy, which defaults to keep.
+func (serv *friendService) SetPrivacy(ctx context.Context, email string, private bool, policy string) (*PrivacyChange, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.SetPrivacy")
+	defer span.End()
+
+	if policy == "" {
+		policy = repository.FollowerPolicyKeep
+	}
+	switch policy {
+	case repository.FollowerPolicyKeep, repository.FollowerPolicyReview, repository.FollowerPolicyRemove:
+	default:
+		return nil, errors.New(response.ErrMsgInvalidFollowerPolicy)
+	}
+	// The policy only applies to users made private
+	if !private {
+		policy = ""
 	}
+
+	email = emailaddr.Normalize(email)
+	user, err := serv.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user by email")
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	if user == nil || user.Status != repository.UserStatusActive {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+
+	change := &PrivacyChange{Email: user.Email, Private: private, Policy: policy}
+	if user.Private == private {
+		return change, nil
+	}
+
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		n, err := repo.SetUserPrivacy(ctx, user.ID, private, policy)
+		if err != nil {
+			return err
+		}
+		change.Subscriptions = n
+		if err := recordEvent(ctx, repo, events.PrivacyChanged, events.PrivacyChangedPayload{
+			Email:         user.Email,
+			Private:       private,
+			Policy:        policy,
+			Subscriptions: n,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.PrivacyChanged, user.Email, user.Email,
+			relationState{"private": !private}, relationState{"private": private})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id", user.ID).Bool("private", private).Str("policy", policy).Msg("Failed to set privacy")
+		return nil, errors.Wrap(err, response.ErrMsgSetPrivacy)
+	}
+	logger.FromContext(ctx).Info().Int("user_id", user.ID).Bool("private", private).Str("policy", policy).
+		Int("subscriptions", change.Subscriptions).Msg("Privacy changed")
+
+	return change, nil
+}
+
+// ListSubscriptionRequests retrieves the pending subscriptions to the updates of the user with
+// the email, oldest first.
+func (serv *friendService) ListSubscriptionRequests(ctx context.Context, email string, limit, offset int) ([]repository.SubscriptionRequest, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.ListSubscriptionRequests")
+	defer span.End()
+
+	email = emailaddr.Normalize(email)
+	user, err := serv.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user by email")
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	if user == nil || user.Status != repository.UserStatusActive {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+
+	requests, err := serv.repo.ListSubscriptionRequests(ctx, user.ID, limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id", user.ID).Msg("Failed to get subscription requests")
+		return nil, errors.Wrap(err, response.ErrMsgGetSubscriptionRequests)
+	}
+	return requests, nil
+}
+
+// ApproveSubscription approves the pending subscription of requestor to the updates of target,
+// who starts sending them to requestor.
+func (serv *friendService) ApproveSubscription(ctx context.Context, requestor, target string) error {
+	ctx, span := tracing.Start(ctx, "FriendService.ApproveSubscription")
+	defer span.End()
+
+	requestor, target = emailaddr.Normalize(requestor), emailaddr.Normalize(target)
+
+	err := serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		approved, err := repo.ApproveSubscription(ctx, requestor, target)
+		if err != nil {
+			return err
+		}
+		if !approved {
+			return errors.New(response.ErrMsgSubscriptionNotPending)
+		}
+		if err := recordEvent(ctx, repo, events.SubscriptionApproved, events.SubscriptionRequestPayload{
+			Requestor: requestor,
+			Target:    target,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.SubscriptionApproved, requestor, target,
+			relationState{"subscribed": false, "pending": true}, relationState{"subscribed": true, "pending": false})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestor)).Str("target", logger.Email(target)).Msg("Failed to approve subscription")
+		return err
+	}
+	metrics.SubscriptionsCreated.Inc()
+
+	return nil
+}
+
+// DenySubscription deletes the pending subscription of requestor to the updates of target.
+func (serv *friendService) DenySubscription(ctx context.Context, requestor, target string) error {
+	ctx, span := tracing.Start(ctx, "FriendService.DenySubscription")
+	defer span.End()
+
+	requestor, target = emailaddr.Normalize(requestor), emailaddr.Normalize(target)
+
+	err := serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		denied, err := repo.DenySubscription(ctx, requestor, target)
+		if err != nil {
+			return err
+		}
+		if !denied {
+			return errors.New(response.ErrMsgSubscriptionNotPending)
+		}
+		if err := recordEvent(ctx, repo, events.SubscriptionDenied, events.SubscriptionRequestPayload{
+			Requestor: requestor,
+			Target:    target,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.SubscriptionDenied, requestor, target,
+			relationState{"subscribed": false, "pending": true}, relationState{"subscribed": false, "pending": false})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestor)).Str("target", logger.Email(target)).Msg("Failed to deny subscription")
+		return err
+	}
+
 	return nil
 }
 
-// BlockUpdates handles the business logic for blocking updates.
-func (serv *friendService) BlockUpdates(ctx context.Context, requestorEmail, targetEmail string) error {
+// BlockUpdates handles the business logic for blocking updates. The block lapses at the expiry
+// of the details, if any, and its category defaults to other.
+func (serv *friendService) BlockUpdates(ctx context.Context, requestorEmail, targetEmail string, details repository.BlockDetails) error {
+	ctx, span := tracing.Start(ctx, "FriendService.BlockUpdates")
+	defer span.End()
+
+	requestorEmail, targetEmail = emailaddr.Normalize(requestorEmail), emailaddr.Normalize(targetEmail)
+
+	if details.Category == "" {
+		details.Category = repository.BlockCategoryOther
+	}
+	if !isBlockCategory(details.Category) {
+		return errors.New(response.ErrMsgInvalidBlockCategory)
+	}
+	if details.ExpiresAt != nil && !details.ExpiresAt.After(time.Now()) {
+		return errors.New(response.ErrMsgInvalidExpiry)
+	}
+	details.Reason = strings.TrimSpace(details.Reason)
+
 	// Retrieve users by email
 	requestorUser, err := serv.repo.GetUserByEmail(ctx, requestorEmail)
 	if err != nil {
 		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
+	if err := checkActive(requestorUser); err != nil {
+		return err
+	}
 
 	targetUser, err := serv.repo.GetUserByEmail(ctx, targetEmail)
 	if err != nil {
@@ -150,29 +507,308 @@ func (serv *friendService) BlockUpdates(ctx context.Context, requestorEmail, tar
 		return errors.Wrap(err, response.ErrMsgCheckFriend)
 	}
 
-	if areFriends {
-		// Delete the subscription
-		err = serv.repo.DeleteSubscription(ctx, requestorID, targetID)
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		if areFriends {
+			// Delete the subscription
+			if err := repo.DeleteSubscription(ctx, requestorID, targetID); err != nil {
+				return errors.Wrap(err, response.ErrMsgRemoveSubscription)
+			}
+		}
+
+		// Block the user
+		if err := repo.BlockUser(ctx, requestorID, targetID, details); err != nil {
+			return errors.Wrap(err, response.ErrMsgBlockUser)
+		}
+
+		if err := recordEvent(ctx, repo, events.Blocked, events.BlockedPayload{
+			Requestor: requestorEmail,
+			Target:    targetEmail,
+			Category:  details.Category,
+			ExpiresAt: details.ExpiresAt,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.Blocked, requestorEmail, targetEmail,
+			relationState{"blocked": false, "friends": areFriends}, relationState{"blocked": true, "friends": areFriends})
+	})
+	if err != nil {
+		return err
+	}
+	metrics.BlocksCreated.Inc()
+
+	return nil
+}
+
+// ExpireBlocks deletes the blocks whose expiry passed, recording a BlockExpired event for each,
+// and returns how many were expired.
+func (serv *friendService) ExpireBlocks(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.ExpireBlocks")
+	defer span.End()
+
+	total := 0
+	for {
+		var expired []repository.ExpiredBlock
+		err := serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+			var err error
+			expired, err = repo.ExpireBlocks(ctx, expireBlocksBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, block := range expired {
+				if err := recordEvent(ctx, repo, events.BlockExpired, events.BlockExpiredPayload{
+					Requestor: block.Requestor,
+					Target:    block.Target,
+					Category:  block.Category,
+					ExpiresAt: block.ExpiresAt,
+				}); err != nil {
+					return err
+				}
+				if err := recordAudit(ctx, repo, events.BlockExpired, block.Requestor, block.Target,
+					relationState{"blocked": true}, relationState{"blocked": false}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Int("expired", total).Msg("Failed to expire blocks")
+			return total, err
+		}
+		total += len(expired)
+		metrics.BlocksExpired.Add(float64(len(expired)))
+		if len(expired) < expireBlocksBatchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// isBlockCategory reports whether category is a known block category.
+func isBlockCategory(category string) bool {
+	for _, known := range repository.BlockCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveFriend removes the friend connection between two users.
+func (serv *friendService) RemoveFriend(ctx context.Context, email1, email2 string) error {
+	ctx, span := tracing.Start(ctx, "FriendService.RemoveFriend")
+	defer span.End()
+
+	email1, email2 = emailaddr.Normalize(email1), emailaddr.Normalize(email2)
+
+	user1, err := serv.repo.GetUserByEmail(ctx, email1)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	user2, err := serv.repo.GetUserByEmail(ctx, email2)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+
+	// Remove the friend connection, recording the event in the same transaction
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		removed, err := repo.RemoveFriend(ctx, user1.ID, user2.ID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return errors.New(response.ErrMsgNotFriends)
+		}
+		if err := recordEvent(ctx, repo, events.FriendshipRemoved, events.FriendshipRemovedPayload{
+			Friends: []string{user1.Email, user2.Email},
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.FriendshipRemoved, user1.Email, user2.Email,
+			relationState{"friends": true}, relationState{"friends": false})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id1", user1.ID).Int("user_id2", user2.ID).Msg("Failed to remove friend connection")
+		return err
+	}
+
+	return nil
+}
+
+// UnblockUpdates lifts the block of the requestor on updates from the target.
+func (serv *friendService) UnblockUpdates(ctx context.Context, requestorEmail, targetEmail string) error {
+	ctx, span := tracing.Start(ctx, "FriendService.UnblockUpdates")
+	defer span.End()
+
+	requestorEmail, targetEmail = emailaddr.Normalize(requestorEmail), emailaddr.Normalize(targetEmail)
+
+	requestorUser, err := serv.repo.GetUserByEmail(ctx, requestorEmail)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	targetUser, err := serv.repo.GetUserByEmail(ctx, targetEmail)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+
+	// Remove the block, recording the event in the same transaction
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		unblocked, err := repo.UnblockUser(ctx, requestorUser.ID, targetUser.ID)
 		if err != nil {
-			return errors.Wrap(err, response.ErrMsgRemoveSubscription)
+			return err
+		}
+		if !unblocked {
+			return errors.New(response.ErrMsgNotBlocked)
 		}
+		if err := recordEvent(ctx, repo, events.Unblocked, events.UnblockedPayload{
+			Requestor: requestorEmail,
+			Target:    targetEmail,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, events.Unblocked, requestorEmail, targetEmail,
+			relationState{"blocked": true}, relationState{"blocked": false})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestorEmail)).Str("target", logger.Email(targetEmail)).Msg("Failed to unblock updates")
+		return err
+	}
+
+	return nil
+}
+
+// MuteUpdates stops the updates of target from reaching requestor until expiresAt, or until
+// unmuted when expiresAt is nil. Unlike a block, a mute leaves the friendship and subscriptions
+// untouched and is never revealed to target, so no event is recorded, only an audit entry.
+func (serv *friendService) MuteUpdates(ctx context.Context, requestorEmail, targetEmail string, expiresAt *time.Time) error {
+	ctx, span := tracing.Start(ctx, "FriendService.MuteUpdates")
+	defer span.End()
+
+	requestorEmail, targetEmail = emailaddr.Normalize(requestorEmail), emailaddr.Normalize(targetEmail)
+	if requestorEmail == targetEmail {
+		return errors.New(response.ErrMsgSelfRelation)
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return errors.New(response.ErrMsgInvalidExpiry)
 	}
 
-	// Block the user
-	err = serv.repo.BlockUser(ctx, requestorID, targetID)
+	requestorUser, err := serv.repo.GetUserByEmail(ctx, requestorEmail)
 	if err != nil {
-		return errors.Wrap(err, response.ErrMsgBlockUser)
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	if err := checkActive(requestorUser); err != nil {
+		return err
+	}
+	targetUser, err := serv.repo.GetUserByEmail(ctx, targetEmail)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
 
+	// Mute the target, recording the audit entry in the same transaction
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		if err := repo.MuteUser(ctx, requestorUser.ID, targetUser.ID, expiresAt); err != nil {
+			return err
+		}
+		return recordAudit(ctx, repo, audit.ActionMuted, requestorEmail, targetEmail,
+			relationState{"muted": false}, relationState{"muted": true})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestorEmail)).Str("target", logger.Email(targetEmail)).Msg("Failed to mute updates")
+		return err
+	}
 	return nil
 }
 
+// UnmuteUpdates lets the updates of target reach requestor again.
+func (serv *friendService) UnmuteUpdates(ctx context.Context, requestorEmail, targetEmail string) error {
+	ctx, span := tracing.Start(ctx, "FriendService.UnmuteUpdates")
+	defer span.End()
+
+	requestorEmail, targetEmail = emailaddr.Normalize(requestorEmail), emailaddr.Normalize(targetEmail)
+
+	requestorUser, err := serv.repo.GetUserByEmail(ctx, requestorEmail)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	targetUser, err := serv.repo.GetUserByEmail(ctx, targetEmail)
+	if err != nil {
+		return errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+
+	// Remove the mute, recording the audit entry in the same transaction
+	err = serv.repo.WithTx(ctx, func(repo repository.FriendRepository) error {
+		unmuted, err := repo.UnmuteUser(ctx, requestorUser.ID, targetUser.ID)
+		if err != nil {
+			return err
+		}
+		if !unmuted {
+			return errors.New(response.ErrMsgNotMuted)
+		}
+		return recordAudit(ctx, repo, audit.ActionUnmuted, requestorEmail, targetEmail,
+			relationState{"muted": true}, relationState{"muted": false})
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("requestor", logger.Email(requestorEmail)).Str("target", logger.Email(targetEmail)).Msg("Failed to unmute updates")
+		return err
+	}
+	return nil
+}
+
+// ListMutes retrieves the users currently muted by a user, most recent first.
+func (serv *friendService) ListMutes(ctx context.Context, email string) ([]repository.Mute, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.ListMutes")
+	defer span.End()
+
+	email = emailaddr.Normalize(email)
+	user, err := serv.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user by email")
+		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
+	}
+	if user == nil || user.Status != repository.UserStatusActive {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+
+	mutes, err := serv.repo.ListMutes(ctx, user.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id", user.ID).Msg("Failed to get mutes")
+		return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+	}
+	return mutes, nil
+}
+
 // GetEligibleRecipients retrieves all email addresses that can receive updates from an email address.
 func (serv *friendService) GetEligibleRecipients(ctx context.Context, senderEmail, text string) ([]string, error) {
+	result, err := serv.GetRecipients(ctx, senderEmail, text)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]string, 0, len(result.Recipients))
+	for _, r := range result.Recipients {
+		recipients = append(recipients, r.Email)
+	}
+	return recipients, nil
+}
+
+// GetRecipients computes the recipients of an update along with the reasons they receive it,
+// the candidates excluded with the reason why, and the mentions found in the text. Recipients
+// are the active friends and approved subscribers of the sender and the active users mentioned
+// in the text, except those who blocked the sender or whom the sender blocked. Friends of a
+// private sender are only recipients once the sender approved their subscription, befriending
+// a private user does not grant access to their updates. Users muting the sender are left out
+// of the recipients without being reported as excluded.
+func (serv *friendService) GetRecipients(ctx context.Context, senderEmail, text string) (*Recipients, error) {
+	ctx, span := tracing.Start(ctx, "FriendService.GetRecipients")
+	defer span.End()
+
+	senderEmail = emailaddr.Normalize(senderEmail)
+
 	senderUser, err := serv.repo.GetUserByEmail(ctx, senderEmail)
 	if err != nil {
 		return nil, errors.Wrap(err, response.ErrMsgGetUserByEmail)
 	}
+	if err := checkActive(senderUser); err != nil {
+		return nil, err
+	}
 
 	senderID := senderUser.ID
 
@@ -186,70 +822,138 @@ func (serv *friendService) GetEligibleRecipients(ctx context.Context, senderEmai
 		return nil, errors.Wrap(err, response.ErrMsgCheckSubscription)
 	}
 
-	mentionedEmails := extractMentionedEmails(text)
+	mutedBy, err := serv.repo.GetMutedBy(ctx, senderID)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgGetMutes)
+	}
+	muted := make(map[string]bool, len(mutedBy))
+	for _, email := range mutedBy {
+		muted[emailaddr.Normalize(email)] = true
+	}
 
-	recipientsSet := make(map[string]struct{})
-	for _, friendEmail := range friends {
-		blocked, err := serv.repo.HasBlockedUpdates(ctx, friendEmail, senderEmail)
-		if err != nil {
-			return nil, errors.Wrap(err, response.ErrMsgBlockUpdates)
-		}
-		if !blocked {
-			recipientsSet[friendEmail] = struct{}{}
+	mentions := mention.Parse(text)
+
+	// Candidates in order of discovery, with every reason they were found for
+	var candidates []Recipient
+	index := map[string]int{}
+	add := func(email, source string) {
+		email = emailaddr.Normalize(email)
+		i, ok := index[email]
+		if !ok {
+			i = len(candidates)
+			index[email] = i
+			candidates = append(candidates, Recipient{Email: email})
 		}
+		candidates[i].Sources = append(candidates[i].Sources, source)
 	}
 
+	for _, friendEmail := range friends {
+		add(friendEmail, SourceFriend)
+	}
 	for _, subscriberEmail := range subscribers {
-		blocked, err := serv.repo.HasBlockedUpdates(ctx, subscriberEmail, senderEmail)
+		add(subscriberEmail, SourceSubscription)
+	}
+	excluded := []Exclusion{}
+	for _, email := range mention.Emails(mentions) {
+		mentionedUser, err := serv.repo.GetUserByEmail(ctx, email)
+		if errors.Cause(err) == sql.ErrNoRows {
+			excluded = append(excluded, Exclusion{Email: email, Sources: []string{SourceMention}, Reason: ExclusionUnknownUser})
+			continue
+		}
 		if err != nil {
-			return nil, errors.Wrap(err, response.ErrMsgBlockUpdates)
+			logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get mentioned user")
+			return nil, err
 		}
-		if !blocked {
-			recipientsSet[subscriberEmail] = struct{}{}
+		if mentionedUser.Status != repository.UserStatusActive {
+			excluded = append(excluded, Exclusion{Email: mentionedUser.Email, Sources: []string{SourceMention}, Reason: ExclusionInactiveUser})
+			continue
 		}
+		add(mentionedUser.Email, SourceMention)
 	}
 
-	for _, email := range mentionedEmails {
-		mentionedUser, err := serv.repo.GetUserByEmail(ctx, email)
-		if err == nil {
-			blocked, err := serv.repo.HasBlockedUpdates(ctx, mentionedUser.Email, senderEmail)
-			if err != nil {
-				return nil, errors.Wrap(err, response.ErrMsgBlockUpdates)
-			}
-			if !blocked {
-				recipientsSet[mentionedUser.Email] = struct{}{}
-			}
+	recipients := make([]Recipient, 0, len(candidates))
+	for _, candidate := range candidates {
+		if muted[candidate.Email] {
+			continue
+		}
+
+		// Skip the friends of a private sender who did not approve them as subscribers
+		if senderUser.Private && len(candidate.Sources) == 1 && candidate.Sources[0] == SourceFriend {
+			excluded = append(excluded, Exclusion{Email: candidate.Email, Sources: candidate.Sources, Reason: ExclusionUnapprovedFriend})
+			continue
 		}
-	}
 
-	// Remove blocked recipients
-	for recipientEmail := range recipientsSet {
-		blocked, err := serv.repo.HasBlockedUpdates(ctx, senderEmail, recipientEmail)
+		// Skip recipients who blocked the sender
+		blocked, err := serv.repo.HasBlockedUpdates(ctx, candidate.Email, senderEmail)
 		if err != nil {
 			return nil, errors.Wrap(err, response.ErrMsgBlockUpdates)
 		}
 		if blocked {
-			delete(recipientsSet, recipientEmail)
+			excluded = append(excluded, Exclusion{Email: candidate.Email, Sources: candidate.Sources, Reason: ExclusionBlockedSender})
+			continue
 		}
-	}
 
-	recipients := make([]string, 0, len(recipientsSet))
-	for email := range recipientsSet {
-		recipients = append(recipients, email)
+		// Skip recipients the sender blocked
+		blocked, err = serv.repo.HasBlockedUpdates(ctx, senderEmail, candidate.Email)
+		if err != nil {
+			return nil, errors.Wrap(err, response.ErrMsgBlockUpdates)
+		}
+		if blocked {
+			excluded = append(excluded, Exclusion{Email: candidate.Email, Sources: candidate.Sources, Reason: ExclusionBlockedBySender})
+			continue
+		}
+
+		recipients = append(recipients, candidate)
 	}
+	metrics.RecipientsFanout.Observe(float64(len(recipients)))
 
-	return recipients, nil
+	return &Recipients{Recipients: recipients, Excluded: excluded, Mentions: mentions}, nil
 }
 
-func extractMentionedEmails(text string) []string {
-	// Regular expression pattern for matching email addresses
-	emailPattern := `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Z|a-z]{2,}\b`
+// checkActive returns an error unless the account of the user acting in a request, e.g. the
+// requestor of a relation or the sender of an update, is active. Deactivated, suspended and
+// deleted users may neither change their relations nor post.
+func checkActive(user *models.User) error {
+	if user.Status != repository.UserStatusActive {
+		return errors.New(response.ErrMsgInactiveUser)
+	}
+	return nil
+}
 
-	// Compile the regex pattern
-	regex := regexp.MustCompile(emailPattern)
+// recordEvent writes a domain event to the outbox through repo, which should be bound to
+// the transaction making the change so the event is only published if the change commits.
+func recordEvent(ctx context.Context, repo repository.FriendRepository, eventType string, payload interface{}) error {
+	event, err := events.New(eventType, payload)
+	if err != nil {
+		return err
+	}
+	return repo.InsertOutboxEvent(ctx, event)
+}
 
-	// Find all email addresses in the text
-	emails := regex.FindAllString(text, -1)
+// relationState is the state of the relation between the requestor and the target of a
+// change, as recorded in the audit log.
+type relationState map[string]bool
 
-	return emails
+// recordAudit appends an entry to the audit log through repo, which should be bound to the
+// transaction making the change. The actor, request ID and client IP are taken from ctx.
+func recordAudit(ctx context.Context, repo repository.FriendRepository, action, requestor, target string, before, after relationState) error {
+	src := audit.FromContext(ctx)
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	return repo.InsertAuditEntry(ctx, repository.AuditEntry{
+		Actor:     src.Actor,
+		Action:    action,
+		Requestor: requestor,
+		Target:    target,
+		RequestID: src.RequestID,
+		ClientIP:  src.ClientIP,
+		Before:    beforeJSON,
+		After:     afterJSON,
+	})
 }
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/repository"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -29,20 +31,122 @@ func (m *MockFriendService) GetCommonFriends(ctx context.Context, email1, email2
 	return args.Get(0).([]string), args.Error(1)
 }
 
+// GetFriendSuggestions mocks the GetFriendSuggestions method of the FriendService interface.
+func (m *MockFriendService) GetFriendSuggestions(ctx context.Context, email string, limit, offset int) ([]repository.Suggestion, error) {
+	args := m.Called(ctx, email, limit, offset)
+	return args.Get(0).([]repository.Suggestion), args.Error(1)
+}
+
+// GetRelationship mocks the GetRelationship method of the FriendService interface.
+func (m *MockFriendService) GetRelationship(ctx context.Context, viewer, target string) (*repository.Relationship, error) {
+	args := m.Called(ctx, viewer, target)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Relationship), args.Error(1)
+}
+
+// GetRelationships mocks the GetRelationships method of the FriendService interface.
+func (m *MockFriendService) GetRelationships(ctx context.Context, viewer string, targets []string) (*Relationships, error) {
+	args := m.Called(ctx, viewer, targets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Relationships), args.Error(1)
+}
+
 // SubscribeUpdates mocks the SubscribeUpdates method of the FriendService interface.
-func (m *MockFriendService) SubscribeUpdates(ctx context.Context, requestor, target string) error {
+func (m *MockFriendService) SubscribeUpdates(ctx context.Context, requestor, target string) (string, error) {
+	args := m.Called(ctx, requestor, target)
+	return args.String(0), args.Error(1)
+}
+
+// SetPrivacy mocks the SetPrivacy method of the FriendService interface.
+func (m *MockFriendService) SetPrivacy(ctx context.Context, email string, private bool, policy string) (*PrivacyChange, error) {
+	args := m.Called(ctx, email, private, policy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PrivacyChange), args.Error(1)
+}
+
+// ListSubscriptionRequests mocks the ListSubscriptionRequests method of the FriendService interface.
+func (m *MockFriendService) ListSubscriptionRequests(ctx context.Context, email string, limit, offset int) ([]repository.SubscriptionRequest, error) {
+	args := m.Called(ctx, email, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.SubscriptionRequest), args.Error(1)
+}
+
+// ApproveSubscription mocks the ApproveSubscription method of the FriendService interface.
+func (m *MockFriendService) ApproveSubscription(ctx context.Context, requestor, target string) error {
+	args := m.Called(ctx, requestor, target)
+	return args.Error(0)
+}
+
+// DenySubscription mocks the DenySubscription method of the FriendService interface.
+func (m *MockFriendService) DenySubscription(ctx context.Context, requestor, target string) error {
 	args := m.Called(ctx, requestor, target)
 	return args.Error(0)
 }
 
 // BlockUpdates mocks the BlockUpdates method of the FriendService interface.
-func (m *MockFriendService) BlockUpdates(ctx context.Context, requestor, target string) error {
+func (m *MockFriendService) BlockUpdates(ctx context.Context, requestor, target string, details repository.BlockDetails) error {
+	args := m.Called(ctx, requestor, target, details)
+	return args.Error(0)
+}
+
+// ExpireBlocks mocks the ExpireBlocks method of the FriendService interface.
+func (m *MockFriendService) ExpireBlocks(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+// RemoveFriend mocks the RemoveFriend method of the FriendService interface.
+func (m *MockFriendService) RemoveFriend(ctx context.Context, email1, email2 string) error {
+	args := m.Called(ctx, email1, email2)
+	return args.Error(0)
+}
+
+// UnblockUpdates mocks the UnblockUpdates method of the FriendService interface.
+func (m *MockFriendService) UnblockUpdates(ctx context.Context, requestor, target string) error {
 	args := m.Called(ctx, requestor, target)
 	return args.Error(0)
 }
 
+// MuteUpdates mocks the MuteUpdates method of the FriendService interface.
+func (m *MockFriendService) MuteUpdates(ctx context.Context, requestor, target string, expiresAt *time.Time) error {
+	args := m.Called(ctx, requestor, target, expiresAt)
+	return args.Error(0)
+}
+
+// UnmuteUpdates mocks the UnmuteUpdates method of the FriendService interface.
+func (m *MockFriendService) UnmuteUpdates(ctx context.Context, requestor, target string) error {
+	args := m.Called(ctx, requestor, target)
+	return args.Error(0)
+}
+
+// ListMutes mocks the ListMutes method of the FriendService interface.
+func (m *MockFriendService) ListMutes(ctx context.Context, email string) ([]repository.Mute, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.Mute), args.Error(1)
+}
+
 // GetEligibleRecipients mocks the GetEligibleRecipients method of the FriendService interface.
 func (m *MockFriendService) GetEligibleRecipients(ctx context.Context, senderEmail, text string) ([]string, error) {
 	args := m.Called(ctx, senderEmail, text)
 	return args.Get(0).([]string), args.Error(1)
 }
+
+// GetRecipients mocks the GetRecipients method of the FriendService interface.
+func (m *MockFriendService) GetRecipients(ctx context.Context, senderEmail, text string) (*Recipients, error) {
+	args := m.Called(ctx, senderEmail, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Recipients), args.Error(1)
+}
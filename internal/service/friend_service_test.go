@@ -2,10 +2,18 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/audit"
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/mention"
 	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
 	"github.com/boldnguyen/friend-management/internal/pkg/response"
 	"github.com/boldnguyen/friend-management/internal/repository"
 	"github.com/stretchr/testify/mock"
@@ -32,7 +40,7 @@ func TestCreateFriend(t *testing.T) {
 			email2: "test2@example.com",
 			mockFn: mockRepoService{
 				expGetUserByEmail: map[string]*models.User{
-					"test1@example.com": {ID: 1},
+					"test1@example.com": {ID: 1, Status: repository.UserStatusActive},
 					"test2@example.com": {ID: 2},
 				},
 				expCheckFriends: false,
@@ -45,7 +53,7 @@ func TestCreateFriend(t *testing.T) {
 			email2: "test2@example.com",
 			mockFn: mockRepoService{
 				expGetUserByEmail: map[string]*models.User{
-					"test1@example.com": {ID: 1},
+					"test1@example.com": {ID: 1, Status: repository.UserStatusActive},
 					"test2@example.com": {ID: 2},
 				},
 				expCheckFriends: false,
@@ -58,7 +66,7 @@ func TestCreateFriend(t *testing.T) {
 			email2: "test2@example.com",
 			mockFn: mockRepoService{
 				expGetUserByEmail: map[string]*models.User{
-					"test1@example.com": {ID: 1},
+					"test1@example.com": {ID: 1, Status: repository.UserStatusActive},
 					"test2@example.com": {ID: 2},
 				},
 				expCheckFriends: true, // Users are already friends
@@ -84,6 +92,16 @@ func TestCreateFriend(t *testing.T) {
 			// Expect AddFriend only if not already friends and user found
 			if !tc.mockFn.expCheckFriends && tc.mockFn.expGetUserByEmail[tc.email1] != nil && tc.mockFn.expGetUserByEmail[tc.email2] != nil {
 				mockRepo.On("AddFriend", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(tc.mockFn.expAddFriendErr).Once()
+
+				// Expect the FriendshipCreated event to be recorded once the connection is added
+				if tc.mockFn.expAddFriendErr == nil {
+					mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+						return e.Type == events.FriendshipCreated
+					})).Return(nil).Once()
+					mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+						return e.Action == events.FriendshipCreated
+					})).Return(nil).Once()
+				}
 			}
 
 			// When
@@ -131,6 +149,16 @@ func TestGetFriendsList(t *testing.T) {
 			expError: "",
 			expList:  []string{"friend1@example.com", "friend2@example.com"},
 		},
+		"mixed_case_email": {
+			email: " Test@Example.COM",
+			mockFn: mockRepoService{
+				expGetUserByEmail: map[string]*models.User{
+					" Test@Example.COM": {ID: 1},
+				},
+				expGetFriendsList: []string{"friend1@example.com"},
+			},
+			expList: []string{"friend1@example.com"},
+		},
 		"user_not_found": {
 			email: "test@example.com",
 			mockFn: mockRepoService{
@@ -151,8 +179,8 @@ func TestGetFriendsList(t *testing.T) {
 			mockRepo := new(repository.MockRepo)
 			friendService := NewFriendService(mockRepo)
 
-			// Set up mock expectations for GetUserByEmail
-			mockRepo.On("GetUserByEmail", mock.Anything, tc.email).Return(tc.mockFn.expGetUserByEmail[tc.email], nil).Once()
+			// Set up mock expectations for GetUserByEmail, which is given the canonical email
+			mockRepo.On("GetUserByEmail", mock.Anything, emailaddr.Normalize(tc.email)).Return(tc.mockFn.expGetUserByEmail[tc.email], nil).Once()
 
 			if tc.mockFn.expGetUserByEmail[tc.email] != nil {
 				// Set up mock expectations for GetFriendsList if user found
@@ -250,30 +278,239 @@ func TestGetCommonFriends(t *testing.T) {
 	}
 }
 
+// TestGetFriendSuggestions tests the GetFriendSuggestions method of the FriendService.
+func TestGetFriendSuggestions(t *testing.T) {
+	tcs := map[string]struct {
+		user        *models.User            // User returned by GetUserByEmail
+		suggestions []repository.Suggestion // Suggestions returned by GetFriendSuggestions
+		repoErr     error                   // Error returned by GetFriendSuggestions
+		expError    string                  // Expected error message
+	}{
+		"success": {
+			user: &models.User{ID: 1, Email: "andy@example.com"},
+			suggestions: []repository.Suggestion{
+				{Email: "kate@example.com", MutualCount: 2, MutualFriends: []string{"john@example.com", "lisa@example.com"}},
+			},
+		},
+		"user_not_found": {
+			expError: response.ErrMsgUserNotFound,
+		},
+		"repository_error": {
+			user:     &models.User{ID: 1, Email: "andy@example.com"},
+			repoErr:  errors.New("connection refused"),
+			expError: response.ErrMsgGetSuggestions,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On("GetUserByEmail", mock.Anything, "andy@example.com").Return(tc.user, nil).Once()
+			if tc.user != nil {
+				mockRepo.On("GetFriendSuggestions", mock.Anything, 1, 20, 40).Return(tc.suggestions, tc.repoErr).Once()
+			}
+
+			// When
+			suggestions, err := friendService.GetFriendSuggestions(context.Background(), " Andy@Example.com", 20, 40)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				require.Nil(t, suggestions)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.suggestions, suggestions)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetRelationship tests the GetRelationship method of the FriendService.
+func TestGetRelationship(t *testing.T) {
+	rel := repository.Relationship{Email: "andy@example.com", Target: "kate@example.com", Following: true, MutualFriends: 2}
+
+	tcs := map[string]struct {
+		target   string                    // Target email
+		expCall  bool                      // Whether the repository is expected to be called
+		found    []repository.Relationship // Relationships returned by the repository
+		repoErr  error                     // Error returned by the repository
+		expError string                    // Expected error message
+	}{
+		"success": {
+			target:  "Kate@Example.com",
+			expCall: true,
+			found:   []repository.Relationship{rel},
+		},
+		"same_user": {
+			target:   "andy@example.com",
+			expError: response.ErrMsgSelfRelation,
+		},
+		"user_not_found": {
+			target:   "kate@example.com",
+			expCall:  true,
+			found:    []repository.Relationship{},
+			expError: response.ErrMsgUserNotFound,
+		},
+		"repository_error": {
+			target:   "kate@example.com",
+			expCall:  true,
+			repoErr:  errors.New(response.ErrMsgGetRelationship),
+			expError: response.ErrMsgGetRelationship,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+			if tc.expCall {
+				mockRepo.On("GetUserByEmail", mock.Anything, "andy@example.com").
+					Return(&models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive}, nil).Once()
+				mockRepo.On("GetRelationships", mock.Anything, 1, []string{"kate@example.com"}).Return(tc.found, tc.repoErr).Once()
+			}
+
+			// When
+			got, err := friendService.GetRelationship(context.Background(), " Andy@Example.com", tc.target)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				require.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, &rel, got)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetRelationships tests the GetRelationships method of the FriendService.
+func TestGetRelationships(t *testing.T) {
+	viewer := &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive}
+	kate := repository.Relationship{Email: "andy@example.com", Target: "kate@example.com", Friends: true, MutualFriends: 3}
+
+	tooMany := make([]string, MaxRelationshipTargets+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("user%d@example.com", i)
+	}
+
+	tcs := map[string]struct {
+		targets     []string                  // Requested targets
+		viewer      *models.User              // Viewer returned by GetUserByEmail
+		expTargets  []string                  // Expected targets passed to the repository
+		found       []repository.Relationship // Relationships returned by the repository
+		expNotFound []string                  // Expected targets not found
+		expError    string                    // Expected error message
+	}{
+		"success": {
+			targets:     []string{"Kate@Example.com", "ghost@example.com", "kate@example.com"},
+			viewer:      viewer,
+			expTargets:  []string{"kate@example.com", "ghost@example.com"},
+			found:       []repository.Relationship{kate},
+			expNotFound: []string{"ghost@example.com"},
+		},
+		"too_many_targets": {
+			targets:  tooMany,
+			expError: response.ErrMsgTooManyTargets,
+		},
+		"inactive_viewer": {
+			targets:  []string{"kate@example.com"},
+			viewer:   &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusDeactivated},
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+			if tc.viewer != nil {
+				mockRepo.On("GetUserByEmail", mock.Anything, "andy@example.com").Return(tc.viewer, nil).Once()
+			}
+			if tc.expTargets != nil {
+				mockRepo.On("GetRelationships", mock.Anything, 1, tc.expTargets).Return(tc.found, nil).Once()
+			}
+
+			// When
+			result, err := friendService.GetRelationships(context.Background(), "Andy@Example.com", tc.targets)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				require.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.found, result.Relationships)
+				require.Equal(t, tc.expNotFound, result.NotFound)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 // TestSubscribeUpdates tests the SubscribeUpdates method of the FriendService.
 func TestSubscribeUpdates(t *testing.T) {
 	type mockRepoService struct {
-		expCheckSubscription   bool  // Whether the CheckSubscription method is expected to be called
-		expExists              bool  // Expected return value of CheckSubscription method
-		expSubscribeUpdatesErr error // Expected error returned by SubscribeUpdates method
+		expCheckSubscription   bool   // Whether the CheckSubscription method is expected to be called
+		expExists              bool   // Expected return value of CheckSubscription method
+		expStatus              string // Expected status returned by SubscribeUpdates method
+		expSubscribeUpdatesErr error  // Expected error returned by SubscribeUpdates method
 	}
 
 	// Define test cases for different scenarios
 	tcs := map[string]struct {
 		requestor string          // Requestor's email
 		target    string          // Target's email
+		targetErr error           // Error returned when looking up the target
 		mockFn    mockRepoService // Function to set up mock
+		expStatus string          // Expected status of the subscription
 		expError  string          // Expected error message
 	}{
+		"target_not_found": {
+			requestor: "requestor@example.com",
+			target:    "nobody@example.com",
+			targetErr: sql.ErrNoRows,
+			expError:  response.ErrMsgUserNotFound,
+		},
+		"error_get_target": {
+			requestor: "requestor@example.com",
+			target:    "target@example.com",
+			targetErr: errors.New(response.ErrMsgGetUserByEmail),
+			expError:  response.ErrMsgGetUserByEmail,
+		},
 		"success": {
 			requestor: "requestor@example.com",
 			target:    "target@example.com",
 			mockFn: mockRepoService{
 				expCheckSubscription:   true,
 				expExists:              false,
+				expStatus:              repository.SubscriptionStatusApproved,
 				expSubscribeUpdatesErr: nil,
 			},
-			expError: "",
+			expStatus: repository.SubscriptionStatusApproved,
+			expError:  "",
+		},
+		"pending_private_target": {
+			requestor: "requestor@example.com",
+			target:    "private@example.com",
+			mockFn: mockRepoService{
+				expCheckSubscription:   true,
+				expExists:              false,
+				expStatus:              repository.SubscriptionStatusPending,
+				expSubscribeUpdatesErr: nil,
+			},
+			expStatus: repository.SubscriptionStatusPending,
+			expError:  "",
 		},
 		"already_subscribed": {
 			requestor: "requestor@example.com",
@@ -313,16 +550,41 @@ func TestSubscribeUpdates(t *testing.T) {
 			mockRepo := new(repository.MockRepo)
 			friendService := NewFriendService(mockRepo)
 
+			mockRepo.On("GetUserByEmail", mock.Anything, tc.requestor).
+				Return(&models.User{ID: 1, Email: tc.requestor, Status: repository.UserStatusActive}, nil).Once()
+			var target *models.User
+			if tc.targetErr == nil {
+				target = &models.User{ID: 2, Email: tc.target}
+			}
+			mockRepo.On("GetUserByEmail", mock.Anything, tc.target).Return(target, tc.targetErr).Once()
+
 			// Set up mock expectations for CheckSubscription
-			mockRepo.On("CheckSubscription", mock.Anything, tc.requestor, tc.target).Return(tc.mockFn.expExists, nil).Once()
+			if tc.mockFn.expCheckSubscription {
+				mockRepo.On("CheckSubscription", mock.Anything, tc.requestor, tc.target).Return(tc.mockFn.expExists, nil).Once()
+			}
 
 			// Set up mock expectations for SubscribeUpdates
-			if !tc.mockFn.expExists {
-				mockRepo.On("SubscribeUpdates", mock.Anything, tc.requestor, tc.target).Return(tc.mockFn.expSubscribeUpdatesErr).Once()
+			if tc.mockFn.expCheckSubscription && !tc.mockFn.expExists {
+				mockRepo.On("SubscribeUpdates", mock.Anything, tc.requestor, tc.target).Return(tc.mockFn.expStatus, tc.mockFn.expSubscribeUpdatesErr).Once()
+
+				// Expect the Subscribed event, or SubscriptionRequested when pending, to be
+				// recorded once the subscription is added
+				if tc.mockFn.expSubscribeUpdatesErr == nil {
+					eventType := events.Subscribed
+					if tc.mockFn.expStatus == repository.SubscriptionStatusPending {
+						eventType = events.SubscriptionRequested
+					}
+					mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+						return e.Type == eventType
+					})).Return(nil).Once()
+					mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+						return e.Action == eventType
+					})).Return(nil).Once()
+				}
 			}
 
 			// When
-			err := friendService.SubscribeUpdates(context.Background(), tc.requestor, tc.target)
+			status, err := friendService.SubscribeUpdates(context.Background(), tc.requestor, tc.target)
 
 			// Then
 			if tc.expError != "" {
@@ -330,6 +592,7 @@ func TestSubscribeUpdates(t *testing.T) {
 				require.Contains(t, err.Error(), tc.expError)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tc.expStatus, status)
 			}
 
 			// Assert that the expected calls to the mock repository were made
@@ -338,71 +601,190 @@ func TestSubscribeUpdates(t *testing.T) {
 	}
 }
 
+// TestSetPrivacy tests the SetPrivacy method of the FriendService.
+func TestSetPrivacy(t *testing.T) {
+	tcs := map[string]struct {
+		user      *models.User // User returned by GetUserByEmail
+		private   bool         // Requested privacy
+		policy    string       // Requested policy for the existing subscribers
+		expPolicy string       // Policy expected to be applied, empty when SetUserPrivacy is not called
+		changed   int          // Subscriptions changed by SetUserPrivacy
+		expChange *PrivacyChange
+		expError  string
+	}{
+		"private_default_policy": {
+			user:      &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive},
+			private:   true,
+			expPolicy: repository.FollowerPolicyKeep,
+			expChange: &PrivacyChange{Email: "andy@example.com", Private: true, Policy: repository.FollowerPolicyKeep},
+		},
+		"private_review_policy": {
+			user:      &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive},
+			private:   true,
+			policy:    repository.FollowerPolicyReview,
+			expPolicy: repository.FollowerPolicyReview,
+			changed:   3,
+			expChange: &PrivacyChange{Email: "andy@example.com", Private: true, Policy: repository.FollowerPolicyReview, Subscriptions: 3},
+		},
+		"public_approves_pending": {
+			user:      &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive, Private: true},
+			private:   false,
+			policy:    repository.FollowerPolicyRemove,
+			expPolicy: "",
+			changed:   2,
+			expChange: &PrivacyChange{Email: "andy@example.com", Private: false, Subscriptions: 2},
+		},
+		"unchanged": {
+			user:      &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive, Private: true},
+			private:   true,
+			expChange: &PrivacyChange{Email: "andy@example.com", Private: true, Policy: repository.FollowerPolicyKeep},
+		},
+		"invalid_policy": {
+			private:  true,
+			policy:   "forget",
+			expError: response.ErrMsgInvalidFollowerPolicy,
+		},
+		"inactive_user": {
+			user:     &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusSuspended},
+			private:  true,
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			if tc.user != nil {
+				mockRepo.On("GetUserByEmail", mock.Anything, "andy@example.com").Return(tc.user, nil).Once()
+			}
+			if tc.expChange != nil && tc.user.Private != tc.private {
+				mockRepo.On("SetUserPrivacy", mock.Anything, 1, tc.private, tc.expPolicy).Return(tc.changed, nil).Once()
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					return e.Type == events.PrivacyChanged
+				})).Return(nil).Once()
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == events.PrivacyChanged
+				})).Return(nil).Once()
+			}
+
+			// When
+			change, err := friendService.SetPrivacy(context.Background(), "Andy@Example.com", tc.private, tc.policy)
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expChange, change)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestApproveSubscription tests the ApproveSubscription and DenySubscription methods of the FriendService.
+func TestApproveSubscription(t *testing.T) {
+	tcs := map[string]struct {
+		deny      bool   // Whether the request is denied rather than approved
+		pending   bool   // Whether the repository finds a pending subscription
+		expMethod string // Repository method expected to be called
+		expEvent  string // Event expected to be recorded
+		expError  string // Expected error message
+	}{
+		"approve": {
+			pending:   true,
+			expMethod: "ApproveSubscription",
+			expEvent:  events.SubscriptionApproved,
+		},
+		"deny": {
+			deny:      true,
+			pending:   true,
+			expMethod: "DenySubscription",
+			expEvent:  events.SubscriptionDenied,
+		},
+		"approve_not_pending": {
+			expMethod: "ApproveSubscription",
+			expError:  response.ErrMsgSubscriptionNotPending,
+		},
+		"deny_not_pending": {
+			deny:      true,
+			expMethod: "DenySubscription",
+			expError:  response.ErrMsgSubscriptionNotPending,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On(tc.expMethod, mock.Anything, "requestor@example.com", "target@example.com").Return(tc.pending, nil).Once()
+			if tc.pending {
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					return e.Type == tc.expEvent
+				})).Return(nil).Once()
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == tc.expEvent
+				})).Return(nil).Once()
+			}
+
+			// When
+			var err error
+			if tc.deny {
+				err = friendService.DenySubscription(context.Background(), "Requestor@example.com", "target@example.com")
+			} else {
+				err = friendService.ApproveSubscription(context.Background(), "Requestor@example.com", "target@example.com")
+			}
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 // TestBlockUpdates tests the BlockUpdates method of the FriendService.
 func TestBlockUpdates(t *testing.T) {
-	type mockRepoService struct {
-		expGetUserByEmail  map[string]*models.User // Expected GetUserByEmail return values
-		expCheckFriends    bool                    // Expected CheckFriends return value
-		expBlockUpdatesErr error                   // Expected BlockUpdates error
-	}
-	// Define test cases for different scenarios
 	tcs := map[string]struct {
-		requestor string          // Requestor's email
-		target    string          // Target's email
-		mockFn    mockRepoService // Function to set up mock
-		expError  string          // Expected error message
+		requestorErr error  // Error returned when looking up the requestor
+		friends      bool   // Whether the users are friends
+		checkErr     error  // Error returned by CheckFriends
+		blockErr     error  // Error returned by BlockUser
+		expCheck     bool   // Whether CheckFriends is expected to be called
+		expBlock     bool   // Whether BlockUser is expected to be called
+		expError     string // Expected error message
 	}{
 		"success": {
-			requestor: "requestor@example.com",
-			target:    "target@example.com",
-			mockFn: mockRepoService{
-				expGetUserByEmail: map[string]*models.User{
-					"requestor@example.com": {ID: 1},
-					"target@example.com":    {ID: 2},
-				},
-				expCheckFriends:    true, // Users are friends
-				expBlockUpdatesErr: nil,
-			},
-			expError: "",
+			expCheck: true,
+			expBlock: true,
+		},
+		"success_friends": {
+			friends:  true,
+			expCheck: true,
+			expBlock: true,
 		},
 		"error_get_user_by_email": {
-			requestor: "requestor@example.com",
-			target:    "target@example.com",
-			mockFn: mockRepoService{
-				expGetUserByEmail: map[string]*models.User{
-					"requestor@example.com": {}, // Requestor not found
-					"target@example.com":    {ID: 2},
-				},
-				expCheckFriends:    false,
-				expBlockUpdatesErr: nil,
-			},
-			expError: response.ErrMsgCheckFriend,
+			requestorErr: errors.New("connection refused"),
+			expError:     response.ErrMsgGetUserByEmail,
 		},
 		"error_check_friends": {
-			requestor: "requestor@example.com",
-			target:    "target@example.com",
-			mockFn: mockRepoService{
-				expGetUserByEmail: map[string]*models.User{
-					"requestor@example.com": {ID: 1},
-					"target@example.com":    {ID: 2},
-				},
-				expCheckFriends:    false, // Users are not friends
-				expBlockUpdatesErr: nil,
-			},
+			checkErr: errors.New("connection refused"),
+			expCheck: true,
 			expError: response.ErrMsgCheckFriend,
 		},
-		"error_block_updates": {
-			requestor: "requestor@example.com",
-			target:    "target@example.com",
-			mockFn: mockRepoService{
-				expGetUserByEmail: map[string]*models.User{
-					"requestor@example.com": {ID: 1},
-					"target@example.com":    {ID: 2},
-				},
-				expCheckFriends:    true, // Users are friends
-				expBlockUpdatesErr: errors.New("block updates failed"),
-			},
-			expError: response.ErrMsgBlockUpdates,
+		"error_block_user": {
+			blockErr: errors.New("connection refused"),
+			expCheck: true,
+			expBlock: true,
+			expError: response.ErrMsgBlockUser,
 		},
 	}
 
@@ -411,21 +793,34 @@ func TestBlockUpdates(t *testing.T) {
 			// Given
 			mockRepo := new(repository.MockRepo)
 			friendService := NewFriendService(mockRepo)
+			details := repository.BlockDetails{Category: repository.BlockCategoryOther}
 
-			// Set up mock expectations for GetUserByEmail
-			mockRepo.On("GetUserByEmail", mock.Anything, tc.requestor).Return(tc.mockFn.expGetUserByEmail[tc.requestor], nil).Once()
-			mockRepo.On("GetUserByEmail", mock.Anything, tc.target).Return(tc.mockFn.expGetUserByEmail[tc.target], nil).Once()
-
-			// Set up mock expectations for CheckFriends
-			mockRepo.On("CheckFriends", mock.Anything, mock.AnythingOfType("int"), mock.AnythingOfType("int")).Return(tc.mockFn.expCheckFriends, nil).Once()
-
-			// Set up mock expectations for BlockUpdates
-			if tc.mockFn.expCheckFriends && tc.mockFn.expGetUserByEmail[tc.requestor] != nil && tc.mockFn.expGetUserByEmail[tc.target] != nil {
-				mockRepo.On("BlockUpdates", mock.Anything, tc.requestor, tc.target).Return(tc.mockFn.expBlockUpdatesErr).Once()
+			var requestor *models.User
+			if tc.requestorErr == nil {
+				requestor = &models.User{ID: 1, Status: repository.UserStatusActive}
+			}
+			mockRepo.On("GetUserByEmail", mock.Anything, "requestor@example.com").Return(requestor, tc.requestorErr).Once()
+			if tc.expCheck {
+				mockRepo.On("GetUserByEmail", mock.Anything, "target@example.com").Return(&models.User{ID: 2}, nil).Once()
+				mockRepo.On("CheckFriends", mock.Anything, 1, 2).Return(tc.friends, tc.checkErr).Once()
+			}
+			if tc.friends {
+				mockRepo.On("DeleteSubscription", mock.Anything, 1, 2).Return(nil).Once()
+			}
+			if tc.expBlock {
+				mockRepo.On("BlockUser", mock.Anything, 1, 2, details).Return(tc.blockErr).Once()
+			}
+			if tc.expBlock && tc.blockErr == nil {
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					return e.Type == events.Blocked
+				})).Return(nil).Once()
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == events.Blocked && e.Requestor == "requestor@example.com" && e.Target == "target@example.com"
+				})).Return(nil).Once()
 			}
 
 			// When
-			err := friendService.BlockUpdates(context.Background(), tc.requestor, tc.target)
+			err := friendService.BlockUpdates(context.Background(), "Requestor@example.com", "target@example.com", repository.BlockDetails{})
 
 			// Then
 			if tc.expError != "" {
@@ -434,12 +829,157 @@ func TestBlockUpdates(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
 
-			// Assert that the expected calls to the mock repository were made
+// TestBlockUpdatesWithDetails tests that the BlockUpdates method of the FriendService validates
+// and stores the category, reason and expiry of the block.
+func TestBlockUpdatesWithDetails(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tcs := map[string]struct {
+		details    repository.BlockDetails // Requested details
+		expDetails repository.BlockDetails // Details expected to be stored
+		expError   string                  // Expected error message
+	}{
+		"default_category": {
+			expDetails: repository.BlockDetails{Category: repository.BlockCategoryOther},
+		},
+		"temporary_block": {
+			details:    repository.BlockDetails{Category: repository.BlockCategorySpam, Reason: "  Sends ads ", ExpiresAt: &future},
+			expDetails: repository.BlockDetails{Category: repository.BlockCategorySpam, Reason: "Sends ads", ExpiresAt: &future},
+		},
+		"invalid_category": {
+			details:  repository.BlockDetails{Category: "rude"},
+			expError: response.ErrMsgInvalidBlockCategory,
+		},
+		"expiry_in_the_past": {
+			details:  repository.BlockDetails{ExpiresAt: &past},
+			expError: response.ErrMsgInvalidExpiry,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			if tc.expError == "" {
+				mockRepo.On("GetUserByEmail", mock.Anything, "requestor@example.com").Return(&models.User{ID: 1, Status: repository.UserStatusActive}, nil).Once()
+				mockRepo.On("GetUserByEmail", mock.Anything, "target@example.com").Return(&models.User{ID: 2}, nil).Once()
+				mockRepo.On("CheckFriends", mock.Anything, 1, 2).Return(false, nil).Once()
+				mockRepo.On("BlockUser", mock.Anything, 1, 2, tc.expDetails).Return(nil).Once()
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					var payload events.BlockedPayload
+					return e.Type == events.Blocked && json.Unmarshal(e.Payload, &payload) == nil &&
+						payload.Category == tc.expDetails.Category && (payload.ExpiresAt != nil) == (tc.expDetails.ExpiresAt != nil)
+				})).Return(nil).Once()
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == events.Blocked
+				})).Return(nil).Once()
+			}
+
+			// When
+			err := friendService.BlockUpdates(context.Background(), "requestor@example.com", "target@example.com", tc.details)
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+// TestExpireBlocks tests that the ExpireBlocks method of the FriendService expires the blocks in
+// batches and records a BlockExpired event for each.
+func TestExpireBlocks(t *testing.T) {
+	expiresAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	batch := func(n int) []repository.ExpiredBlock {
+		blocks := make([]repository.ExpiredBlock, n)
+		for i := range blocks {
+			blocks[i] = repository.ExpiredBlock{
+				Requestor: fmt.Sprintf("user%d@example.com", i),
+				Target:    "target@example.com",
+				Category:  repository.BlockCategorySpam,
+				ExpiresAt: expiresAt,
+			}
+		}
+		return blocks
+	}
+
+	tcs := map[string]struct {
+		batches  [][]repository.ExpiredBlock // Blocks expired by each call to the repository
+		err      error                       // Error returned by the last call to the repository
+		expTotal int                         // Expected number of expired blocks
+		expError string                      // Expected error message
+	}{
+		"nothing_expired": {
+			batches: [][]repository.ExpiredBlock{nil},
+		},
+		"single_batch": {
+			batches:  [][]repository.ExpiredBlock{batch(2)},
+			expTotal: 2,
+		},
+		"several_batches": {
+			batches:  [][]repository.ExpiredBlock{batch(expireBlocksBatchSize), batch(1)},
+			expTotal: expireBlocksBatchSize + 1,
+		},
+		"repository_error": {
+			batches:  [][]repository.ExpiredBlock{batch(expireBlocksBatchSize), nil},
+			err:      errors.New(response.ErrMsgExpireBlocks),
+			expTotal: expireBlocksBatchSize,
+			expError: response.ErrMsgExpireBlocks,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			expEvents := 0
+			for i, blocks := range tc.batches {
+				if i == len(tc.batches)-1 && tc.err != nil {
+					mockRepo.On("ExpireBlocks", mock.Anything, expireBlocksBatchSize).Return(nil, tc.err).Once()
+					continue
+				}
+				mockRepo.On("ExpireBlocks", mock.Anything, expireBlocksBatchSize).Return(blocks, nil).Once()
+				expEvents += len(blocks)
+			}
+			if expEvents > 0 {
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					var payload events.BlockExpiredPayload
+					return e.Type == events.BlockExpired && json.Unmarshal(e.Payload, &payload) == nil &&
+						payload.Category == repository.BlockCategorySpam && payload.ExpiresAt.Equal(expiresAt)
+				})).Return(nil).Times(expEvents)
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == events.BlockExpired
+				})).Return(nil).Times(expEvents)
+			}
+
+			// When
+			total, err := friendService.ExpireBlocks(context.Background())
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expTotal, total)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetEligibleRecipients(t *testing.T) {
 	// mockRepoService defines the expected behavior of the mock repository.
 	type mockRepoService struct {
@@ -462,7 +1002,7 @@ func TestGetEligibleRecipients(t *testing.T) {
 			senderEmail: "sender@example.com",
 			text:        "Hello World!",
 			mockFn: mockRepoService{
-				expGetUserByEmail:    map[string]*models.User{"sender@example.com": {ID: 1}},
+				expGetUserByEmail:    map[string]*models.User{"sender@example.com": {ID: 1, Status: repository.UserStatusActive}},
 				expGetFriendsList:    []string{"friend1@example.com", "friend2@example.com"},
 				expGetSubscribers:    []string{"subscriber1@example.com", "subscriber2@example.com"},
 				expHasBlockedUpdates: map[string]bool{"friend1@example.com": false, "friend2@example.com": false, "subscriber1@example.com": false, "subscriber2@example.com": false},
@@ -489,10 +1029,15 @@ func TestGetEligibleRecipients(t *testing.T) {
 			// Set up mock expectations for GetSubscribers
 			mockRepo.On("GetSubscribers", mock.Anything, mock.AnythingOfType("int")).Return(tc.mockFn.expGetSubscribers, tc.mockFn.expErr).Once()
 
-			// Set up mock expectations for HasBlockedUpdates
-			for _, recipient := range append(tc.mockFn.expGetFriendsList, append(tc.mockFn.expGetSubscribers, extractMentionedEmails(tc.text)...)...) {
-				// Use Times to specify the number of expected calls
-				mockRepo.On("HasBlockedUpdates", context.Background(), recipient, tc.senderEmail).Return(tc.mockFn.expHasBlockedUpdates[recipient], nil).Once()
+			// Set up mock expectations for GetMutedBy
+			mockRepo.On("GetMutedBy", mock.Anything, mock.AnythingOfType("int")).Return([]string(nil), nil).Once()
+
+			// Set up mock expectations for HasBlockedUpdates in both directions
+			for _, recipient := range append(tc.mockFn.expGetFriendsList, append(tc.mockFn.expGetSubscribers, mention.Emails(mention.Parse(tc.text))...)...) {
+				mockRepo.On("HasBlockedUpdates", mock.Anything, recipient, tc.senderEmail).Return(tc.mockFn.expHasBlockedUpdates[recipient], nil).Once()
+				if !tc.mockFn.expHasBlockedUpdates[recipient] {
+					mockRepo.On("HasBlockedUpdates", mock.Anything, tc.senderEmail, recipient).Return(false, nil).Once()
+				}
 			}
 
 			// When
@@ -513,3 +1058,453 @@ func TestGetEligibleRecipients(t *testing.T) {
 		})
 	}
 }
+
+// TestGetRecipients tests that the GetRecipients method reports why each user receives an update
+// and why excluded candidates do not.
+func TestGetRecipients(t *testing.T) {
+	tcs := map[string]struct {
+		text          string          // Update text
+		private       bool            // Whether the account of the sender is private
+		friends       []string        // Friends of the sender
+		subscribers   []string        // Subscribers of the sender
+		users         map[string]bool // Mentioned emails that belong to a user
+		inactive      map[string]bool // Mentioned users whose account is not active
+		blockedSender map[string]bool // Recipients who blocked the sender
+		blockedBy     map[string]bool // Recipients the sender blocked
+		mutedBy       []string        // Users muting the sender
+		expRecipients []Recipient     // Expected recipients
+		expExcluded   []Exclusion     // Expected excluded candidates
+		expMentions   int             // Expected number of mentions
+	}{
+		"sources": {
+			text:        "Hi @kate@example.com and john@example.com",
+			friends:     []string{"john@example.com"},
+			subscribers: []string{"lisa@example.com", "john@example.com"},
+			users:       map[string]bool{"kate@example.com": true, "john@example.com": true},
+			expRecipients: []Recipient{
+				{Email: "john@example.com", Sources: []string{SourceFriend, SourceSubscription, SourceMention}},
+				{Email: "lisa@example.com", Sources: []string{SourceSubscription}},
+				{Email: "kate@example.com", Sources: []string{SourceMention}},
+			},
+			expExcluded: []Exclusion{},
+			expMentions: 2,
+		},
+		"unknown_mention_and_blocks": {
+			text:          "Hi nobody@example.com",
+			friends:       []string{"john@example.com", "kate@example.com"},
+			subscribers:   []string{"lisa@example.com"},
+			blockedSender: map[string]bool{"john@example.com": true},
+			blockedBy:     map[string]bool{"lisa@example.com": true},
+			expRecipients: []Recipient{
+				{Email: "kate@example.com", Sources: []string{SourceFriend}},
+			},
+			expExcluded: []Exclusion{
+				{Email: "nobody@example.com", Sources: []string{SourceMention}, Reason: ExclusionUnknownUser},
+				{Email: "john@example.com", Sources: []string{SourceFriend}, Reason: ExclusionBlockedSender},
+				{Email: "lisa@example.com", Sources: []string{SourceSubscription}, Reason: ExclusionBlockedBySender},
+			},
+			expMentions: 1,
+		},
+		"inactive_mention": {
+			text:     "Hi kate@example.com",
+			friends:  []string{"john@example.com"},
+			users:    map[string]bool{"kate@example.com": true},
+			inactive: map[string]bool{"kate@example.com": true},
+			expRecipients: []Recipient{
+				{Email: "john@example.com", Sources: []string{SourceFriend}},
+			},
+			expExcluded: []Exclusion{
+				{Email: "kate@example.com", Sources: []string{SourceMention}, Reason: ExclusionInactiveUser},
+			},
+			expMentions: 1,
+		},
+		"muted_by_recipients": {
+			text:        "Hi kate@example.com",
+			friends:     []string{"john@example.com"},
+			subscribers: []string{"lisa@example.com"},
+			users:       map[string]bool{"kate@example.com": true},
+			mutedBy:     []string{"John@Example.com", "kate@example.com"},
+			expRecipients: []Recipient{
+				{Email: "lisa@example.com", Sources: []string{SourceSubscription}},
+			},
+			expExcluded: []Exclusion{},
+			expMentions: 1,
+		},
+		"private_sender": {
+			text:        "Hi kate@example.com",
+			private:     true,
+			friends:     []string{"john@example.com", "kate@example.com", "mike@example.com"},
+			subscribers: []string{"john@example.com"},
+			users:       map[string]bool{"kate@example.com": true},
+			expRecipients: []Recipient{
+				{Email: "john@example.com", Sources: []string{SourceFriend, SourceSubscription}},
+				{Email: "kate@example.com", Sources: []string{SourceFriend, SourceMention}},
+			},
+			expExcluded: []Exclusion{
+				{Email: "mike@example.com", Sources: []string{SourceFriend}, Reason: ExclusionUnapprovedFriend},
+			},
+			expMentions: 1,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			sender := "sender@example.com"
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On("GetUserByEmail", mock.Anything, sender).Return(&models.User{ID: 1, Email: sender, Status: repository.UserStatusActive, Private: tc.private}, nil).Once()
+			mockRepo.On("GetFriendsList", mock.Anything, 1).Return(tc.friends, nil).Once()
+			mockRepo.On("GetSubscribers", mock.Anything, 1).Return(tc.subscribers, nil).Once()
+			mockRepo.On("GetMutedBy", mock.Anything, 1).Return(tc.mutedBy, nil).Once()
+			for _, email := range mention.Emails(mention.Parse(tc.text)) {
+				if tc.users[email] {
+					status := repository.UserStatusActive
+					if tc.inactive[email] {
+						status = repository.UserStatusDeactivated
+					}
+					mockRepo.On("GetUserByEmail", mock.Anything, email).Return(&models.User{Email: email, Status: status}, nil).Once()
+				} else {
+					mockRepo.On("GetUserByEmail", mock.Anything, email).Return((*models.User)(nil), sql.ErrNoRows).Once()
+				}
+			}
+			for _, email := range append(append(tc.friends, tc.subscribers...), "kate@example.com") {
+				mockRepo.On("HasBlockedUpdates", mock.Anything, email, sender).Return(tc.blockedSender[email], nil).Maybe()
+				mockRepo.On("HasBlockedUpdates", mock.Anything, sender, email).Return(tc.blockedBy[email], nil).Maybe()
+			}
+
+			// When
+			result, err := friendService.GetRecipients(context.Background(), sender, tc.text)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tc.expRecipients, result.Recipients)
+			require.Equal(t, tc.expExcluded, result.Excluded)
+			require.Len(t, result.Mentions, tc.expMentions)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetRecipients_MentionLookupError tests that a failure to look up a mentioned user is
+// returned rather than reported as an unknown user.
+func TestGetRecipients_MentionLookupError(t *testing.T) {
+	// Given
+	sender := "sender@example.com"
+	mockRepo := new(repository.MockRepo)
+	friendService := NewFriendService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", mock.Anything, sender).Return(&models.User{ID: 1, Email: sender, Status: repository.UserStatusActive}, nil).Once()
+	mockRepo.On("GetFriendsList", mock.Anything, 1).Return([]string{"john@example.com"}, nil).Once()
+	mockRepo.On("GetSubscribers", mock.Anything, 1).Return([]string(nil), nil).Once()
+	mockRepo.On("GetMutedBy", mock.Anything, 1).Return([]string(nil), nil).Once()
+	mockRepo.On("GetUserByEmail", mock.Anything, "kate@example.com").
+		Return((*models.User)(nil), errors.New(response.ErrMsgGetUserByEmail)).Once()
+
+	// When
+	result, err := friendService.GetRecipients(context.Background(), sender, "Hi kate@example.com")
+
+	// Then
+	require.EqualError(t, err, response.ErrMsgGetUserByEmail)
+	require.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestRemoveFriend tests the RemoveFriend method of the FriendService.
+func TestRemoveFriend(t *testing.T) {
+	tcs := map[string]struct {
+		removed   bool   // Whether RemoveFriend finds a connection to remove
+		removeErr error  // Error returned by RemoveFriend
+		expError  string // Expected error message
+	}{
+		"success": {
+			removed: true,
+		},
+		"not_friends": {
+			expError: response.ErrMsgNotFriends,
+		},
+		"remove_error": {
+			removeErr: errors.New(response.ErrMsgRemoveFriend),
+			expError:  response.ErrMsgRemoveFriend,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On("GetUserByEmail", mock.Anything, "test1@example.com").Return(&models.User{ID: 1, Email: "test1@example.com"}, nil).Once()
+			mockRepo.On("GetUserByEmail", mock.Anything, "test2@example.com").Return(&models.User{ID: 2, Email: "test2@example.com"}, nil).Once()
+			mockRepo.On("RemoveFriend", mock.Anything, 1, 2).Return(tc.removed, tc.removeErr).Once()
+			if tc.removed {
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					return e.Type == events.FriendshipRemoved
+				})).Return(nil).Once()
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == events.FriendshipRemoved
+				})).Return(nil).Once()
+			}
+
+			// When
+			err := friendService.RemoveFriend(context.Background(), "Test1@Example.com", "test2@example.com")
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestUnblockUpdates tests the UnblockUpdates method of the FriendService.
+func TestUnblockUpdates(t *testing.T) {
+	tcs := map[string]struct {
+		unblocked bool   // Whether UnblockUser finds a block to remove
+		expError  string // Expected error message
+	}{
+		"success": {
+			unblocked: true,
+		},
+		"not_blocked": {
+			expError: response.ErrMsgNotBlocked,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On("GetUserByEmail", mock.Anything, "requestor@example.com").Return(&models.User{ID: 1, Status: repository.UserStatusActive}, nil).Once()
+			mockRepo.On("GetUserByEmail", mock.Anything, "target@example.com").Return(&models.User{ID: 2}, nil).Once()
+			mockRepo.On("UnblockUser", mock.Anything, 1, 2).Return(tc.unblocked, nil).Once()
+			if tc.unblocked {
+				mockRepo.On("InsertOutboxEvent", mock.Anything, mock.MatchedBy(func(e events.Event) bool {
+					return e.Type == events.Unblocked
+				})).Return(nil).Once()
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == events.Unblocked
+				})).Return(nil).Once()
+			}
+
+			// When
+			err := friendService.UnblockUpdates(context.Background(), "requestor@example.com", "target@example.com")
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestMuteUpdates tests the MuteUpdates method of the FriendService, which never records an
+// event nor an audit entry.
+func TestMuteUpdates(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tcs := map[string]struct {
+		target    string     // Muted email
+		expiresAt *time.Time // Expiry of the mute
+		muteErr   error      // Error returned by MuteUser
+		expMute   bool       // Whether MuteUser is expected to be called
+		expError  string     // Expected error message
+	}{
+		"success": {
+			target:  "target@example.com",
+			expMute: true,
+		},
+		"success_with_expiry": {
+			target:    "Target@Example.com",
+			expiresAt: &future,
+			expMute:   true,
+		},
+		"expiry_in_the_past": {
+			target:    "target@example.com",
+			expiresAt: &past,
+			expError:  response.ErrMsgInvalidExpiry,
+		},
+		"self_mute": {
+			target:   "Requestor@example.com",
+			expError: response.ErrMsgSelfRelation,
+		},
+		"mute_error": {
+			target:   "target@example.com",
+			muteErr:  errors.New(response.ErrMsgMuteUser),
+			expMute:  true,
+			expError: response.ErrMsgMuteUser,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			if tc.expMute {
+				mockRepo.On("GetUserByEmail", mock.Anything, "requestor@example.com").Return(&models.User{ID: 1, Status: repository.UserStatusActive}, nil).Once()
+				mockRepo.On("GetUserByEmail", mock.Anything, "target@example.com").Return(&models.User{ID: 2}, nil).Once()
+				mockRepo.On("MuteUser", mock.Anything, 1, 2, tc.expiresAt).Return(tc.muteErr).Once()
+			}
+			if tc.expMute && tc.muteErr == nil {
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == audit.ActionMuted && e.Requestor == "requestor@example.com" && e.Target == "target@example.com"
+				})).Return(nil).Once()
+			}
+
+			// When
+			err := friendService.MuteUpdates(context.Background(), "requestor@example.com", tc.target, tc.expiresAt)
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "InsertOutboxEvent", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestUnmuteUpdates tests the UnmuteUpdates method of the FriendService.
+func TestUnmuteUpdates(t *testing.T) {
+	tcs := map[string]struct {
+		unmuted  bool   // Whether UnmuteUser finds an active mute to remove
+		expError string // Expected error message
+	}{
+		"success": {
+			unmuted: true,
+		},
+		"not_muted": {
+			expError: response.ErrMsgNotMuted,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On("GetUserByEmail", mock.Anything, "requestor@example.com").Return(&models.User{ID: 1}, nil).Once()
+			mockRepo.On("GetUserByEmail", mock.Anything, "target@example.com").Return(&models.User{ID: 2}, nil).Once()
+			mockRepo.On("UnmuteUser", mock.Anything, 1, 2).Return(tc.unmuted, nil).Once()
+			if tc.unmuted {
+				mockRepo.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(e repository.AuditEntry) bool {
+					return e.Action == audit.ActionUnmuted
+				})).Return(nil).Once()
+			}
+
+			// When
+			err := friendService.UnmuteUpdates(context.Background(), "requestor@example.com", "target@example.com")
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestInactiveRequestor tests that the users whose account is not active can neither change
+// their relations nor find the recipients of their updates.
+func TestInactiveRequestor(t *testing.T) {
+	tcs := map[string]struct {
+		status string                         // Account status of the requestor
+		call   func(serv FriendService) error // Request made by the requestor
+	}{
+		"create_friend_deactivated": {
+			status: repository.UserStatusDeactivated,
+			call: func(serv FriendService) error {
+				return serv.CreateFriend(context.Background(), "requestor@example.com", "target@example.com")
+			},
+		},
+		"subscribe_suspended": {
+			status: repository.UserStatusSuspended,
+			call: func(serv FriendService) error {
+				_, err := serv.SubscribeUpdates(context.Background(), "requestor@example.com", "target@example.com")
+				return err
+			},
+		},
+		"block_deleted": {
+			status: repository.UserStatusDeleted,
+			call: func(serv FriendService) error {
+				return serv.BlockUpdates(context.Background(), "requestor@example.com", "target@example.com", repository.BlockDetails{})
+			},
+		},
+		"mute_suspended": {
+			status: repository.UserStatusSuspended,
+			call: func(serv FriendService) error {
+				return serv.MuteUpdates(context.Background(), "requestor@example.com", "target@example.com", nil)
+			},
+		},
+		"get_recipients_deactivated": {
+			status: repository.UserStatusDeactivated,
+			call: func(serv FriendService) error {
+				_, err := serv.GetRecipients(context.Background(), "requestor@example.com", "hello target@example.com")
+				return err
+			},
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockRepo)
+			friendService := NewFriendService(mockRepo)
+
+			mockRepo.On("GetUserByEmail", mock.Anything, "requestor@example.com").
+				Return(&models.User{ID: 1, Email: "requestor@example.com", Status: tc.status}, nil).Once()
+
+			// When
+			err := tc.call(friendService)
+
+			// Then
+			require.EqualError(t, err, response.ErrMsgInactiveUser)
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "WithTx", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestRecordAudit tests that recordAudit records the source of the change carried by the
+// context along with the state of the relation.
+func TestRecordAudit(t *testing.T) {
+	// Given
+	mockRepo := new(repository.MockRepo)
+	ctx := audit.NewContext(context.Background(), audit.Source{Actor: "admin@example.com", RequestID: "req-1", ClientIP: "10.0.0.1"})
+	mockRepo.On("InsertAuditEntry", mock.Anything, repository.AuditEntry{
+		Actor:     "admin@example.com",
+		Action:    events.FriendshipCreated,
+		Requestor: "andy@example.com",
+		Target:    "john@example.com",
+		RequestID: "req-1",
+		ClientIP:  "10.0.0.1",
+		Before:    []byte(`{"friends":false}`),
+		After:     []byte(`{"friends":true}`),
+	}).Return(nil).Once()
+
+	// When
+	err := recordAudit(ctx, mockRepo, events.FriendshipCreated, "andy@example.com", "john@example.com",
+		relationState{"friends": false}, relationState{"friends": true})
+
+	// Then
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// GetFriendPath finds a shortest chain of friendships from email1 to email2 of at most maxDepth
+// friendships, 0 meaning the configured limit. It returns the emails along the path, both ends
+// included, or nil when the users are not connected within maxDepth. Users blocking each other
+// are never connected, and the path never crosses a block nor goes through an inactive user.
+func (serv *graphService) GetFriendPath(ctx context.Context, email1, email2 string, maxDepth int) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "GraphService.GetFriendPath")
+	defer span.End()
+
+	if maxDepth == 0 {
+		maxDepth = serv.maxDepth
+	}
+	if maxDepth < 1 || maxDepth > serv.maxDepth {
+		return nil, errors.Errorf("%s: must be between 1 and %d", response.ErrMsgInvalidPathDepth, serv.maxDepth)
+	}
+
+	user1, err := serv.activeUser(ctx, emailaddr.Normalize(email1))
+	if err != nil {
+		return nil, err
+	}
+	user2, err := serv.activeUser(ctx, emailaddr.Normalize(email2))
+	if err != nil {
+		return nil, err
+	}
+	if user1.ID == user2.ID {
+		return []string{user1.Email}, nil
+	}
+
+	blocked, err := serv.repo.HasBlock(ctx, user1.ID, user2.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id1", user1.ID).Int("user_id2", user2.ID).Msg("Failed to check blocks")
+		return nil, errors.Wrap(err, response.ErrMsgGetFriendPath)
+	}
+	if blocked {
+		return nil, nil
+	}
+
+	path, err := serv.shortestPath(ctx, user1, user2, maxDepth)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("user_id1", user1.ID).Int("user_id2", user2.ID).Msg("Failed to find friendship path")
+		return nil, errors.Wrap(err, response.ErrMsgGetFriendPath)
+	}
+	return path, nil
+}
+
+// activeUser looks up an active user by email, inactive users being reported as not found.
+func (serv *graphService) activeUser(ctx context.Context, email string) (*models.User, error) {
+	user, err := serv.friendRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgUserNotFound)
+	}
+	if user == nil || user.Status != repository.UserStatusActive {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+	return user, nil
+}
+
+// searchSide is the state of one of the two searches of a bidirectional breadth-first search.
+type searchSide struct {
+	// parents links every user reached to the user it was reached from, the root excluded.
+	parents map[int]int
+	// depths is the number of friendships between every user reached and the root.
+	depths   map[int]int
+	frontier []int
+}
+
+func newSearchSide(root int) *searchSide {
+	return &searchSide{parents: map[int]int{}, depths: map[int]int{root: 0}, frontier: []int{root}}
+}
+
+// shortestPath runs a breadth-first search from both users at once, always expanding the smaller
+// frontier by a whole level with a single query, until the searches meet or maxDepth is reached.
+func (serv *graphService) shortestPath(ctx context.Context, from, to *models.User, maxDepth int) ([]string, error) {
+	forward, backward := newSearchSide(from.ID), newSearchSide(to.ID)
+	emails := map[int]string{from.ID: from.Email, to.ID: to.Email}
+
+	for depth := 0; depth < maxDepth; depth++ {
+		side, other := forward, backward
+		if len(backward.frontier) < len(forward.frontier) {
+			side, other = backward, forward
+		}
+
+		friends, err := serv.repo.GetFriendRefs(ctx, side.frontier)
+		if err != nil {
+			return nil, err
+		}
+
+		// Every meeting point found while expanding the level is considered, as they may be
+		// at different depths of the other search
+		meet, best := 0, 0
+		var next []int
+		for _, id := range side.frontier {
+			for _, friend := range friends[id] {
+				if _, seen := side.depths[friend.ID]; seen {
+					continue
+				}
+				side.parents[friend.ID] = id
+				side.depths[friend.ID] = side.depths[id] + 1
+				emails[friend.ID] = friend.Email
+				next = append(next, friend.ID)
+
+				if d, ok := other.depths[friend.ID]; ok && (meet == 0 || side.depths[friend.ID]+d < best) {
+					meet, best = friend.ID, side.depths[friend.ID]+d
+				}
+			}
+		}
+		if meet != 0 {
+			return joinPath(forward, backward, meet, emails), nil
+		}
+		if len(next) == 0 {
+			return nil, nil
+		}
+		side.frontier = next
+	}
+	return nil, nil
+}
+
+// joinPath builds the path from the root of forward to the root of backward through meet.
+func joinPath(forward, backward *searchSide, meet int, emails map[int]string) []string {
+	var path []string
+	for id, ok := meet, true; ok; id, ok = forward.parents[id] {
+		path = append([]string{emails[id]}, path...)
+	}
+	for id, ok := backward.parents[meet]; ok; id, ok = backward.parents[id] {
+		path = append(path, emails[id])
+	}
+	return path
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGraphService is a mock implementation of GraphService interface.
+type MockGraphService struct {
+	mock.Mock
+}
+
+// GetFriendPath mocks the GetFriendPath method of the GraphService interface.
+func (m *MockGraphService) GetFriendPath(ctx context.Context, email1, email2 string, maxDepth int) ([]string, error) {
+	args := m.Called(ctx, email1, email2, maxDepth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetFriendPath tests the GetFriendPath method of the GraphService.
+func TestGetFriendPath(t *testing.T) {
+	// The graph is andy - john - kate, with lisa also a friend of andy
+	andy := &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive}
+	john := repository.UserRef{ID: 2, Email: "john@example.com"}
+	kate := &models.User{ID: 3, Email: "kate@example.com", Status: repository.UserStatusActive}
+	lisa := repository.UserRef{ID: 4, Email: "lisa@example.com"}
+
+	// expansion is an expected GetFriendRefs call.
+	type expansion struct {
+		ids     []int
+		friends map[int][]repository.UserRef
+	}
+
+	tcs := map[string]struct {
+		target     *models.User // User returned for the second email
+		maxDepth   int          // Requested maximum depth
+		blocked    bool         // Whether the users block each other
+		expansions []expansion  // Expected GetFriendRefs calls, in order
+		expPath    []string     // Expected path
+		expError   string       // Expected error message
+	}{
+		"friend_of_friend": {
+			target: kate,
+			expansions: []expansion{
+				{ids: []int{1}, friends: map[int][]repository.UserRef{1: {john, lisa}}},
+				// The search continues from kate, whose frontier is smaller
+				{ids: []int{3}, friends: map[int][]repository.UserRef{3: {john}}},
+			},
+			expPath: []string{"andy@example.com", "john@example.com", "kate@example.com"},
+		},
+		"friends": {
+			target:     &models.User{ID: 2, Email: "john@example.com", Status: repository.UserStatusActive},
+			expansions: []expansion{{ids: []int{1}, friends: map[int][]repository.UserRef{1: {john, lisa}}}},
+			expPath:    []string{"andy@example.com", "john@example.com"},
+		},
+		"same_user": {
+			target:  andy,
+			expPath: []string{"andy@example.com"},
+		},
+		"not_connected": {
+			target:     kate,
+			expansions: []expansion{{ids: []int{1}, friends: map[int][]repository.UserRef{}}},
+		},
+		"beyond_max_depth": {
+			target:     kate,
+			maxDepth:   1,
+			expansions: []expansion{{ids: []int{1}, friends: map[int][]repository.UserRef{1: {john, lisa}}}},
+		},
+		"blocked": {
+			target:  kate,
+			blocked: true,
+		},
+		"inactive_user": {
+			target:   &models.User{ID: 3, Email: "kate@example.com", Status: repository.UserStatusSuspended},
+			expError: response.ErrMsgUserNotFound,
+		},
+		"invalid_max_depth": {
+			target:   kate,
+			maxDepth: 7,
+			expError: response.ErrMsgInvalidPathDepth,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockGraphRepo)
+			mockFriendRepo := new(repository.MockRepo)
+			graphService := NewGraphService(mockRepo, mockFriendRepo, 6)
+
+			if tc.expError != response.ErrMsgInvalidPathDepth {
+				mockFriendRepo.On("GetUserByEmail", mock.Anything, "andy@example.com").Return(andy, nil).Once()
+				mockFriendRepo.On("GetUserByEmail", mock.Anything, tc.target.Email).Return(tc.target, nil).Once()
+			}
+			if tc.expError == "" && tc.target.ID != andy.ID {
+				mockRepo.On("HasBlock", mock.Anything, andy.ID, tc.target.ID).Return(tc.blocked, nil).Once()
+			}
+			for _, e := range tc.expansions {
+				mockRepo.On("GetFriendRefs", mock.Anything, e.ids).Return(e.friends, nil).Once()
+			}
+
+			// When
+			path, err := graphService.GetFriendPath(context.Background(), "Andy@Example.com", tc.target.Email, tc.maxDepth)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expPath, path)
+			}
+			mockRepo.AssertExpectations(t)
+			mockFriendRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -2,7 +2,14 @@ package service
 
 import (
 	"context"
+	"net"
+	"time"
 
+	"github.com/boldnguyen/friend-management/internal/bulk"
+	"github.com/boldnguyen/friend-management/internal/mention"
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/netguard"
 	"github.com/boldnguyen/friend-management/internal/repository"
 )
 
@@ -11,9 +18,82 @@ type FriendService interface {
 	CreateFriend(ctx context.Context, email1, email2 string) error
 	GetFriendsList(ctx context.Context, email string) ([]string, error)
 	GetCommonFriends(ctx context.Context, email1, email2 string) ([]string, error)
-	SubscribeUpdates(ctx context.Context, requestor, target string) error
-	BlockUpdates(ctx context.Context, requestor, target string) error
+	GetFriendSuggestions(ctx context.Context, email string, limit, offset int) ([]repository.Suggestion, error)
+	GetRelationship(ctx context.Context, viewer, target string) (*repository.Relationship, error)
+	GetRelationships(ctx context.Context, viewer string, targets []string) (*Relationships, error)
+	SubscribeUpdates(ctx context.Context, requestor, target string) (string, error)
+	SetPrivacy(ctx context.Context, email string, private bool, policy string) (*PrivacyChange, error)
+	ListSubscriptionRequests(ctx context.Context, email string, limit, offset int) ([]repository.SubscriptionRequest, error)
+	ApproveSubscription(ctx context.Context, requestor, target string) error
+	DenySubscription(ctx context.Context, requestor, target string) error
+	BlockUpdates(ctx context.Context, requestor, target string, details repository.BlockDetails) error
+	ExpireBlocks(ctx context.Context) (int, error)
+	RemoveFriend(ctx context.Context, email1, email2 string) error
+	UnblockUpdates(ctx context.Context, requestor, target string) error
+	MuteUpdates(ctx context.Context, requestor, target string, expiresAt *time.Time) error
+	UnmuteUpdates(ctx context.Context, requestor, target string) error
+	ListMutes(ctx context.Context, email string) ([]repository.Mute, error)
 	GetEligibleRecipients(ctx context.Context, senderEmail, text string) ([]string, error)
+	GetRecipients(ctx context.Context, senderEmail, text string) (*Recipients, error)
+}
+
+// Sources of the recipients of an update.
+const (
+	SourceFriend       = "friend"
+	SourceSubscription = "subscription"
+	SourceMention      = "mention"
+)
+
+// Reasons a candidate recipient does not receive an update.
+const (
+	ExclusionBlockedSender    = "blocked_sender"
+	ExclusionBlockedBySender  = "blocked_by_sender"
+	ExclusionUnknownUser      = "unknown_mentioned_email"
+	ExclusionInactiveUser     = "inactive_user"
+	ExclusionUnapprovedFriend = "unapproved_friend"
+)
+
+// Recipient is a user receiving an update, with the reasons they receive it.
+type Recipient struct {
+	Email   string   `json:"email"`
+	Sources []string `json:"sources"`
+}
+
+// Exclusion is a candidate recipient that does not receive an update.
+type Exclusion struct {
+	Email string `json:"email"`
+	// Sources are the reasons the candidate would have received the update.
+	Sources []string `json:"sources"`
+	Reason  string   `json:"reason"`
+}
+
+// Recipients holds the recipients of an update, the candidates excluded from them and
+// the mentions found in its text.
+type Recipients struct {
+	Recipients []Recipient       `json:"recipients"`
+	Excluded   []Exclusion       `json:"excluded"`
+	Mentions   []mention.Mention `json:"mentions"`
+}
+
+// MaxRelationshipTargets is the largest number of targets whose relationship with a viewer
+// is retrieved at once.
+const MaxRelationshipTargets = 100
+
+// Relationships holds the relationships between a viewer and a batch of targets.
+type Relationships struct {
+	Relationships []repository.Relationship `json:"relationships"`
+	// NotFound lists the targets that are unknown or inactive, or the viewer themselves.
+	NotFound []string `json:"not_found"`
+}
+
+// PrivacyChange is the outcome of making a user private or public.
+type PrivacyChange struct {
+	Email   string `json:"email"`
+	Private bool   `json:"private"`
+	// Policy is the policy applied to the existing subscribers when the user became private.
+	Policy string `json:"policy,omitempty"`
+	// Subscriptions is the number of existing subscriptions approved, turned pending or removed.
+	Subscriptions int `json:"subscriptions"`
 }
 
 // friendService implements the FriendService interface.
@@ -25,3 +105,174 @@ type friendService struct {
 func NewFriendService(repo repository.FriendRepository) FriendService {
 	return &friendService{repo: repo}
 }
+
+// WebhookService provides methods for managing the webhooks notified of graph changes.
+type WebhookService interface {
+	RegisterWebhook(ctx context.Context, url string, eventTypes, emails []string) (*repository.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]repository.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]repository.WebhookDelivery, error)
+	ListDeadLetters(ctx context.Context, webhookID int, limit, offset int) ([]repository.WebhookDeadLetter, error)
+}
+
+// webhookService implements the WebhookService interface.
+type webhookService struct {
+	repo repository.WebhookRepository
+	// resolver resolves the hosts of the registered URLs to check they are publicly routable.
+	resolver netguard.Resolver
+	// allowPrivateNetworks skips that check.
+	allowPrivateNetworks bool
+}
+
+// NewWebhookService creates a new WebhookService instance.
+func NewWebhookService(repo repository.WebhookRepository, cfg config.Webhooks) WebhookService {
+	return &webhookService{
+		repo:                 repo,
+		resolver:             net.DefaultResolver,
+		allowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}
+}
+
+// UpdateService provides methods for posting updates and reading inboxes.
+type UpdateService interface {
+	PostUpdate(ctx context.Context, senderEmail, text string) (*repository.Update, error)
+	FanOut(ctx context.Context, updateID int64) error
+	GetInbox(ctx context.Context, email string, limit, offset int) ([]repository.InboxItem, error)
+}
+
+// UpdatePublisher pushes fanned out updates to the recipients that are online.
+type UpdatePublisher interface {
+	Publish(recipients []string, item repository.InboxItem)
+}
+
+// updateService implements the UpdateService interface.
+type updateService struct {
+	repo          repository.UpdateRepository
+	friendRepo    repository.FriendRepository
+	friendService FriendService
+	publisher     UpdatePublisher
+	// queue receives the updates to fan out in the background.
+	queue chan<- int64
+	// asyncThreshold is the audience size above which the fan-out runs in the background.
+	asyncThreshold int
+}
+
+// NewUpdateService creates a new UpdateService instance. Updates of senders with more than
+// asyncThreshold friends and subscribers are sent to queue to be fanned out in the background.
+// Fanned out updates are also handed to publisher for real-time delivery.
+func NewUpdateService(repo repository.UpdateRepository, friendRepo repository.FriendRepository, friendService FriendService, publisher UpdatePublisher, queue chan<- int64, asyncThreshold int) UpdateService {
+	return &updateService{
+		repo:           repo,
+		friendRepo:     friendRepo,
+		friendService:  friendService,
+		publisher:      publisher,
+		queue:          queue,
+		asyncThreshold: asyncThreshold,
+	}
+}
+
+// UserService provides methods for administering the users.
+type UserService interface {
+	CreateUser(ctx context.Context, name, email string) (*models.User, error)
+	DeleteUser(ctx context.Context, email string) error
+	SetUserStatus(ctx context.Context, email, status string) (*models.User, error)
+	PurgeDeletedUsers(ctx context.Context, before time.Time) (int, error)
+	ExportUserData(ctx context.Context, email string) (*repository.UserArchive, error)
+	EraseUser(ctx context.Context, email, mode, reason string) (*repository.Erasure, error)
+	ListDuplicateEmails(ctx context.Context) ([]repository.DuplicateEmail, error)
+}
+
+// userService implements the UserService interface.
+type userService struct {
+	repo repository.UserRepository
+	// erasureKey keys the hash of the emails of the erased users.
+	erasureKey string
+}
+
+// NewUserService creates a new UserService instance.
+func NewUserService(repo repository.UserRepository, cfg config.Accounts) UserService {
+	return &userService{repo: repo, erasureKey: cfg.ErasureKey}
+}
+
+// BulkService provides methods for importing and exporting the relations between users.
+type BulkService interface {
+	Import(ctx context.Context, rows []bulk.Row, dryRun bool) (*bulk.Report, error)
+	Export(ctx context.Context, email string, fn func(repository.Relation) error) error
+}
+
+// bulkService implements the BulkService interface.
+type bulkService struct {
+	repo repository.BulkRepository
+}
+
+// NewBulkService creates a new BulkService instance.
+func NewBulkService(repo repository.BulkRepository) BulkService {
+	return &bulkService{repo: repo}
+}
+
+// AuditService provides methods for querying the audit log of the graph changes.
+type AuditService interface {
+	ListAuditEntries(ctx context.Context, filter repository.AuditFilter, limit, offset int) ([]repository.AuditEntry, error)
+}
+
+// auditService implements the AuditService interface.
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditService creates a new AuditService instance.
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// GraphService provides methods for querying the friendship graph.
+type GraphService interface {
+	GetFriendPath(ctx context.Context, email1, email2 string, maxDepth int) ([]string, error)
+}
+
+// graphService implements the GraphService interface.
+type graphService struct {
+	repo       repository.GraphRepository
+	friendRepo repository.FriendRepository
+	// maxDepth is the largest number of friendships a path may have.
+	maxDepth int
+}
+
+// NewGraphService creates a new GraphService instance. Paths are searched up to maxDepth friendships.
+func NewGraphService(repo repository.GraphRepository, friendRepo repository.FriendRepository, maxDepth int) GraphService {
+	return &graphService{repo: repo, friendRepo: friendRepo, maxDepth: maxDepth}
+}
+
+// AnalyticsService provides methods for computing aggregate statistics on the social graph.
+type AnalyticsService interface {
+	ComputeSnapshot(ctx context.Context) (*repository.AnalyticsReport, error)
+	GetLatestSnapshot(ctx context.Context) (*repository.AnalyticsReport, error)
+}
+
+// analyticsService implements the AnalyticsService interface.
+type analyticsService struct {
+	repo repository.AnalyticsRepository
+	// topUsers is the number of users listed in the rankings.
+	topUsers int
+}
+
+// NewAnalyticsService creates a new AnalyticsService instance. Rankings list up to topUsers users.
+func NewAnalyticsService(repo repository.AnalyticsRepository, topUsers int) AnalyticsService {
+	return &analyticsService{repo: repo, topUsers: topUsers}
+}
+
+// StatsService provides methods for reading and repairing the counters of the relations of the users.
+type StatsService interface {
+	GetUserStats(ctx context.Context, email string) (*repository.UserStats, error)
+	ReconcileUserStats(ctx context.Context) (int, error)
+}
+
+// statsService implements the StatsService interface.
+type statsService struct {
+	repo repository.StatsRepository
+}
+
+// NewStatsService creates a new StatsService instance.
+func NewStatsService(repo repository.StatsRepository) StatsService {
+	return &statsService{repo: repo}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// GetUserStats retrieves the counters of the relations of a user.
+func (serv *statsService) GetUserStats(ctx context.Context, email string) (*repository.UserStats, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetUserStats")
+	defer span.End()
+
+	email = emailaddr.Normalize(email)
+	stats, err := serv.repo.GetUserStats(ctx, email)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user stats")
+		return nil, err
+	}
+	if stats == nil {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+	return stats, nil
+}
+
+// ReconcileUserStats repairs the counters that drifted from the relations they count, returning
+// how many users had their counters repaired.
+func (serv *statsService) ReconcileUserStats(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.ReconcileUserStats")
+	defer span.End()
+
+	n, err := serv.repo.ReconcileUserStats(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to reconcile user stats")
+		return 0, err
+	}
+	if n > 0 {
+		// Counters are maintained by triggers, drift hints at relations changed outside of them
+		logger.FromContext(ctx).Warn().Int("repaired", n).Msg("Repaired drifted user stats")
+	}
+	return n, nil
+}
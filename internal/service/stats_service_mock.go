@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStatsService is a mock implementation of StatsService interface.
+type MockStatsService struct {
+	mock.Mock
+}
+
+// GetUserStats mocks the GetUserStats method of the StatsService interface.
+func (m *MockStatsService) GetUserStats(ctx context.Context, email string) (*repository.UserStats, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.UserStats), args.Error(1)
+}
+
+// ReconcileUserStats mocks the ReconcileUserStats method of the StatsService interface.
+func (m *MockStatsService) ReconcileUserStats(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
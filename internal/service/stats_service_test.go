@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserStats tests the GetUserStats method of the StatsService.
+func TestGetUserStats(t *testing.T) {
+	tcs := map[string]struct {
+		stats    *repository.UserStats // Counters returned by the repository
+		repoErr  error                 // Error returned by the repository
+		expError string                // Expected error message
+	}{
+		"success": {
+			stats: &repository.UserStats{Email: "andy@example.com", Friends: 3, Followers: 10, Following: 2, Blocked: 1},
+		},
+		"user_not_found": {
+			expError: response.ErrMsgUserNotFound,
+		},
+		"repository_error": {
+			repoErr:  errors.New(response.ErrMsgGetUserStats),
+			expError: response.ErrMsgGetUserStats,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockStatsRepo)
+			statsService := NewStatsService(mockRepo)
+			mockRepo.On("GetUserStats", mock.Anything, "andy@example.com").Return(tc.stats, tc.repoErr).Once()
+
+			// When
+			stats, err := statsService.GetUserStats(context.Background(), " Andy@Example.com")
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				require.Nil(t, stats)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.stats, stats)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestReconcileUserStats tests the ReconcileUserStats method of the StatsService.
+func TestReconcileUserStats(t *testing.T) {
+	tcs := map[string]struct {
+		repaired int   // Counters repaired by the repository
+		err      error // Error returned by the repository
+	}{
+		"no_drift": {},
+		"drift_repaired": {
+			repaired: 4,
+		},
+		"repository_error": {
+			err: errors.New(response.ErrMsgReconcileUserStats),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockStatsRepo)
+			statsService := NewStatsService(mockRepo)
+			mockRepo.On("ReconcileUserStats", mock.Anything).Return(tc.repaired, tc.err).Once()
+
+			// When
+			n, err := statsService.ReconcileUserStats(context.Background())
+
+			// Then
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.repaired, n)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
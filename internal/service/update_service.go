@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// PostUpdate stores the update and fans it out to the inboxes of the eligible recipients.
+// The fan-out runs in the background when the sender has a large audience, or when it failed,
+// in which case the returned update is still pending.
+func (serv *updateService) PostUpdate(ctx context.Context, senderEmail, text string) (*repository.Update, error) {
+	ctx, span := tracing.Start(ctx, "UpdateService.PostUpdate")
+	defer span.End()
+
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New(response.ErrMsgInvalidUpdateText)
+	}
+
+	sender, err := serv.friendRepo.GetUserByEmail(ctx, senderEmail)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, errors.Wrap(err, response.ErrMsgUserNotFound)
+		}
+		logger.FromContext(ctx).Error().Err(err).Str("sender", logger.Email(senderEmail)).Msg("Failed to get sender")
+		return nil, err
+	}
+	if err := checkActive(sender); err != nil {
+		return nil, err
+	}
+
+	update, err := serv.repo.CreateUpdate(ctx, sender.ID, text)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("sender", logger.Email(senderEmail)).Msg("Failed to create update")
+		return nil, err
+	}
+
+	audience, err := serv.repo.CountAudience(ctx, sender.ID)
+	if err != nil {
+		// The size of the audience is unknown, leave the fan-out to the background workers
+		logger.FromContext(ctx).Error().Err(err).Int64("update_id", update.ID).Msg("Failed to count audience, deferring update")
+	}
+	if err != nil || audience > serv.asyncThreshold {
+		select {
+		case serv.queue <- update.ID:
+		default:
+			// The queue is full; the update stays pending until the sweeper picks it up.
+			logger.FromContext(ctx).Warn().Int64("update_id", update.ID).Msg("Fan-out queue full, deferring update")
+		}
+		return update, nil
+	}
+
+	recipients, err := serv.fanOut(ctx, update)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int64("update_id", update.ID).Msg("Failed to fan out update, deferring")
+		return update, nil
+	}
+	update.FanoutStatus = repository.FanoutStatusDone
+	update.RecipientCount = recipients
+
+	return update, nil
+}
+
+// FanOut delivers a pending update to the inboxes of its eligible recipients. It is safe to
+// call more than once for the same update. The fan-out of an update whose sender is no
+// longer active fails for good rather than being retried.
+func (serv *updateService) FanOut(ctx context.Context, updateID int64) error {
+	ctx, span := tracing.Start(ctx, "UpdateService.FanOut")
+	defer span.End()
+
+	update, err := serv.repo.GetUpdate(ctx, updateID)
+	if err != nil {
+		return err
+	}
+	if update.FanoutStatus != repository.FanoutStatusPending {
+		return nil
+	}
+
+	_, err = serv.fanOut(ctx, update)
+	if err != nil && errors.Cause(err).Error() == response.ErrMsgInactiveUser {
+		logger.FromContext(ctx).Warn().Int64("update_id", update.ID).Msg("Sender is inactive, dropping update")
+		return serv.repo.MarkUpdateFanoutFailed(ctx, update.ID)
+	}
+	return err
+}
+
+// GetInbox retrieves a page of the updates delivered to the user, newest first.
+func (serv *updateService) GetInbox(ctx context.Context, email string, limit, offset int) ([]repository.InboxItem, error) {
+	ctx, span := tracing.Start(ctx, "UpdateService.GetInbox")
+	defer span.End()
+
+	user, err := serv.friendRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, errors.Wrap(err, response.ErrMsgUserNotFound)
+		}
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get user")
+		return nil, err
+	}
+
+	items, err := serv.repo.GetInbox(ctx, user.ID, limit, offset)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to get inbox")
+		return nil, err
+	}
+	return items, nil
+}
+
+// fanOut computes the recipients of the update, adds it to their inboxes, marks it as
+// fanned out and publishes it to the online recipients, returning the number of recipients.
+func (serv *updateService) fanOut(ctx context.Context, update *repository.Update) (int, error) {
+	recipients, err := serv.friendService.GetEligibleRecipients(ctx, update.Sender, update.Text)
+	if err != nil {
+		return 0, err
+	}
+
+	// Senders do not receive their own updates, even when they mention themselves
+	filtered := recipients[:0]
+	for _, email := range recipients {
+		if emailaddr.Normalize(email) != emailaddr.Normalize(update.Sender) {
+			filtered = append(filtered, email)
+		}
+	}
+
+	if _, err := serv.repo.DeliverToInboxes(ctx, update.ID, filtered); err != nil {
+		return 0, err
+	}
+	if err := serv.repo.MarkUpdateFannedOut(ctx, update.ID, len(filtered)); err != nil {
+		return 0, err
+	}
+
+	serv.publisher.Publish(filtered, repository.InboxItem{
+		UpdateID:    update.ID,
+		Sender:      update.Sender,
+		Text:        update.Text,
+		PostedAt:    update.CreatedAt,
+		DeliveredAt: time.Now().UTC(),
+	})
+
+	return len(filtered), nil
+}
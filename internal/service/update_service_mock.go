@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUpdateService is a mock implementation of UpdateService interface.
+type MockUpdateService struct {
+	mock.Mock
+}
+
+// PostUpdate mocks the PostUpdate method of the UpdateService interface.
+func (m *MockUpdateService) PostUpdate(ctx context.Context, senderEmail, text string) (*repository.Update, error) {
+	args := m.Called(ctx, senderEmail, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Update), args.Error(1)
+}
+
+// FanOut mocks the FanOut method of the UpdateService interface.
+func (m *MockUpdateService) FanOut(ctx context.Context, updateID int64) error {
+	args := m.Called(ctx, updateID)
+	return args.Error(0)
+}
+
+// GetInbox mocks the GetInbox method of the UpdateService interface.
+func (m *MockUpdateService) GetInbox(ctx context.Context, email string, limit, offset int) ([]repository.InboxItem, error) {
+	args := m.Called(ctx, email, limit, offset)
+	return args.Get(0).([]repository.InboxItem), args.Error(1)
+}
+
+// MockUpdatePublisher is a mock implementation of UpdatePublisher interface.
+type MockUpdatePublisher struct {
+	mock.Mock
+}
+
+// Publish mocks the Publish method of the UpdatePublisher interface.
+func (m *MockUpdatePublisher) Publish(recipients []string, item repository.InboxItem) {
+	m.Called(recipients, item)
+}
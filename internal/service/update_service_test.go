@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostUpdate tests the PostUpdate method of the UpdateService.
+func TestPostUpdate(t *testing.T) {
+	tcs := map[string]struct {
+		text         string   // Update text
+		senderErr    error    // Error returned when looking up the sender
+		senderStatus string   // Account status of the sender, active when empty
+		audience     int      // Friends and subscribers of the sender
+		audienceErr  error    // Error returned by CountAudience
+		queueSize    int      // Capacity of the fan-out queue
+		recipients   []string // Eligible recipients of the update
+		expQueued    bool     // Whether the update is expected to be queued
+		expStatus    string   // Expected fan-out status of the returned update
+		expRecipient int      // Expected recipient count
+		expError     string   // Expected error message
+	}{
+		"sync_fanout": {
+			text:         "hello @kate@example.com",
+			audience:     2,
+			queueSize:    1,
+			recipients:   []string{"john@example.com", "kate@example.com", "lisa@example.com"},
+			expStatus:    repository.FanoutStatusDone,
+			expRecipient: 2,
+		},
+		"async_fanout": {
+			text:      "hello",
+			audience:  500,
+			queueSize: 1,
+			expQueued: true,
+			expStatus: repository.FanoutStatusPending,
+		},
+		"queue_full": {
+			text:      "hello",
+			audience:  500,
+			queueSize: 0,
+			expStatus: repository.FanoutStatusPending,
+		},
+		"count_audience_error": {
+			text:        "hello",
+			audienceErr: errors.New(response.ErrMsgGetUpdate),
+			queueSize:   1,
+			expQueued:   true,
+			expStatus:   repository.FanoutStatusPending,
+		},
+		"empty_text": {
+			text:     "   ",
+			expError: response.ErrMsgInvalidUpdateText,
+		},
+		"unknown_sender": {
+			text:      "hello",
+			senderErr: sql.ErrNoRows,
+			expError:  response.ErrMsgUserNotFound,
+		},
+		"sender_lookup_error": {
+			text:      "hello",
+			senderErr: errors.New(response.ErrMsgGetUserByEmail),
+			expError:  response.ErrMsgGetUserByEmail,
+		},
+		"suspended_sender": {
+			text:         "hello",
+			senderStatus: repository.UserStatusSuspended,
+			expError:     response.ErrMsgInactiveUser,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUpdateRepo)
+			mockFriendRepo := new(repository.MockRepo)
+			mockFriendService := new(MockFriendService)
+			mockPublisher := new(MockUpdatePublisher)
+			queue := make(chan int64, tc.queueSize)
+			updateService := NewUpdateService(mockRepo, mockFriendRepo, mockFriendService, mockPublisher, queue, 100)
+
+			if tc.expError != response.ErrMsgInvalidUpdateText {
+				var sender *models.User
+				if tc.senderErr == nil {
+					sender = &models.User{ID: 1, Email: "lisa@example.com", Status: repository.UserStatusActive}
+					if tc.senderStatus != "" {
+						sender.Status = tc.senderStatus
+					}
+				}
+				mockFriendRepo.On("GetUserByEmail", mock.Anything, "lisa@example.com").Return(sender, tc.senderErr).Once()
+			}
+			if tc.expError == "" {
+				update := &repository.Update{ID: 9, SenderID: 1, Sender: "lisa@example.com", Text: tc.text, FanoutStatus: repository.FanoutStatusPending}
+				mockRepo.On("CreateUpdate", mock.Anything, 1, tc.text).Return(update, nil).Once()
+				mockRepo.On("CountAudience", mock.Anything, 1).Return(tc.audience, tc.audienceErr).Once()
+			}
+			if tc.recipients != nil {
+				expInboxes := []string{"john@example.com", "kate@example.com"}
+				mockFriendService.On("GetEligibleRecipients", mock.Anything, "lisa@example.com", tc.text).Return(tc.recipients, nil).Once()
+				mockRepo.On("DeliverToInboxes", mock.Anything, int64(9), expInboxes).Return(len(expInboxes), nil).Once()
+				mockRepo.On("MarkUpdateFannedOut", mock.Anything, int64(9), len(expInboxes)).Return(nil).Once()
+				mockPublisher.On("Publish", expInboxes, mock.MatchedBy(func(item repository.InboxItem) bool {
+					return item.UpdateID == 9 && item.Sender == "lisa@example.com"
+				})).Once()
+			}
+
+			// When
+			update, err := updateService.PostUpdate(context.Background(), "lisa@example.com", tc.text)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				if tc.senderErr != sql.ErrNoRows {
+					require.NotContains(t, err.Error(), response.ErrMsgUserNotFound)
+				}
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expStatus, update.FanoutStatus)
+				require.Equal(t, tc.expRecipient, update.RecipientCount)
+			}
+			require.Equal(t, tc.expQueued, len(queue) == 1)
+			mockRepo.AssertExpectations(t)
+			mockFriendRepo.AssertExpectations(t)
+			mockFriendService.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
+
+// TestFanOut tests the FanOut method of the UpdateService.
+func TestFanOut(t *testing.T) {
+	tcs := map[string]struct {
+		status       string // Fan-out status of the stored update
+		getErr       error  // Error returned by GetUpdate
+		recipientErr error  // Error returned by GetEligibleRecipients
+		deliverErr   error  // Error returned by DeliverToInboxes
+		expFanout    bool   // Whether the recipients are expected to be computed
+		expFailed    bool   // Whether the fan-out is expected to be marked as failed
+		expError     string // Expected error message
+	}{
+		"success": {
+			status:    repository.FanoutStatusPending,
+			expFanout: true,
+		},
+		"already_done": {
+			status: repository.FanoutStatusDone,
+		},
+		"already_failed": {
+			status: repository.FanoutStatusFailed,
+		},
+		"inactive_sender": {
+			status:       repository.FanoutStatusPending,
+			recipientErr: errors.New(response.ErrMsgInactiveUser),
+			expFanout:    true,
+			expFailed:    true,
+		},
+		"recipients_error": {
+			status:       repository.FanoutStatusPending,
+			recipientErr: errors.New(response.ErrMsgGetFriendsList),
+			expFanout:    true,
+			expError:     response.ErrMsgGetFriendsList,
+		},
+		"update_not_found": {
+			getErr:   errors.New(response.ErrMsgUpdateNotFound),
+			expError: response.ErrMsgUpdateNotFound,
+		},
+		"deliver_error": {
+			status:     repository.FanoutStatusPending,
+			deliverErr: errors.New(response.ErrMsgDeliverUpdate),
+			expFanout:  true,
+			expError:   response.ErrMsgDeliverUpdate,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUpdateRepo)
+			mockFriendService := new(MockFriendService)
+			mockPublisher := new(MockUpdatePublisher)
+			updateService := NewUpdateService(mockRepo, new(repository.MockRepo), mockFriendService, mockPublisher, nil, 100)
+
+			var update *repository.Update
+			if tc.getErr == nil {
+				update = &repository.Update{ID: 9, Sender: "lisa@example.com", Text: "hi", FanoutStatus: tc.status}
+			}
+			mockRepo.On("GetUpdate", mock.Anything, int64(9)).Return(update, tc.getErr).Once()
+			if tc.expFanout {
+				mockFriendService.On("GetEligibleRecipients", mock.Anything, "lisa@example.com", "hi").Return([]string{"john@example.com"}, tc.recipientErr).Once()
+			}
+			if tc.expFailed {
+				mockRepo.On("MarkUpdateFanoutFailed", mock.Anything, int64(9)).Return(nil).Once()
+			}
+			if tc.expFanout && tc.recipientErr == nil {
+				mockRepo.On("DeliverToInboxes", mock.Anything, int64(9), []string{"john@example.com"}).Return(1, tc.deliverErr).Once()
+				if tc.deliverErr == nil {
+					mockRepo.On("MarkUpdateFannedOut", mock.Anything, int64(9), 1).Return(nil).Once()
+					mockPublisher.On("Publish", []string{"john@example.com"}, mock.AnythingOfType("repository.InboxItem")).Once()
+				}
+			}
+
+			// When
+			err := updateService.FanOut(context.Background(), 9)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+			mockFriendService.AssertExpectations(t)
+			mockPublisher.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+)
+
+// purgeBatchSize is the maximum number of users purged per statement.
+const purgeBatchSize = 100
+
+// CreateUser creates a user with the email, named after it when name is empty.
+func (serv *userService) CreateUser(ctx context.Context, name, email string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
+	email = emailaddr.Normalize(email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, errors.Errorf("%s: %q", response.ErrMsgInvalidEmail, email)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = email[:strings.LastIndex(email, "@")]
+	}
+
+	user, err := serv.repo.CreateUser(ctx, name, email)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to create user")
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New(response.ErrMsgUserAlreadyExists)
+	}
+	return user, nil
+}
+
+// DeleteUser marks the user with the email as deleted. The user is hidden like a deactivated
+// one and can be restored until purged, once the retention period is over.
+func (serv *userService) DeleteUser(ctx context.Context, email string) error {
+	_, err := serv.SetUserStatus(ctx, email, repository.UserStatusDeleted)
+	return err
+}
+
+// SetUserStatus changes the account state of the user with the email. Setting it back to
+// active restores the user along with their relations.
+func (serv *userService) SetUserStatus(ctx context.Context, email, status string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetUserStatus")
+	defer span.End()
+
+	switch status {
+	case repository.UserStatusActive, repository.UserStatusDeactivated, repository.UserStatusSuspended, repository.UserStatusDeleted:
+	default:
+		return nil, errors.New(response.ErrMsgInvalidUserStatus)
+	}
+
+	email = emailaddr.Normalize(email)
+	user, err := serv.repo.SetUserStatus(ctx, email, status)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Str("status", status).Msg("Failed to set user status")
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New(response.ErrMsgUserNotFound)
+	}
+	return user, nil
+}
+
+// PurgeDeletedUsers permanently deletes the users deleted before the given time, along with
+// everything related to them, and returns how many were purged.
+func (serv *userService) PurgeDeletedUsers(ctx context.Context, before time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserService.PurgeDeletedUsers")
+	defer span.End()
+
+	total := 0
+	for {
+		n, err := serv.repo.PurgeDeletedUsers(ctx, before, purgeBatchSize)
+		total += n
+		if err != nil {
+			logger.FromContext(ctx).Error().Err(err).Int("purged", total).Msg("Failed to purge deleted users")
+			return total, err
+		}
+		if n < purgeBatchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// ExportUserData gathers all the data held about the user with the email.
+func (serv *userService) ExportUserData(ctx context.Context, email string) (*repository.UserArchive, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ExportUserData")
+	defer span.End()
+
+	archive, err := serv.repo.GetUserArchive(ctx, emailaddr.Normalize(email))
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to export user data")
+		return nil, err
+	}
+	return archive, nil
+}
+
+// EraseUser deletes or anonymizes the user with the email, depending on the mode, and
+// records an audit of the erasure. The audit holds a keyed hash of the email, the given
+// reason and the ID of the request. The mode defaults to delete.
+func (serv *userService) EraseUser(ctx context.Context, email, mode, reason string) (*repository.Erasure, error) {
+	ctx, span := tracing.Start(ctx, "UserService.EraseUser")
+	defer span.End()
+
+	if mode == "" {
+		mode = repository.ErasureModeDelete
+	}
+	if mode != repository.ErasureModeDelete && mode != repository.ErasureModeAnonymize {
+		return nil, errors.New(response.ErrMsgInvalidErasureMode)
+	}
+
+	email = emailaddr.Normalize(email)
+	mac := hmac.New(sha256.New, []byte(serv.erasureKey))
+	mac.Write([]byte(email))
+	erasure := &repository.Erasure{
+		EmailHash: hex.EncodeToString(mac.Sum(nil)),
+		Mode:      mode,
+		Reason:    strings.TrimSpace(reason),
+		RequestID: middleware.GetReqID(ctx),
+	}
+	if err := serv.repo.EraseUser(ctx, email, erasure); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", logger.Email(email)).Msg("Failed to erase user")
+		return nil, err
+	}
+	logger.FromContext(ctx).Info().Int64("erasure_id", erasure.ID).Int("user_id", erasure.UserID).Str("mode", mode).Msg("User erased")
+
+	return erasure, nil
+}
+
+// ListDuplicateEmails reports the users sharing the same canonical email, which predate
+// the email normalization and have to be merged by hand.
+func (serv *userService) ListDuplicateEmails(ctx context.Context) ([]repository.DuplicateEmail, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListDuplicateEmails")
+	defer span.End()
+
+	duplicates, err := serv.repo.ListDuplicateEmails(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to list duplicate emails")
+		return nil, err
+	}
+	return duplicates, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserService is a mock implementation of UserService interface.
+type MockUserService struct {
+	mock.Mock
+}
+
+// CreateUser mocks the CreateUser method of the UserService interface.
+func (m *MockUserService) CreateUser(ctx context.Context, name, email string) (*models.User, error) {
+	args := m.Called(ctx, name, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// DeleteUser mocks the DeleteUser method of the UserService interface.
+func (m *MockUserService) DeleteUser(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+// SetUserStatus mocks the SetUserStatus method of the UserService interface.
+func (m *MockUserService) SetUserStatus(ctx context.Context, email, status string) (*models.User, error) {
+	args := m.Called(ctx, email, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// PurgeDeletedUsers mocks the PurgeDeletedUsers method of the UserService interface.
+func (m *MockUserService) PurgeDeletedUsers(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(ctx, before)
+	return args.Int(0), args.Error(1)
+}
+
+// ExportUserData mocks the ExportUserData method of the UserService interface.
+func (m *MockUserService) ExportUserData(ctx context.Context, email string) (*repository.UserArchive, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.UserArchive), args.Error(1)
+}
+
+// EraseUser mocks the EraseUser method of the UserService interface.
+func (m *MockUserService) EraseUser(ctx context.Context, email, mode, reason string) (*repository.Erasure, error) {
+	args := m.Called(ctx, email, mode, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Erasure), args.Error(1)
+}
+
+// ListDuplicateEmails mocks the ListDuplicateEmails method of the UserService interface.
+func (m *MockUserService) ListDuplicateEmails(ctx context.Context) ([]repository.DuplicateEmail, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.DuplicateEmail), args.Error(1)
+}
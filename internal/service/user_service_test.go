@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/models"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListDuplicateEmails tests the ListDuplicateEmails method of the UserService.
+func TestListDuplicateEmails(t *testing.T) {
+	tcs := map[string]struct {
+		duplicates []repository.DuplicateEmail // Duplicates returned by the repository
+		repoErr    error                       // Error returned by the repository
+		expError   string                      // Expected error message
+	}{
+		"success": {
+			duplicates: []repository.DuplicateEmail{{
+				CanonicalEmail: "andy@example.com",
+				Users:          []repository.DuplicateUser{{ID: 1, Email: "andy@example.com"}, {ID: 7, Email: "Andy@Example.com"}},
+			}},
+		},
+		"repository_error": {
+			repoErr:  errors.New(response.ErrMsgGetDuplicateEmails),
+			expError: response.ErrMsgGetDuplicateEmails,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{})
+			if tc.repoErr != nil {
+				mockRepo.On("ListDuplicateEmails", mock.Anything).Return(nil, tc.repoErr).Once()
+			} else {
+				mockRepo.On("ListDuplicateEmails", mock.Anything).Return(tc.duplicates, nil).Once()
+			}
+
+			// When
+			duplicates, err := userService.ListDuplicateEmails(context.Background())
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.duplicates, duplicates)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestCreateUser tests the CreateUser method of the UserService.
+func TestCreateUser(t *testing.T) {
+	tcs := map[string]struct {
+		name     string       // Name of the user
+		email    string       // Email of the user
+		expName  string       // Expected name given to the repository
+		expEmail string       // Expected email given to the repository
+		created  *models.User // User returned by the repository
+		expError string       // Expected error message
+	}{
+		"success": {
+			name:     "Andy",
+			email:    "Andy@Example.com",
+			expName:  "Andy",
+			expEmail: "andy@example.com",
+			created:  &models.User{ID: 1, Name: "Andy", Email: "andy@example.com"},
+		},
+		"default_name": {
+			email:    "andy@example.com",
+			expName:  "andy",
+			expEmail: "andy@example.com",
+			created:  &models.User{ID: 1, Name: "andy", Email: "andy@example.com"},
+		},
+		"already_exists": {
+			email:    "andy@example.com",
+			expName:  "andy",
+			expEmail: "andy@example.com",
+			expError: response.ErrMsgUserAlreadyExists,
+		},
+		"invalid_email": {
+			email:    "Andy <andy@example.com>",
+			expError: response.ErrMsgInvalidEmail,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{})
+			if tc.expEmail != "" {
+				mockRepo.On("CreateUser", mock.Anything, tc.expName, tc.expEmail).Return(tc.created, nil).Once()
+			}
+
+			// When
+			user, err := userService.CreateUser(context.Background(), tc.name, tc.email)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.created, user)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestDeleteUser tests the DeleteUser method of the UserService.
+func TestDeleteUser(t *testing.T) {
+	tcs := map[string]struct {
+		deleted  bool   // Whether the repository finds the user
+		expError string // Expected error message
+	}{
+		"success": {
+			deleted: true,
+		},
+		"user_not_found": {
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{})
+			if tc.deleted {
+				mockRepo.On("SetUserStatus", mock.Anything, "andy@example.com", repository.UserStatusDeleted).
+					Return(&models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusDeleted}, nil).Once()
+			} else {
+				mockRepo.On("SetUserStatus", mock.Anything, "andy@example.com", repository.UserStatusDeleted).Return(nil, nil).Once()
+			}
+
+			// When
+			err := userService.DeleteUser(context.Background(), " Andy@example.com")
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestSetUserStatus tests the SetUserStatus method of the UserService.
+func TestSetUserStatus(t *testing.T) {
+	tcs := map[string]struct {
+		status   string       // Requested account state
+		user     *models.User // User returned by the repository, nil if not found
+		expCall  bool         // Whether the repository is expected to be called
+		expError string       // Expected error message
+	}{
+		"deactivate": {
+			status:  repository.UserStatusDeactivated,
+			user:    &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusDeactivated},
+			expCall: true,
+		},
+		"restore": {
+			status:  repository.UserStatusActive,
+			user:    &models.User{ID: 1, Email: "andy@example.com", Status: repository.UserStatusActive},
+			expCall: true,
+		},
+		"invalid_status": {
+			status:   "banned",
+			expError: response.ErrMsgInvalidUserStatus,
+		},
+		"user_not_found": {
+			status:   repository.UserStatusSuspended,
+			expCall:  true,
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{})
+			if tc.expCall {
+				if tc.user != nil {
+					mockRepo.On("SetUserStatus", mock.Anything, "andy@example.com", tc.status).Return(tc.user, nil).Once()
+				} else {
+					mockRepo.On("SetUserStatus", mock.Anything, "andy@example.com", tc.status).Return(nil, nil).Once()
+				}
+			}
+
+			// When
+			user, err := userService.SetUserStatus(context.Background(), "Andy@example.com", tc.status)
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.user, user)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestPurgeDeletedUsers tests that the PurgeDeletedUsers method of the UserService purges
+// batches until one is not full.
+func TestPurgeDeletedUsers(t *testing.T) {
+	tcs := map[string]struct {
+		batches  []int // Users purged by each call to the repository
+		err      error // Error returned by the last call to the repository
+		expTotal int   // Expected number of users purged
+	}{
+		"single_batch": {
+			batches:  []int{3},
+			expTotal: 3,
+		},
+		"several_batches": {
+			batches:  []int{purgeBatchSize, purgeBatchSize, 0},
+			expTotal: 2 * purgeBatchSize,
+		},
+		"error": {
+			batches:  []int{purgeBatchSize, 0},
+			err:      errors.New(response.ErrMsgPurgeUsers),
+			expTotal: purgeBatchSize,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{})
+			for i, n := range tc.batches {
+				var err error
+				if i == len(tc.batches)-1 {
+					err = tc.err
+				}
+				mockRepo.On("PurgeDeletedUsers", mock.Anything, before, purgeBatchSize).Return(n, err).Once()
+			}
+
+			// When
+			total, err := userService.PurgeDeletedUsers(context.Background(), before)
+
+			// Then
+			if tc.err != nil {
+				require.EqualError(t, err, tc.err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expTotal, total)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestExportUserData tests the ExportUserData method of the UserService.
+func TestExportUserData(t *testing.T) {
+	tcs := map[string]struct {
+		archive  *repository.UserArchive // Archive returned by the repository
+		err      error                   // Error returned by the repository
+		expError string                  // Expected error message
+	}{
+		"success": {
+			archive: &repository.UserArchive{
+				Friends: []repository.ArchiveLink{{Email: "john@example.com"}},
+			},
+		},
+		"user_not_found": {
+			err:      errors.New(response.ErrMsgUserNotFound),
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{})
+			if tc.err != nil {
+				mockRepo.On("GetUserArchive", mock.Anything, "andy@example.com").Return(nil, tc.err).Once()
+			} else {
+				mockRepo.On("GetUserArchive", mock.Anything, "andy@example.com").Return(tc.archive, nil).Once()
+			}
+
+			// When
+			archive, err := userService.ExportUserData(context.Background(), "Andy@Example.com")
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.archive, archive)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestEraseUser tests the EraseUser method of the UserService.
+func TestEraseUser(t *testing.T) {
+	// HMAC-SHA256 of andy@example.com keyed with the erasure key
+	const erasureKey = "test-erasure-key-test-erasure-key"
+	const emailHash = "9c28c018931021d82ead709d68eca35b858d662f52a5544ce3d1bd19be43185f"
+
+	tcs := map[string]struct {
+		mode     string // Requested erasure mode
+		err      error  // Error returned by the repository
+		expMode  string // Expected mode passed to the repository, empty if not called
+		expError string // Expected error message
+	}{
+		"default_mode": {
+			expMode: repository.ErasureModeDelete,
+		},
+		"anonymize": {
+			mode:    repository.ErasureModeAnonymize,
+			expMode: repository.ErasureModeAnonymize,
+		},
+		"invalid_mode": {
+			mode:     "shred",
+			expError: response.ErrMsgInvalidErasureMode,
+		},
+		"user_not_found": {
+			expMode:  repository.ErasureModeDelete,
+			err:      errors.New(response.ErrMsgUserNotFound),
+			expError: response.ErrMsgUserNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockUserRepo)
+			userService := NewUserService(mockRepo, config.Accounts{ErasureKey: erasureKey})
+			if tc.expMode != "" {
+				mockRepo.On("EraseUser", mock.Anything, "andy@example.com", mock.MatchedBy(func(e *repository.Erasure) bool {
+					return e.Mode == tc.expMode && e.Reason == "requested by user" && e.EmailHash == emailHash
+				})).Run(func(args mock.Arguments) {
+					args.Get(2).(*repository.Erasure).ID = 1
+				}).Return(tc.err).Once()
+			}
+
+			// When
+			erasure, err := userService.EraseUser(context.Background(), "Andy@Example.com", tc.mode, " requested by user ")
+
+			// Then
+			if tc.expError != "" {
+				require.EqualError(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), erasure.ID)
+				require.Equal(t, tc.expMode, erasure.Mode)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/netguard"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/pkg/tracing"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// secretBytes is the length of the generated webhook secrets before hex encoding.
+const secretBytes = 32
+
+// RegisterWebhook registers an endpoint notified of the events of the given types about the
+// given users. Empty filters match everything. The returned webhook holds the generated secret
+// used to sign the payloads; it is not returned again.
+func (serv *webhookService) RegisterWebhook(ctx context.Context, rawURL string, eventTypes, emails []string) (*repository.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.RegisterWebhook")
+	defer span.End()
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New(response.ErrMsgInvalidWebhookURL)
+	}
+	if !serv.allowPrivateNetworks {
+		// Deliveries check the dialled address again, as DNS records may change
+		if err := netguard.CheckHost(ctx, serv.resolver, u.Hostname()); err != nil {
+			logger.FromContext(ctx).Warn().Err(err).Str("url", rawURL).Msg("Refused webhook URL")
+			return nil, errors.New(response.ErrMsgForbiddenWebhookURL)
+		}
+	}
+	for _, t := range eventTypes {
+		if !events.IsValidType(t) {
+			return nil, errors.Errorf("%s: %s", response.ErrMsgInvalidEventType, t)
+		}
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, response.ErrMsgCreateWebhook)
+	}
+
+	webhook := &repository.Webhook{
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Emails:     emailaddr.NormalizeAll(emails),
+	}
+	if err := serv.repo.CreateWebhook(ctx, webhook); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("url", rawURL).Msg("Failed to create webhook")
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks retrieves the registered webhooks.
+func (serv *webhookService) ListWebhooks(ctx context.Context) ([]repository.Webhook, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListWebhooks")
+	defer span.End()
+
+	webhooks, err := serv.repo.ListWebhooks(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Msg("Failed to list webhooks")
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []repository.Webhook{}
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes the webhook. Its pending deliveries are dropped.
+func (serv *webhookService) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	if err := serv.repo.DeleteWebhook(ctx, id); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Int("webhook_id", id).Msg("Failed to delete webhook")
+		return err
+	}
+	return nil
+}
+
+// ListDeliveries retrieves the delivery log of the webhook, optionally filtered by status.
+func (serv *webhookService) ListDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]repository.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	switch status {
+	case "", repository.DeliveryStatusPending, repository.DeliveryStatusDelivered, repository.DeliveryStatusDead:
+	default:
+		return nil, errors.Errorf("%s: unknown status %q", response.ErrMsgGetWebhookDeliveries, status)
+	}
+
+	// Make sure the webhook exists so an unknown ID is not mistaken for an empty log.
+	if _, err := serv.repo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return serv.repo.ListWebhookDeliveries(ctx, webhookID, status, limit, offset)
+}
+
+// ListDeadLetters retrieves the deliveries of the webhook that exhausted their retries.
+func (serv *webhookService) ListDeadLetters(ctx context.Context, webhookID int, limit, offset int) ([]repository.WebhookDeadLetter, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeadLetters")
+	defer span.End()
+
+	if _, err := serv.repo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return serv.repo.ListWebhookDeadLetters(ctx, webhookID, limit, offset)
+}
+
+// newSecret generates a random webhook secret.
+func newSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
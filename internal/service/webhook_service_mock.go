@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookService is a mock implementation of WebhookService interface.
+type MockWebhookService struct {
+	mock.Mock
+}
+
+// RegisterWebhook mocks the RegisterWebhook method of the WebhookService interface.
+func (m *MockWebhookService) RegisterWebhook(ctx context.Context, url string, eventTypes, emails []string) (*repository.Webhook, error) {
+	args := m.Called(ctx, url, eventTypes, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.Webhook), args.Error(1)
+}
+
+// ListWebhooks mocks the ListWebhooks method of the WebhookService interface.
+func (m *MockWebhookService) ListWebhooks(ctx context.Context) ([]repository.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]repository.Webhook), args.Error(1)
+}
+
+// DeleteWebhook mocks the DeleteWebhook method of the WebhookService interface.
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// ListDeliveries mocks the ListDeliveries method of the WebhookService interface.
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, webhookID int, status string, limit, offset int) ([]repository.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, status, limit, offset)
+	return args.Get(0).([]repository.WebhookDelivery), args.Error(1)
+}
+
+// ListDeadLetters mocks the ListDeadLetters method of the WebhookService interface.
+func (m *MockWebhookService) ListDeadLetters(ctx context.Context, webhookID int, limit, offset int) ([]repository.WebhookDeadLetter, error) {
+	args := m.Called(ctx, webhookID, limit, offset)
+	return args.Get(0).([]repository.WebhookDeadLetter), args.Error(1)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver resolves the hosts it knows, example.com to a public address by default.
+type stubResolver map[string]string
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ip, ok := r[host]
+	if !ok {
+		ip = "93.184.216.34"
+	}
+	return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+}
+
+// TestRegisterWebhook tests the RegisterWebhook method of the WebhookService.
+func TestRegisterWebhook(t *testing.T) {
+	tcs := map[string]struct {
+		url        string   // Endpoint URL
+		eventTypes []string // Event type filter
+		emails     []string // Email filter
+		expCreate  bool     // Whether CreateWebhook is expected to be called
+		createErr  error    // Error returned by CreateWebhook
+		expEmails  []string // Expected stored email filter
+		expError   string   // Expected error message
+	}{
+		"success": {
+			url:        "https://example.com/hooks",
+			eventTypes: []string{events.Blocked},
+			emails:     []string{" John@Example.com"},
+			expCreate:  true,
+			expEmails:  []string{"john@example.com"},
+		},
+		"invalid_url": {
+			url:      "ftp://example.com",
+			expError: response.ErrMsgInvalidWebhookURL,
+		},
+		"relative_url": {
+			url:      "/hooks",
+			expError: response.ErrMsgInvalidWebhookURL,
+		},
+		"loopback_url": {
+			url:      "http://127.0.0.1:8080/hooks",
+			expError: response.ErrMsgForbiddenWebhookURL,
+		},
+		"metadata_url": {
+			url:      "http://169.254.169.254/latest/meta-data",
+			expError: response.ErrMsgForbiddenWebhookURL,
+		},
+		"host_resolving_to_private_address": {
+			url:      "https://intranet.example.com/hooks",
+			expError: response.ErrMsgForbiddenWebhookURL,
+		},
+		"unknown_event_type": {
+			url:        "https://example.com/hooks",
+			eventTypes: []string{"Unfriended"},
+			expError:   response.ErrMsgInvalidEventType,
+		},
+		"create_error": {
+			url:       "https://example.com/hooks",
+			expCreate: true,
+			expEmails: []string{},
+			createErr: errors.New(response.ErrMsgCreateWebhook),
+			expError:  response.ErrMsgCreateWebhook,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockWebhookRepo)
+			webhookService := &webhookService{
+				repo:     mockRepo,
+				resolver: stubResolver{"intranet.example.com": "10.0.0.5"},
+			}
+
+			if tc.expCreate {
+				mockRepo.On("CreateWebhook", mock.Anything, mock.MatchedBy(func(w *repository.Webhook) bool {
+					return w.URL == tc.url && len(w.Secret) == 2*secretBytes && len(w.Emails) == len(tc.expEmails) &&
+						(len(tc.expEmails) == 0 || w.Emails[0] == tc.expEmails[0])
+				})).Return(tc.createErr).Once()
+			}
+
+			// When
+			webhook, err := webhookService.RegisterWebhook(context.Background(), tc.url, tc.eventTypes, tc.emails)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+				require.Nil(t, webhook)
+			} else {
+				require.NoError(t, err)
+				require.NotEmpty(t, webhook.Secret)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestListDeliveries tests the ListDeliveries method of the WebhookService.
+func TestListDeliveries(t *testing.T) {
+	tcs := map[string]struct {
+		status   string // Status filter
+		getErr   error  // Error returned by GetWebhook
+		expList  bool   // Whether ListWebhookDeliveries is expected to be called
+		expError string // Expected error message
+	}{
+		"success": {
+			status:  repository.DeliveryStatusDead,
+			expList: true,
+		},
+		"invalid_status": {
+			status:   "lost",
+			expError: response.ErrMsgGetWebhookDeliveries,
+		},
+		"webhook_not_found": {
+			getErr:   errors.New(response.ErrMsgWebhookNotFound),
+			expError: response.ErrMsgWebhookNotFound,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockRepo := new(repository.MockWebhookRepo)
+			webhookService := NewWebhookService(mockRepo, config.Webhooks{})
+
+			if tc.status != "lost" {
+				mockRepo.On("GetWebhook", mock.Anything, 1).Return(&repository.Webhook{ID: 1}, tc.getErr).Once()
+			}
+			if tc.expList {
+				mockRepo.On("ListWebhookDeliveries", mock.Anything, 1, tc.status, 20, 0).
+					Return([]repository.WebhookDelivery{{ID: 5, WebhookID: 1}}, nil).Once()
+			}
+
+			// When
+			deliveries, err := webhookService.ListDeliveries(context.Background(), 1, tc.status, 20, 0)
+
+			// Then
+			if tc.expError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expError)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, deliveries, 1)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package stats repairs the counters of the relations of the users.
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/service"
+)
+
+// Reconciler periodically recounts the relations of the users to repair the counters that
+// drifted from them, e.g. after relations were changed with the triggers disabled.
+type Reconciler struct {
+	svc      service.StatsService
+	interval time.Duration
+}
+
+// NewReconciler creates a new Reconciler.
+func NewReconciler(svc service.StatsService, cfg config.Stats) *Reconciler {
+	return &Reconciler{svc: svc, interval: cfg.ReconcileInterval}
+}
+
+// Run reconciles the counters until ctx is cancelled.
+func (r *Reconciler) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Dur("interval", r.interval).Msg("Starting user stats reconciliation")
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.ReconcileOnce(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to reconcile user stats")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping user stats reconciliation")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileOnce repairs the drifted counters, returning how many users had theirs repaired.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (int, error) {
+	return r.svc.ReconcileUserStats(ctx)
+}
@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/response"
+	"github.com/boldnguyen/friend-management/internal/service"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReconciler_ReconcileOnce tests the ReconcileOnce method of the Reconciler.
+func TestReconciler_ReconcileOnce(t *testing.T) {
+	tcs := map[string]struct {
+		repaired int   // Counters repaired by the service
+		err      error // Error returned by the service
+	}{
+		"success": {
+			repaired: 2,
+		},
+		"service_error": {
+			err: errors.New(response.ErrMsgReconcileUserStats),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			mockService := new(service.MockStatsService)
+			reconciler := NewReconciler(mockService, config.Stats{ReconcileInterval: time.Hour})
+			mockService.On("ReconcileUserStats", mock.Anything).Return(tc.repaired, tc.err).Once()
+
+			// When
+			n, err := reconciler.ReconcileOnce(context.Background())
+
+			// Then
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.repaired, n)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// TestReconciler_Run tests that the Reconciler reconciles on start and stops with its context.
+func TestReconciler_Run(t *testing.T) {
+	// Given
+	mockService := new(service.MockStatsService)
+	reconciler := NewReconciler(mockService, config.Stats{ReconcileInterval: time.Hour})
+
+	done := make(chan struct{})
+	mockService.On("ReconcileUserStats", mock.Anything).Run(func(mock.Arguments) { close(done) }).Return(0, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		reconciler.Run(ctx)
+		close(stopped)
+	}()
+
+	// When
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("user stats were not reconciled")
+	}
+	cancel()
+
+	// Then
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("reconciler did not stop")
+	}
+	mockService.AssertExpectations(t)
+}
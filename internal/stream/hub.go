@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/pkg/metrics"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrTooManyConnections is returned by Subscribe when the user reached the connection limit.
+	ErrTooManyConnections = errors.New("too many open streams for this user")
+	// ErrSlowConsumer is the reason a subscriber is disconnected when its buffer is full.
+	ErrSlowConsumer = errors.New("stream buffer overflow")
+	// ErrHubClosed is the reason a subscriber is disconnected when the hub shuts down.
+	ErrHubClosed = errors.New("server shutting down")
+)
+
+// Hub is an in-process publish/subscribe hub pushing updates to the streams of the
+// connected users. Publishing never blocks: every subscriber has a bounded buffer and
+// a subscriber that falls behind is disconnected, leaving the client to reconnect and
+// catch up from its inbox.
+type Hub struct {
+	bufferSize      int
+	maxConnsPerUser int
+
+	mu     sync.Mutex
+	subs   map[string]map[*Subscriber]struct{}
+	closed bool
+}
+
+// Subscriber is a single stream of a connected user.
+type Subscriber struct {
+	email string
+	// C delivers the updates sent to the user.
+	C    chan repository.InboxItem
+	done chan struct{}
+	err  error
+	once sync.Once
+}
+
+// Done is closed when the subscriber is disconnected by the hub, see Err for the reason.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the hub disconnected the subscriber once Done is closed.
+func (s *Subscriber) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// close disconnects the subscriber for the given reason.
+func (s *Subscriber) close(reason error) {
+	s.once.Do(func() {
+		s.err = reason
+		close(s.done)
+	})
+}
+
+// NewHub creates a new Hub.
+func NewHub(cfg config.Stream) *Hub {
+	return &Hub{
+		bufferSize:      cfg.BufferSize,
+		maxConnsPerUser: cfg.MaxConnectionsPerUser,
+		subs:            map[string]map[*Subscriber]struct{}{},
+	}
+}
+
+// Subscribe opens a stream for the user.
+func (h *Hub) Subscribe(email string) (*Subscriber, error) {
+	email = emailaddr.Normalize(email)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrHubClosed
+	}
+	if h.maxConnsPerUser > 0 && len(h.subs[email]) >= h.maxConnsPerUser {
+		return nil, ErrTooManyConnections
+	}
+
+	sub := &Subscriber{
+		email: email,
+		C:     make(chan repository.InboxItem, h.bufferSize),
+		done:  make(chan struct{}),
+	}
+	if h.subs[email] == nil {
+		h.subs[email] = map[*Subscriber]struct{}{}
+	}
+	h.subs[email][sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Unsubscribe closes the stream.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub, nil)
+}
+
+// Close disconnects every subscriber and rejects new ones. It lets the streams
+// end so a graceful shutdown of the HTTP server does not wait for them.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub, ErrHubClosed)
+		}
+	}
+}
+
+// Publish pushes the update to the streams of the recipients that are connected.
+func (h *Hub) Publish(recipients []string, item repository.InboxItem) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, email := range recipients {
+		for sub := range h.subs[emailaddr.Normalize(email)] {
+			select {
+			case sub.C <- item:
+			default:
+				// The buffer is full: drop the slow consumer rather than blocking the publisher
+				h.remove(sub, ErrSlowConsumer)
+				metrics.StreamDropped.Inc()
+			}
+		}
+	}
+}
+
+// Connections returns the number of open streams.
+func (h *Hub) Connections() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	for _, subs := range h.subs {
+		n += len(subs)
+	}
+	return n
+}
+
+// remove unregisters and closes the subscriber. h.mu must be held.
+func (h *Hub) remove(sub *Subscriber, reason error) {
+	subs := h.subs[sub.email]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.email)
+	}
+	sub.close(reason)
+}
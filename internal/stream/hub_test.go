@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHub_Publish tests that updates only reach the streams of their recipients.
+func TestHub_Publish(t *testing.T) {
+	// Given
+	hub := NewHub(config.Stream{BufferSize: 4})
+	john, err := hub.Subscribe("John@example.com")
+	require.NoError(t, err)
+	kate, err := hub.Subscribe("kate@example.com")
+	require.NoError(t, err)
+
+	// When
+	hub.Publish([]string{"john@example.com", "lisa@example.com"}, repository.InboxItem{UpdateID: 1})
+
+	// Then
+	require.Len(t, john.C, 1)
+	require.Equal(t, int64(1), (<-john.C).UpdateID)
+	require.Len(t, kate.C, 0)
+}
+
+// TestHub_SlowConsumer tests that a stream whose buffer is full is disconnected without blocking the publisher.
+func TestHub_SlowConsumer(t *testing.T) {
+	// Given
+	hub := NewHub(config.Stream{BufferSize: 1})
+	sub, err := hub.Subscribe("john@example.com")
+	require.NoError(t, err)
+
+	// When
+	hub.Publish([]string{"john@example.com"}, repository.InboxItem{UpdateID: 1})
+	hub.Publish([]string{"john@example.com"}, repository.InboxItem{UpdateID: 2})
+
+	// Then
+	<-sub.Done()
+	require.Equal(t, ErrSlowConsumer, sub.Err())
+	require.Equal(t, 0, hub.Connections())
+}
+
+// TestHub_Subscribe tests the connection limits of the Hub.
+func TestHub_Subscribe(t *testing.T) {
+	tcs := map[string]struct {
+		maxConns int   // Maximum streams per user
+		open     int   // Streams already open by the user
+		closed   bool  // Whether the hub is closed
+		expErr   error // Expected error
+	}{
+		"success":              {maxConns: 2, open: 1},
+		"unlimited":            {maxConns: 0, open: 10},
+		"too_many_connections": {maxConns: 2, open: 2, expErr: ErrTooManyConnections},
+		"hub_closed":           {maxConns: 2, closed: true, expErr: ErrHubClosed},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			hub := NewHub(config.Stream{BufferSize: 1, MaxConnectionsPerUser: tc.maxConns})
+			for i := 0; i < tc.open; i++ {
+				_, err := hub.Subscribe("john@example.com")
+				require.NoError(t, err)
+			}
+			if tc.closed {
+				hub.Close()
+			}
+
+			// When
+			sub, err := hub.Subscribe("john@example.com")
+
+			// Then
+			require.Equal(t, tc.expErr, err)
+			if tc.expErr == nil {
+				require.NotNil(t, sub)
+				hub.Unsubscribe(sub)
+				require.Equal(t, tc.open, hub.Connections())
+			}
+		})
+	}
+}
+
+// TestHub_Close tests that closing the hub disconnects every stream.
+func TestHub_Close(t *testing.T) {
+	// Given
+	hub := NewHub(config.Stream{BufferSize: 1})
+	sub, err := hub.Subscribe("john@example.com")
+	require.NoError(t, err)
+
+	// When
+	hub.Close()
+
+	// Then
+	<-sub.Done()
+	require.Equal(t, ErrHubClosed, sub.Err())
+	require.Equal(t, 0, hub.Connections())
+}
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/outbox"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/logger"
+	"github.com/boldnguyen/friend-management/internal/pkg/netguard"
+	"github.com/boldnguyen/friend-management/internal/repository"
+)
+
+// maxErrorBody is how much of an error response body is kept in the delivery log.
+const maxErrorBody = 512
+
+// Deliverer sends the enqueued deliveries to the webhook endpoints. A delivery that
+// fails is retried with an exponential backoff and dead-lettered after MaxAttempts.
+type Deliverer struct {
+	repo         repository.WebhookRepository
+	client       *http.Client
+	maxAttempts  int
+	pollInterval time.Duration
+	batchSize    int
+	timeout      time.Duration
+	now          func() time.Time
+}
+
+// NewDeliverer creates a new Deliverer. Unless cfg.AllowPrivateNetworks is set, it refuses
+// to connect to addresses that are not publicly routable, whatever the webhook URL resolves
+// or redirects to.
+func NewDeliverer(repo repository.WebhookRepository, cfg config.Webhooks) *Deliverer {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateNetworks {
+		// The check applies to the dialled address, which a proxy would hide
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   cfg.Timeout,
+			KeepAlive: 30 * time.Second,
+			Control:   netguard.Control,
+		}).DialContext
+	}
+
+	return &Deliverer{
+		repo:         repo,
+		client:       &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		maxAttempts:  cfg.MaxAttempts,
+		pollInterval: cfg.PollInterval,
+		batchSize:    cfg.BatchSize,
+		timeout:      cfg.Timeout,
+		now:          time.Now,
+	}
+}
+
+// Run delivers webhooks until ctx is cancelled.
+func (d *Deliverer) Run(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	log.Info().Msg("Starting webhook deliverer")
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick.
+		for {
+			n, err := d.DeliverOnce(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to deliver webhooks")
+				break
+			}
+			if n < d.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Stopping webhook deliverer")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverOnce claims a batch of due deliveries and sends them, returning how many were claimed.
+func (d *Deliverer) DeliverOnce(ctx context.Context) (int, error) {
+	// Deliveries stay claimed for as long as sending the whole batch may take.
+	lease := time.Duration(d.batchSize+1) * d.timeout
+
+	due, err := d.repo.ClaimDueWebhookDeliveries(ctx, d.batchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range due {
+		statusCode, err := d.send(ctx, delivery)
+		if err == nil {
+			if err := d.repo.MarkWebhookDelivered(ctx, delivery.ID, statusCode); err != nil {
+				return len(due), err
+			}
+			continue
+		}
+
+		log := logger.FromContext(ctx).Warn().Err(err).Int64("delivery_id", delivery.ID).
+			Int("webhook_id", delivery.WebhookID).Int64("event_id", delivery.EventID).Int("attempts", delivery.Attempts+1)
+
+		if delivery.Attempts+1 >= d.maxAttempts {
+			log.Msg("Webhook delivery exhausted its retries")
+			if err := d.repo.DeadLetterWebhookDelivery(ctx, delivery.ID, statusCode, err.Error()); err != nil {
+				return len(due), err
+			}
+			continue
+		}
+
+		next := d.now().Add(outbox.Backoff(delivery.Attempts))
+		log.Time("next_attempt_at", next).Msg("Failed to deliver webhook")
+		if err := d.repo.MarkWebhookDeliveryFailed(ctx, delivery.ID, statusCode, err.Error(), next); err != nil {
+			return len(due), err
+		}
+	}
+
+	return len(due), nil
+}
+
+// send POSTs the signed event to the webhook, returning the response status code,
+// or 0 when no response was received. Any non-2xx response is an error.
+func (d *Deliverer) send(ctx context.Context, delivery repository.DueWebhookDelivery) (int, error) {
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, strconv.Itoa(delivery.WebhookID))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderEventID, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("webhook responded %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/outbox"
+	"github.com/boldnguyen/friend-management/internal/pkg/config"
+	"github.com/boldnguyen/friend-management/internal/pkg/netguard"
+	"github.com/boldnguyen/friend-management/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeliverer_DeliverOnce tests the DeliverOnce method of the Deliverer against a real HTTP receiver.
+func TestDeliverer_DeliverOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":7,"type":"Blocked","payload":{"requestor":"a@example.com","target":"b@example.com"}}`)
+
+	tcs := map[string]struct {
+		status      int  // Status code returned by the receiver
+		attempts    int  // Failed attempts before this one
+		expDelivery bool // Whether the delivery is expected to succeed
+		expDead     bool // Whether the delivery is expected to be dead-lettered
+	}{
+		"success": {
+			status:      http.StatusNoContent,
+			expDelivery: true,
+		},
+		"server_error_schedules_retry": {
+			status:   http.StatusInternalServerError,
+			attempts: 1,
+		},
+		"last_attempt_dead_letters": {
+			status:   http.StatusBadGateway,
+			attempts: 2,
+			expDead:  true,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Given
+			var received *http.Request
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			mockRepo := new(repository.MockWebhookRepo)
+			deliverer := NewDeliverer(mockRepo, config.Webhooks{MaxAttempts: 3, BatchSize: 10, Timeout: time.Second, AllowPrivateNetworks: true})
+			deliverer.now = func() time.Time { return now }
+
+			due := repository.DueWebhookDelivery{
+				WebhookDelivery: repository.WebhookDelivery{
+					ID: 11, WebhookID: 3, EventID: 7, EventType: events.Blocked, Payload: payload, Attempts: tc.attempts,
+				},
+				URL:    srv.URL,
+				Secret: "s3cret",
+			}
+			mockRepo.On("ClaimDueWebhookDeliveries", mock.Anything, 10, mock.AnythingOfType("time.Duration")).
+				Return([]repository.DueWebhookDelivery{due}, nil).Once()
+
+			switch {
+			case tc.expDelivery:
+				mockRepo.On("MarkWebhookDelivered", mock.Anything, int64(11), tc.status).Return(nil).Once()
+			case tc.expDead:
+				mockRepo.On("DeadLetterWebhookDelivery", mock.Anything, int64(11), tc.status, mock.AnythingOfType("string")).Return(nil).Once()
+			default:
+				mockRepo.On("MarkWebhookDeliveryFailed", mock.Anything, int64(11), tc.status, mock.AnythingOfType("string"),
+					now.Add(outbox.Backoff(tc.attempts))).Return(nil).Once()
+			}
+
+			// When
+			n, err := deliverer.DeliverOnce(context.Background())
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+			require.NotNil(t, received)
+			require.Equal(t, payload, body)
+			require.Equal(t, "3", received.Header.Get(HeaderWebhookID))
+			require.Equal(t, "7", received.Header.Get(HeaderEventID))
+			require.Equal(t, "11", received.Header.Get(HeaderDeliveryID))
+			require.Equal(t, events.Blocked, received.Header.Get(HeaderEventType))
+			require.Equal(t, strconv.FormatInt(now.Unix(), 10), received.Header.Get(HeaderTimestamp))
+			require.Equal(t, "sha256="+Sign("s3cret", now.Unix(), payload), received.Header.Get(HeaderSignature))
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestDeliverer_DeliverOnce_Unreachable tests that a delivery to an unreachable endpoint is retried without a status code.
+func TestDeliverer_DeliverOnce_Unreachable(t *testing.T) {
+	// Given
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	mockRepo := new(repository.MockWebhookRepo)
+	deliverer := NewDeliverer(mockRepo, config.Webhooks{MaxAttempts: 3, BatchSize: 10, Timeout: time.Second, AllowPrivateNetworks: true})
+
+	due := repository.DueWebhookDelivery{
+		WebhookDelivery: repository.WebhookDelivery{ID: 1, WebhookID: 1, EventID: 1, Payload: []byte(`{}`)},
+		URL:             url,
+	}
+	mockRepo.On("ClaimDueWebhookDeliveries", mock.Anything, 10, mock.AnythingOfType("time.Duration")).
+		Return([]repository.DueWebhookDelivery{due}, nil).Once()
+	mockRepo.On("MarkWebhookDeliveryFailed", mock.Anything, int64(1), 0, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+		Return(nil).Once()
+
+	// When
+	_, err := deliverer.DeliverOnce(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestDeliverer_DeliverOnce_PrivateNetwork tests that a delivery to a loopback endpoint is refused.
+func TestDeliverer_DeliverOnce_PrivateNetwork(t *testing.T) {
+	// Given
+	var received bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer srv.Close()
+
+	mockRepo := new(repository.MockWebhookRepo)
+	deliverer := NewDeliverer(mockRepo, config.Webhooks{MaxAttempts: 3, BatchSize: 10, Timeout: time.Second})
+
+	due := repository.DueWebhookDelivery{
+		WebhookDelivery: repository.WebhookDelivery{ID: 1, WebhookID: 1, EventID: 1, Payload: []byte(`{}`)},
+		URL:             srv.URL,
+	}
+	mockRepo.On("ClaimDueWebhookDeliveries", mock.Anything, 10, mock.AnythingOfType("time.Duration")).
+		Return([]repository.DueWebhookDelivery{due}, nil).Once()
+	mockRepo.On("MarkWebhookDeliveryFailed", mock.Anything, int64(1), 0, mock.MatchedBy(func(msg string) bool {
+		return strings.Contains(msg, netguard.ErrForbiddenAddress.Error())
+	}), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	// When
+	_, err := deliverer.DeliverOnce(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	require.False(t, received)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestMatches tests the filtering of events by webhook.
+func TestMatches(t *testing.T) {
+	evt, err := events.New(events.Subscribed, events.SubscribedPayload{Requestor: "lisa@example.com", Target: "john@example.com"})
+	require.NoError(t, err)
+
+	tcs := map[string]struct {
+		webhook  repository.Webhook
+		expMatch bool
+	}{
+		"no_filters":         {webhook: repository.Webhook{}, expMatch: true},
+		"matching_type":      {webhook: repository.Webhook{EventTypes: []string{events.Subscribed}}, expMatch: true},
+		"other_type":         {webhook: repository.Webhook{EventTypes: []string{events.Blocked}}, expMatch: false},
+		"matching_email":     {webhook: repository.Webhook{Emails: []string{"john@example.com"}}, expMatch: true},
+		"other_email":        {webhook: repository.Webhook{Emails: []string{"kate@example.com"}}, expMatch: false},
+		"type_and_email":     {webhook: repository.Webhook{EventTypes: []string{events.Subscribed}, Emails: []string{"lisa@example.com"}}, expMatch: true},
+		"type_but_not_email": {webhook: repository.Webhook{EventTypes: []string{events.Subscribed}, Emails: []string{"kate@example.com"}}, expMatch: false},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			require.Equal(t, tc.expMatch, Matches(tc.webhook, evt))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/boldnguyen/friend-management/internal/events"
+	"github.com/boldnguyen/friend-management/internal/pkg/emailaddr"
+	"github.com/boldnguyen/friend-management/internal/repository"
+)
+
+// Headers set on every webhook request.
+const (
+	HeaderWebhookID  = "X-Webhook-Id"
+	HeaderEventType  = "X-Event-Type"
+	HeaderEventID    = "X-Event-Id"
+	HeaderDeliveryID = "X-Delivery-Id"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of a webhook request: the hex encoded HMAC-SHA256, keyed with
+// the webhook secret, of the Unix timestamp and the body joined by a dot. It is sent as
+// "sha256=<signature>" in the X-Webhook-Signature header, the timestamp in X-Webhook-Timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Matches reports whether the webhook subscribed to the event.
+func Matches(w repository.Webhook, event events.Event) bool {
+	if len(w.EventTypes) > 0 && !contains(w.EventTypes, event.Type) {
+		return false
+	}
+	if len(w.Emails) == 0 {
+		return true
+	}
+	for _, subject := range event.Subjects() {
+		if contains(w.Emails, emailaddr.Normalize(subject)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sink schedules a delivery of every outbox event to the webhooks subscribed to it.
+type sink struct {
+	repo repository.WebhookRepository
+}
+
+// NewSink creates an events.Sink enqueuing the events for the Deliverer.
+func NewSink(repo repository.WebhookRepository) events.Sink {
+	return &sink{repo: repo}
+}
+
+// Name returns the name of the sink.
+func (s *sink) Name() string {
+	return "webhooks"
+}
+
+// Publish enqueues a delivery of the event to each matching webhook.
+func (s *sink) Publish(ctx context.Context, event events.Event) error {
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		if !Matches(w, event) {
+			continue
+		}
+		if err := s.repo.EnqueueWebhookDelivery(ctx, w.ID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contains reports whether s is in list.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}